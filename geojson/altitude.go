@@ -0,0 +1,410 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// AltitudeFlag is set on the type prefix of the encoding of a shape
+// whose positions have ordinates beyond the longitude and latitude,
+// such as an altitude. The positions of the shape then follow its s2
+// encoding. Shapes with two dimensional positions are encoded without
+// the flag, exactly as the doc values written before it existed, so
+// both decode the same way.
+const AltitudeFlag = byte(0x80)
+
+var errInvalidPositions = errors.New("invalid encoding of the positions")
+
+// positionedShape is implemented by the shapes whose positions are kept
+// in their encoding when they have extra ordinates. The positions are
+// nested in as many slices as needed to hold those of a multipolygon.
+type positionedShape interface {
+	positions() [][][][]float64
+	setPositions(coords [][][][]float64) error
+}
+
+func (p *Point) positions() [][][][]float64 {
+	return [][][][]float64{{{p.Vertices}}}
+}
+
+func (p *Point) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 || len(coords[0]) != 1 || len(coords[0][0]) != 1 {
+		return errInvalidPositions
+	}
+	p.Vertices = coords[0][0][0]
+	return nil
+}
+
+func (mp *MultiPoint) positions() [][][][]float64 {
+	return [][][][]float64{{mp.Vertices}}
+}
+
+func (mp *MultiPoint) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 || len(coords[0]) != 1 {
+		return errInvalidPositions
+	}
+	mp.Vertices = coords[0][0]
+	return nil
+}
+
+func (ls *LineString) positions() [][][][]float64 {
+	return [][][][]float64{{ls.Vertices}}
+}
+
+func (ls *LineString) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 || len(coords[0]) != 1 {
+		return errInvalidPositions
+	}
+	ls.Vertices = coords[0][0]
+	return nil
+}
+
+func (mls *MultiLineString) positions() [][][][]float64 {
+	return [][][][]float64{mls.Vertices}
+}
+
+func (mls *MultiLineString) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 {
+		return errInvalidPositions
+	}
+	mls.Vertices = coords[0]
+	return nil
+}
+
+func (pg *Polygon) positions() [][][][]float64 {
+	return [][][][]float64{pg.Vertices}
+}
+
+func (pg *Polygon) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 {
+		return errInvalidPositions
+	}
+	pg.Vertices = coords[0]
+	return nil
+}
+
+func (mp *MultiPolygon) positions() [][][][]float64 {
+	return mp.Vertices
+}
+
+func (mp *MultiPolygon) setPositions(coords [][][][]float64) error {
+	mp.Vertices = coords
+	return nil
+}
+
+func (c *Circle) positions() [][][][]float64 {
+	return [][][][]float64{{{c.Vertices}}}
+}
+
+func (c *Circle) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 || len(coords[0]) != 1 || len(coords[0][0]) != 1 {
+		return errInvalidPositions
+	}
+	c.Vertices = coords[0][0][0]
+	return nil
+}
+
+func (e *Envelope) positions() [][][][]float64 {
+	return [][][][]float64{{e.Vertices}}
+}
+
+func (e *Envelope) setPositions(coords [][][][]float64) error {
+	if len(coords) != 1 || len(coords[0]) != 1 {
+		return errInvalidPositions
+	}
+	e.Vertices = coords[0][0]
+	return nil
+}
+
+// hasExtraOrdinates reports whether any of the positions has more than
+// a longitude and a latitude.
+func hasExtraOrdinates(coords [][][][]float64) bool {
+	for _, polygon := range coords {
+		for _, ring := range polygon {
+			for _, pos := range ring {
+				if len(pos) > 2 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// appendPositions flags the encoding of a shape with AltitudeFlag and
+// appends its positions to it, if they have extra ordinates. Otherwise
+// the encoding is returned as it is.
+func appendPositions(encoded []byte, coords [][][][]float64) []byte {
+	if !hasExtraOrdinates(coords) {
+		return encoded
+	}
+	encoded[0] |= AltitudeFlag
+
+	be := binary.BigEndian
+	encoded = be.AppendUint32(encoded, uint32(len(coords)))
+	for _, polygon := range coords {
+		encoded = be.AppendUint32(encoded, uint32(len(polygon)))
+		for _, ring := range polygon {
+			encoded = be.AppendUint32(encoded, uint32(len(ring)))
+			for _, pos := range ring {
+				encoded = be.AppendUint32(encoded, uint32(len(pos)))
+				for _, v := range pos {
+					encoded = be.AppendUint64(encoded, math.Float64bits(v))
+				}
+			}
+		}
+	}
+	return encoded
+}
+
+// encodedPositions returns the positions to append to the encoding of
+// the shape: those of its simplified s2 representation when it keeps
+// its original coordinates (see ParseOptions.KeepOriginal), which only
+// cost space as its decoded form is the simplified one, or its own.
+func encodedPositions(ps positionedShape,
+	simplified [][][][]float64) [][][][]float64 {
+	if simplified != nil {
+		return simplified
+	}
+	return ps.positions()
+}
+
+// readPositionsCount reads the length of a level of the positions,
+// which can't exceed what is left to read when every item takes at
+// least size bytes.
+func readPositionsCount(r *bytes.Reader, size int) (int, error) {
+	var n uint32
+	err := binary.Read(r, binary.BigEndian, &n)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(size) > uint64(r.Len()) {
+		return 0, errInvalidPositions
+	}
+	return int(n), nil
+}
+
+// decodePositions decodes the positions appended by appendPositions.
+func decodePositions(r *bytes.Reader) ([][][][]float64, error) {
+	n, err := readPositionsCount(r, 4)
+	if err != nil {
+		return nil, err
+	}
+	coords := make([][][][]float64, n)
+	for i := range coords {
+		n, err := readPositionsCount(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = make([][][]float64, n)
+		for j := range coords[i] {
+			n, err := readPositionsCount(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			coords[i][j] = make([][]float64, n)
+			for k := range coords[i][j] {
+				n, err := readPositionsCount(r, 8)
+				if err != nil {
+					return nil, err
+				}
+				pos := make([]float64, n)
+				for l := range pos {
+					var bits uint64
+					err := binary.Read(r, binary.BigEndian, &bits)
+					if err != nil {
+						return nil, err
+					}
+					pos[l] = math.Float64frombits(bits)
+				}
+				coords[i][j][k] = pos
+			}
+		}
+	}
+	return coords, nil
+}
+
+// readPositions sets the positions following the encoding of a shape
+// flagged with AltitudeFlag on the decoded shape.
+func readPositions(shape index.GeoJSON, r *bytes.Reader) (index.GeoJSON, error) {
+	ps, ok := shape.(positionedShape)
+	if !ok {
+		return nil, fmt.Errorf("unexpected positions for a %s", shape.Type())
+	}
+	coords, err := decodePositions(r)
+	if err != nil {
+		return nil, err
+	}
+	err = ps.setPositions(coords)
+	if err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+// AltitudeBounds returns the lowest and highest altitudes, i.e. third
+// ordinates, of the positions of the shape and of the members of a
+// geometrycollection. ok is false when none of them has an altitude.
+func AltitudeBounds(shape index.GeoJSON) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	var walk func(index.GeoJSON)
+	walk = func(shape index.GeoJSON) {
+		switch s := shape.(type) {
+		case *GeometryCollection:
+			for _, member := range s.Shapes {
+				walk(member)
+			}
+		case positionedShape:
+			for _, polygon := range s.positions() {
+				for _, ring := range polygon {
+					for _, pos := range ring {
+						if len(pos) < 3 || math.IsNaN(pos[2]) {
+							continue
+						}
+						lo, hi, ok = math.Min(lo, pos[2]), math.Max(hi, pos[2]), true
+					}
+				}
+			}
+		}
+	}
+	walk(shape)
+	if !ok {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// AltitudeRange is a filter on the altitudes of the shapes, given in
+// the unit of their positions, which is usually meters.
+type AltitudeRange struct {
+	Min float64
+	Max float64
+}
+
+// Matches reports whether the altitudes of the shape, from the lowest
+// to the highest, overlap the range. A shape without altitudes never
+// matches.
+func (r AltitudeRange) Matches(shape index.GeoJSON) bool {
+	lo, hi, ok := AltitudeBounds(shape)
+	return ok && lo <= r.Max && hi >= r.Min
+}
+
+// positionKey returns the longitude and latitude of the position as
+// they are output from its s2 point, so that both can be matched.
+func positionKey(pos []float64) [2]float64 {
+	ll := positionFromS2Point(s2.PointFromLatLng(
+		s2.LatLngFromDegrees(pos[1], pos[0])))
+	return [2]float64{ll[0], ll[1]}
+}
+
+// addExtraOrdinates appends the extra ordinates of the positions of
+// the shape to those of its output object, which are rebuilt from the
+// s2 representation with only a longitude and a latitude. Positions are
+// matched in order while they agree, and otherwise by their longitude
+// and latitude, as s2 may reverse or rotate the rings of polygons.
+func addExtraOrdinates(obj interface{}, shape index.GeoJSON) {
+	switch o := obj.(type) {
+	case *geoJSONGeometryCollection:
+		gc, ok := shape.(*GeometryCollection)
+		if !ok {
+			return
+		}
+		i := 0
+		for _, member := range gc.Shapes {
+			if member == nil || i >= len(o.Geometries) {
+				continue
+			}
+			addExtraOrdinates(o.Geometries[i], member)
+			i++
+		}
+
+	case *geoJSONGeometry:
+		ps, ok := shape.(positionedShape)
+		if !ok {
+			return
+		}
+		coords := ps.positions()
+		if !hasExtraOrdinates(coords) {
+			return
+		}
+
+		var input [][]float64
+		var keys [][2]float64
+		lookup := make(map[[2]float64][]float64)
+		for _, polygon := range coords {
+			for _, ring := range polygon {
+				for _, pos := range ring {
+					if len(pos) < 2 {
+						continue
+					}
+					key := positionKey(pos)
+					if _, ok := lookup[key]; !ok {
+						lookup[key] = pos[2:]
+					}
+					input = append(input, pos)
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		next := 0
+		extend := func(pos []float64) []float64 {
+			if len(pos) < 2 {
+				return pos
+			}
+			key := [2]float64{pos[0], pos[1]}
+			extra := lookup[key]
+			if next < len(keys) && keys[next] == key {
+				extra = input[next][2:]
+			}
+			next++
+			if len(extra) == 0 {
+				return pos
+			}
+			return append(pos[:2:2], extra...)
+		}
+
+		switch c := o.Coordinates.(type) {
+		case []float64:
+			o.Coordinates = extend(c)
+		case [][]float64:
+			for i := range c {
+				c[i] = extend(c[i])
+			}
+		case [][][]float64:
+			for _, ring := range c {
+				for i := range ring {
+					ring[i] = extend(ring[i])
+				}
+			}
+		case [][][][]float64:
+			for _, polygon := range c {
+				for _, ring := range polygon {
+					for i := range ring {
+						ring[i] = extend(ring[i])
+					}
+				}
+			}
+		}
+	}
+}
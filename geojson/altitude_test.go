@@ -0,0 +1,257 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestAltitudeMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{`{"type":"Point","coordinates":[4.5,22.5,120]}`,
+			[]float64{4.5, 22.5, 120}},
+		{`{"type":"MultiPoint","coordinates":[[1,1,10],[50,50,20]]}`,
+			[][]float64{{1, 1, 10}, {50, 50, 20}}},
+		// extra ordinates beyond the altitude are kept too
+		{`{"type":"LineString","coordinates":[[0,0,100,1],[10,10,150,2],[20,10,90,3]]}`,
+			[][]float64{{0, 0, 100, 1}, {10, 10, 150, 2}, {20, 10, 90, 3}}},
+		{`{"type":"MultiLineString","coordinates":[[[0,0,1],[1,1,2]],[[5,5,3],[6,6,4]]]}`,
+			[][][]float64{{{0, 0, 1}, {1, 1, 2}}, {{5, 5, 3}, {6, 6, 4}}}},
+		{`{"type":"Polygon","coordinates":[[[0,0,30],[10,0,30],[10,10,35],[0,10,35],[0,0,30]]]}`,
+			[][][]float64{{{0, 0, 30}, {10, 0, 30}, {10, 10, 35}, {0, 10, 35}, {0, 0, 30}}}},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0,1],[1,0,1],[1,1,1],[0,0,1]]],` +
+			`[[[5,5,2],[6,5,2],[6,6,2],[5,5,2]]]]}`,
+			[][][][]float64{{{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 0, 1}}},
+				{{{5, 5, 2}, {6, 5, 2}, {6, 6, 2}, {5, 5, 2}}}}},
+		{`{"type":"Circle","coordinates":[10,10,500],"radius":"10km"}`,
+			[]float64{10, 10, 500}},
+		{`{"type":"Envelope","coordinates":[[0,20,5],[20,0,8]]}`,
+			[][]float64{{0, 20, 5}, {20, 0, 8}}},
+	}
+
+	for i, test := range tests {
+		shape, err := ParseGeoJSONShape([]byte(test.input))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		data, err := shape.(s2Serializable).Marshal()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if data[0]&AltitudeFlag == 0 {
+			t.Fatalf("case %d: expected the encoding to be flagged", i)
+		}
+
+		var reader *bytes.Reader
+		decoded, err := ExtractShapesFromBytes(data, &reader, nil)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if decoded.Type() != shape.Type() {
+			t.Fatalf("case %d: expected a %s, got a %s", i, shape.Type(), decoded.Type())
+		}
+		got := decoded.(positionedShape).positions()
+		if !reflect.DeepEqual(got, shape.(positionedShape).positions()) {
+			t.Fatalf("case %d: expected positions %v, got %v", i, test.want, got)
+		}
+
+		// the decoded shape still behaves as the original one
+		ok, err := decoded.Intersects(shape)
+		if err != nil || !ok {
+			t.Fatalf("case %d: expected the decoded shape to intersect the "+
+				"original one, got %v %v", i, ok, err)
+		}
+
+		// and is encoded the same way again
+		again, err := decoded.(s2Serializable).Marshal()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Fatalf("case %d: expected the decoded shape to encode the same", i)
+		}
+	}
+}
+
+func TestAltitudeLegacyEncoding(t *testing.T) {
+	// shapes without altitudes are encoded as before, with the bare type
+	// prefix, and decode without positions
+	shapes := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{4.5, 22.5}),
+		NewGeoJsonLinestring([][]float64{{0, 0}, {10, 10}}),
+		NewGeoJsonPolygon([][][]float64{testSquare(0, 10)}),
+		NewGeoCircle([]float64{10, 10}, "10km"),
+	}
+	prefixes := []byte{PointTypePrefix, LineStringTypePrefix,
+		PolygonTypePrefix, CircleTypePrefix}
+
+	for i, shape := range shapes {
+		data, err := shape.(s2Serializable).Marshal()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if data[0] != prefixes[i] {
+			t.Fatalf("case %d: expected prefix %d, got %d", i, prefixes[i], data[0])
+		}
+		decoded := decodeShape(t, shape)
+		if _, _, ok := AltitudeBounds(decoded); ok {
+			t.Fatalf("case %d: expected no altitudes", i)
+		}
+	}
+}
+
+func TestAltitudeCollectionRoundTrip(t *testing.T) {
+	input := []byte(`{"type":"GeometryCollection","geometries":[
+		{"type":"Point","coordinates":[1,1,10]},
+		{"type":"LineString","coordinates":[[0,0],[1,1]]},
+		{"type":"GeometryCollection","geometries":[
+			{"type":"Point","coordinates":[2,2,-5]}
+		]}
+	]}`)
+	shape, err := ParseGeoJSONShape(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := decodeShape(t, shape)
+
+	lo, hi, ok := AltitudeBounds(decoded)
+	if !ok || lo != -5 || hi != 10 {
+		t.Fatalf("expected altitudes from -5 to 10, got %v %v %v", lo, hi, ok)
+	}
+
+	out, err := ToGeoJSON(decoded, GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"GeometryCollection","geometries":[` +
+		`{"type":"Point","coordinates":[1,1,10]},` +
+		`{"type":"LineString","coordinates":[[0,0],[1,1]]},` +
+		`{"type":"GeometryCollection","geometries":[` +
+		`{"type":"Point","coordinates":[2,2,-5]}]}]}`
+	if string(out) != want {
+		t.Fatalf("expected %s, got %s", want, out)
+	}
+}
+
+func TestAltitudeToGeoJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"type":"LineString","coordinates":[[0,0,100],[10,10,150],[0,0,90]]}`,
+			`{"type":"LineString","coordinates":[[0,0,100],[10,10,150],[0,0,90]]}`},
+		{`{"type":"Polygon","coordinates":[[[0,0,1],[10,0,2],[10,10,3],[0,10,4],[0,0,1]]]}`,
+			`{"type":"Polygon","coordinates":[[[0,0,1],[10,0,2],[10,10,3],[0,10,4],[0,0,1]]]}`},
+		{`{"type":"Envelope","coordinates":[[0,20,5],[20,0,8]]}`,
+			`{"type":"Envelope","coordinates":[[0,20,5],[20,0,8]]}`},
+	}
+
+	for i, test := range tests {
+		shape, err := ParseGeoJSONShape([]byte(test.input))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		for j, s := range []index.GeoJSON{shape, decodeShape(t, shape)} {
+			out, err := ToGeoJSON(s, GeoJSONOptions{})
+			if err != nil {
+				t.Fatalf("case %d, %d: unexpected error: %v", i, j, err)
+			}
+			if string(out) != test.want {
+				t.Fatalf("case %d, %d: expected %s, got %s", i, j, test.want, out)
+			}
+		}
+	}
+}
+
+func TestAltitudeInvalidEncoding(t *testing.T) {
+	shape := NewGeoJsonLinestring([][]float64{{0, 0, 1}, {10, 10, 2}})
+	data, err := shape.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reader *bytes.Reader
+	for n := len(data) - 1; n > len(data)-60; n-- {
+		if _, err := ExtractShapesFromBytes(data[:n], &reader, nil); err == nil {
+			t.Fatalf("expected an error for an encoding truncated to %d bytes", n)
+		}
+	}
+
+	// the positions of a linestring don't fit a point
+	point := append([]byte{}, data...)
+	point[0] = PointTypePrefix | AltitudeFlag
+	if _, err := ExtractShapesFromBytes(point, &reader, nil); err == nil {
+		t.Fatal("expected an error for positions which don't fit the shape")
+	}
+}
+
+func TestAltitudeRange(t *testing.T) {
+	path := NewGeoJsonLinestring([][]float64{{0, 0, 100}, {1, 1, 150}, {2, 2, 120}})
+	flat := NewGeoJsonLinestring([][]float64{{0, 0}, {1, 1}, {2, 2}})
+
+	tests := []struct {
+		altitudes AltitudeRange
+		shape     index.GeoJSON
+		want      bool
+	}{
+		{AltitudeRange{Min: 0, Max: 200}, path, true},
+		{AltitudeRange{Min: 140, Max: 200}, path, true},
+		{AltitudeRange{Min: 150, Max: 150}, path, true},
+		{AltitudeRange{Min: 0, Max: 99}, path, false},
+		{AltitudeRange{Min: 151, Max: 300}, path, false},
+		// shapes without altitudes never match
+		{AltitudeRange{Min: -1000, Max: 1000}, flat, false},
+	}
+
+	for i, test := range tests {
+		if got := test.altitudes.Matches(test.shape); got != test.want {
+			t.Fatalf("case %d: expected %v, got %v", i, test.want, got)
+		}
+	}
+
+	data, err := path.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := NewGeoJsonPolygon([][][]float64{testSquare(-1, 3)})
+	for i, test := range []struct {
+		altitudes *AltitudeRange
+		relation  string
+		want      bool
+	}{
+		{nil, "intersects", true},
+		{&AltitudeRange{Min: 0, Max: 200}, "intersects", true},
+		{&AltitudeRange{Min: 200, Max: 300}, "intersects", false},
+		{&AltitudeRange{Min: 0, Max: 200}, "within", true},
+		{&AltitudeRange{Min: 0, Max: 200}, "disjoint", false},
+	} {
+		var reader *bytes.Reader
+		got, err := FilterGeoShapesOnRelationWithAltitude(query, data,
+			test.relation, test.altitudes, &reader, nil)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %v, got %v", i, test.want, got)
+		}
+	}
+}
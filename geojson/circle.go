@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -32,6 +33,9 @@ type Circle struct {
 	Radius         string    `json:"radius"`
 	radiusInMeters float64
 	s2cap          *s2.Cap
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 func NewGeoCircle(points []float64,
@@ -59,10 +63,23 @@ func (c *Circle) Value() ([]byte, error) {
 	return jsoniter.Marshal(c)
 }
 
-func (c *Circle) init() {
-	if c.s2cap == nil {
-		c.s2cap = s2Cap(c.Vertices, c.radiusInMeters)
+// MarshalJSON returns the circle with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (c *Circle) MarshalJSON() ([]byte, error) {
+	if c.Vertices != nil {
+		type circle Circle
+		return jsoniter.Marshal((*circle)(c))
 	}
+	return ToGeoJSON(c, GeoJSONOptions{})
+}
+
+func (c *Circle) init() {
+	c.once.Do(func() {
+		if c.s2cap == nil {
+			c.s2cap = s2Cap(c.Vertices, c.radiusInMeters)
+		}
+	})
 }
 
 func (c *Circle) Marshal() ([]byte, error) {
@@ -77,17 +94,20 @@ func (c *Circle) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{CircleTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{CircleTypePrefix}, b.Bytes()...),
+		c.positions()), nil
 }
 
 func (c *Circle) Intersects(other index.GeoJSON) (bool, error) {
 	c.init()
+	initShape(other)
 
 	return checkCircleIntersectsShape(c.s2cap, c, other)
 }
 
 func (c *Circle) Contains(other index.GeoJSON) (bool, error) {
 	c.init()
+	initShape(other)
 	return checkCircleContainsShape(c.s2cap, c, other)
 }
 
@@ -116,21 +136,22 @@ func (c *Circle) UnmarshalJSON(data []byte) error {
 // (fully contained in the cap built from the center and radiusInMeters)
 // and cross cells (overlapping the cap's boundary).
 func (c *Circle) IndexCells() (inner, cross []uint64) {
-	c.init()
-	if c.s2cap == nil {
-		return nil, nil
-	}
-	return indexCellsFromRegion(*c.s2cap)
+	return c.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the circle's query-time covering, partitioned the same
 // way as IndexCells.
 func (c *Circle) QueryCells() (inner, cross []uint64) {
+	return c.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the circle computed with the coverer.
+func (c *Circle) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	c.init()
 	if c.s2cap == nil {
 		return nil, nil
 	}
-	return queryCellsFromRegion(*c.s2cap)
+	return cellsFromRegion(*c.s2cap, coverer)
 }
 
 func (c *Circle) BoundingBox() index.GeoJSON {
@@ -157,6 +178,9 @@ func checkCircleIntersectsShape(s2cap *s2.Cap, shapeIn,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if s2cap.ContainsPoint(*p2.s2point) {
 			return true, nil
 		}
@@ -264,6 +288,9 @@ func checkCircleContainsShape(s2cap *s2.Cap,
 	shapeIn, other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if s2cap.ContainsPoint(*p2.s2point) {
 			return true, nil
 		}
@@ -0,0 +1,139 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// lazyShapes returns one shape of every type whose s2 fields are not
+// built yet, as after decoding a query from JSON.
+func lazyShapes() []index.GeoJSON {
+	return []index.GeoJSON{
+		&Point{Typ: PointType, Vertices: []float64{5, 5}},
+		&MultiPoint{Typ: MultiPointType, Vertices: [][]float64{{5, 5}, {50, 50}}},
+		&LineString{Typ: LineStringType, Vertices: [][]float64{{-5, 5}, {15, 5}}},
+		&MultiLineString{Typ: MultiLineStringType,
+			Vertices: [][][]float64{{{0, 0}, {0, 10}}, {{20, 20}, {30, 30}}}},
+		&Polygon{Typ: PolygonType, Vertices: [][][]float64{testSquare(0, 10)}},
+		&MultiPolygon{Typ: MultiPolygonType,
+			Vertices: [][][][]float64{{testSquare(2, 8)}, {testSquare(20, 30)}}},
+		&Circle{Typ: CircleType, Vertices: []float64{5, 5}, Radius: "100km",
+			radiusInMeters: 100000},
+		&Envelope{Typ: EnvelopeType, Vertices: [][]float64{{0, 10}, {10, 0}}},
+		&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			&Point{Typ: PointType, Vertices: []float64{1, 1}},
+			&Polygon{Typ: PolygonType, Vertices: [][][]float64{testSquare(5, 15)}}}},
+	}
+}
+
+// TestConcurrentReaders shares the shapes between goroutines, which is
+// meant to be run with the race detector.
+func TestConcurrentReaders(t *testing.T) {
+	shapes := lazyShapes()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, shape := range shapes {
+				shape.IndexCells()
+				shape.QueryCells()
+				shape.BoundingBox()
+				if _, err := shape.Value(); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := ToGeoJSON(shape, GeoJSONOptions{BBox: true}); err != nil {
+					errs <- err
+					return
+				}
+				for _, other := range shapes {
+					if _, err := shape.Intersects(other); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := shape.Contains(other); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := Relate(shape, other); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := MinDistance(shape, other); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConcurrentPreparedShape(t *testing.T) {
+	prepared, err := NewPreparedShape(
+		&Polygon{Typ: PolygonType, Vertices: [][][]float64{testSquare(0, 10)}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs [][]byte
+	for _, shape := range lazyShapes() {
+		if _, ok := shape.(*GeometryCollection); ok {
+			continue
+		}
+		data, err := shape.(s2Serializable).Marshal()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		docs = append(docs, data)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, relation := range []string{"intersects", "within",
+				"touches", "dwithin 10km"} {
+				for _, data := range docs {
+					var reader *bytes.Reader
+					_, err := prepared.FilterOnRelation(data, relation, &reader, nil)
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
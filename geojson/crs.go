@@ -0,0 +1,197 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/r2"
+	"github.com/blevesearch/geo/s2"
+)
+
+// crsTessellationTolerance is the distance in meters within which the
+// geodesic edges unprojected from the straight edges of a planar CRS
+// follow them.
+const crsTessellationTolerance = 1.0
+
+// geoJSONCRS is the "crs" member of the 2008 geoJSON specification,
+// which RFC 7946 removed. Only named CRSs are supported.
+type geoJSONCRS struct {
+	Typ        string `json:"type"`
+	Properties struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+// withCRS returns the options with the CRS named by the crs member of
+// the input, if it has one. Unsupported crs members, such as links or
+// unknown names, are ignored as they were before CRSs were supported,
+// unless the parsing is strict or the options name a CRS.
+func (o ParseOptions) withCRS(crs *geoJSONCRS) (ParseOptions, error) {
+	if crs == nil {
+		return o, nil
+	}
+	var err error
+	if strings.ToLower(crs.Typ) != "name" || crs.Properties.Name == "" {
+		err = fmt.Errorf("unsupported crs type: %s", crs.Typ)
+	} else {
+		_, err = crsProjection(crs.Properties.Name)
+	}
+	if err != nil {
+		if o.Strict || o.CRS != "" {
+			return o, err
+		}
+		return o, nil
+	}
+	o.CRS = crs.Properties.Name
+	return o, nil
+}
+
+// crsCode returns the authority and code of the CRS name, like
+// EPSG:3857 for urn:ogc:def:crs:EPSG::3857 as well as for epsg:3857.
+func crsCode(name string) string {
+	code := strings.ToUpper(strings.TrimSpace(name))
+	if rest, ok := strings.CutPrefix(code, "URN:OGC:DEF:CRS:"); ok {
+		// the authority, an optional version and the code
+		parts := strings.Split(rest, ":")
+		if len(parts) >= 2 {
+			code = parts[0] + ":" + parts[len(parts)-1]
+		}
+	}
+	return code
+}
+
+// crsProjection returns the projection of the coordinates of the CRS,
+// which is nil for the WGS84 longitudes and latitudes of geoJSON.
+func crsProjection(name string) (s2.Projection, error) {
+	// planar CRSs in meters span half the equator on either side
+	halfEquator := math.Pi * earthRadiusInMeter
+	switch crsCode(name) {
+	case "", "EPSG:4326", "OGC:CRS84", "CRS84":
+		return nil, nil
+	case "EPSG:3857", "EPSG:900913", "EPSG:3785", "EPSG:102100", "EPSG:102113":
+		return s2.NewMercatorProjection(halfEquator), nil
+	case "EPSG:4087", "EPSG:32662":
+		return s2.NewPlateCarreeProjection(halfEquator), nil
+	}
+	return nil, fmt.Errorf("unsupported crs: %s", name)
+}
+
+// tessellatedType reports whether the edges of the shapes of the type
+// are tessellated when they are unprojected, rather than only their
+// positions.
+func tessellatedType(typ string) bool {
+	switch strings.ToLower(typ) {
+	case LineStringType, MultiLineStringType, PolygonType, MultiPolygonType:
+		return true
+	}
+	return false
+}
+
+// unprojectShape replaces the positions of the shape in the CRS of the
+// options with their longitudes and latitudes. It must be called before
+// the s2 representation of the shape is built.
+func (o ParseOptions) unprojectShape(shape index.GeoJSON) error {
+	if o.CRS == "" {
+		return nil
+	}
+	ps, ok := shape.(positionedShape)
+	if !ok {
+		return nil
+	}
+	coords, err := o.unproject(ps.positions(), shape.Type())
+	if err != nil || coords == nil {
+		return err
+	}
+	return ps.setPositions(coords)
+}
+
+// unproject returns the longitudes and latitudes of the positions of a
+// shape of the given type in the CRS of the options, or nil when they
+// are longitudes and latitudes already. The straight edges between the
+// positions of linestrings and polygons in the CRS are tessellated into
+// geodesic edges, so that they keep following the same path on earth,
+// and the extra ordinates of the positions are interpolated along them.
+// The radius of a circle stays a distance on earth.
+func (o ParseOptions) unproject(coords [][][][]float64,
+	typ string) ([][][][]float64, error) {
+	proj, err := crsProjection(o.CRS)
+	if err != nil || proj == nil {
+		return nil, err
+	}
+	var tessellator *s2.EdgeTessellator
+	if tessellatedType(typ) {
+		tessellator = s2.NewEdgeTessellator(proj,
+			radiusInMetersToS1Angle(crsTessellationTolerance))
+	}
+
+	rv := make([][][][]float64, len(coords))
+	for i, polygon := range coords {
+		rv[i] = make([][][]float64, len(polygon))
+		for j, chain := range polygon {
+			rv[i][j] = unprojectChain(chain, proj, tessellator)
+		}
+	}
+	return rv, nil
+}
+
+// unprojectChain returns the longitudes and latitudes of the chain of
+// projected positions, with the vertices that the tessellator adds
+// along its edges, if any.
+func unprojectChain(chain [][]float64, proj s2.Projection,
+	tessellator *s2.EdgeTessellator) [][]float64 {
+	rv := make([][]float64, 0, len(chain))
+	var points []s2.Point
+	for i, pos := range chain {
+		if len(pos) < 2 {
+			rv = append(rv, pos)
+			continue
+		}
+		if tessellator != nil && i > 0 && len(chain[i-1]) >= 2 {
+			prev := chain[i-1]
+			pa, pb := r2.Point{X: prev[0], Y: prev[1]}, r2.Point{X: pos[0], Y: pos[1]}
+			points = tessellator.AppendUnprojected(pa, pb, points[:0])
+			// the points added between the ends of the edge
+			pb = proj.WrapDestination(pa, pb)
+			for _, p := range points[1 : len(points)-1] {
+				q := proj.WrapDestination(pa, proj.Project(p))
+				f := q.Sub(pa).Norm() / pb.Sub(pa).Norm()
+				rv = append(rv, append(positionFromS2Point(p),
+					interpolateOrdinates(prev[2:], pos[2:], f)...))
+			}
+		}
+		ll := proj.ToLatLng(r2.Point{X: pos[0], Y: pos[1]})
+		rv = append(rv, append([]float64{roundDegrees(ll.Lng.Degrees()),
+			roundDegrees(ll.Lat.Degrees())}, pos[2:]...))
+	}
+	return rv
+}
+
+// interpolateOrdinates returns the extra ordinates at the fraction f of
+// the way from those of a to those of b, as many as both have.
+func interpolateOrdinates(a, b []float64, f float64) []float64 {
+	n := min(len(a), len(b))
+	if n == 0 {
+		return nil
+	}
+	rv := make([]float64, n)
+	for i := range rv {
+		rv[i] = a[i] + f*(b[i]-a[i])
+	}
+	return rv
+}
@@ -0,0 +1,220 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo checks whether the positions have the same ordinates up to
+// the tolerance.
+func closeTo(got, want []float64, tolerance float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseCRS(t *testing.T) {
+	// [10, 10] in Web Mercator meters
+	point := `"coordinates":[1113194.9079327357,1118889.9748579597]`
+	tests := []struct {
+		input string
+		opts  ParseOptions
+		want  []float64
+	}{
+		{`{"type":"Point",` + point + `,` +
+			`"crs":{"type":"name","properties":{"name":"EPSG:3857"}}}`,
+			ParseOptions{}, []float64{10, 10}},
+		{`{"type":"Point",` + point + `,` +
+			`"crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:EPSG::3857"}}}`,
+			ParseOptions{}, []float64{10, 10}},
+		{`{"type":"Point",` + point + `}`,
+			ParseOptions{CRS: "epsg:900913"}, []float64{10, 10}},
+		// the crs member takes precedence over the options
+		{`{"type":"Point","coordinates":[10,10],` +
+			`"crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:OGC:1.3:CRS84"}}}`,
+			ParseOptions{CRS: "EPSG:3857"}, []float64{10, 10}},
+		// plate carree meters
+		{`{"type":"Point","coordinates":[1113194.9079327357,1113194.9079327357]}`,
+			ParseOptions{CRS: "EPSG:4087"}, []float64{10, 10}},
+		// extra ordinates are kept
+		{`{"type":"Point","coordinates":[1113194.9079327357,1118889.9748579597,42]}`,
+			ParseOptions{CRS: "EPSG:3857"}, []float64{10, 10, 42}},
+		// unsupported crs members are ignored by default
+		{`{"type":"Point","coordinates":[10,10],` +
+			`"crs":{"type":"name","properties":{"name":"EPSG:27700"}}}`,
+			ParseOptions{}, []float64{10, 10}},
+		{`{"type":"Point","coordinates":[10,10],` +
+			`"crs":{"type":"link","properties":{"href":"http://example.com/crs"}}}`,
+			ParseOptions{}, []float64{10, 10}},
+	}
+
+	for i, test := range tests {
+		shape, err := ParseGeoJSONShapeWithOptions([]byte(test.input), test.opts)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got := shape.(*Point).Vertices; !closeTo(got, test.want, 1e-9) {
+			t.Fatalf("case %d: expected %v, got %v", i, test.want, got)
+		}
+		ok, err := shape.Intersects(NewGeoJsonPoint(test.want[:2]))
+		if err != nil || !ok {
+			t.Fatalf("case %d: expected the point at %v, got %v %v", i, test.want, ok, err)
+		}
+	}
+
+	// unless the parsing is strict or the options name a CRS
+	for i, input := range []string{
+		`{"type":"Point","coordinates":[0,0],` +
+			`"crs":{"type":"name","properties":{"name":"EPSG:27700"}}}`,
+		`{"type":"Point","coordinates":[0,0],` +
+			`"crs":{"type":"link","properties":{"href":"http://example.com/crs"}}}`,
+	} {
+		for _, opts := range []ParseOptions{{Strict: true}, {CRS: "EPSG:3857"}} {
+			if _, err := ParseGeoJSONShapeWithOptions([]byte(input), opts); err == nil {
+				t.Fatalf("case %d: expected an error with %+v", i, opts)
+			}
+		}
+	}
+}
+
+func TestParseCRSTessellation(t *testing.T) {
+	// a straight line in Web Mercator follows the parallel at 60 degrees
+	// rather than the geodesic between its ends, which goes north of it
+	input := `{"type":"LineString","coordinates":` +
+		`[[-2226389.8158654715,8399737.889818355,0],[2226389.8158654715,8399737.889818355,100]],` +
+		`"crs":{"type":"name","properties":{"name":"EPSG:3857"}}}`
+	shape, err := ParseGeoJSONShape([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vertices := shape.(*LineString).Vertices
+	if len(vertices) < 10 {
+		t.Fatalf("expected the line to be tessellated, got %v", vertices)
+	}
+	if !closeTo(vertices[0], []float64{-20, 60, 0}, 1e-9) ||
+		!closeTo(vertices[len(vertices)-1], []float64{20, 60, 100}, 1e-9) {
+		t.Fatalf("expected the ends of the line, got %v and %v",
+			vertices[0], vertices[len(vertices)-1])
+	}
+	for i, v := range vertices {
+		if math.Abs(v[1]-60) > 1e-3 {
+			t.Fatalf("vertex %d: expected the parallel, got %v", i, v)
+		}
+		if i > 0 && (v[0] <= vertices[i-1][0] || v[2] <= vertices[i-1][2]) {
+			t.Fatalf("vertex %d: expected increasing longitudes and "+
+				"altitudes, got %v after %v", i, v, vertices[i-1])
+		}
+	}
+
+	// within a meter of the parallel between the vertices too
+	ok, err := shape.Intersects(NewGeoCircle([]float64{0, 60}, "1m"))
+	if err != nil || !ok {
+		t.Fatalf("expected the line to follow the parallel, got %v %v", ok, err)
+	}
+	// the middle of the geodesic is 170 km north of the parallel
+	ok, err = shape.Intersects(NewGeoCircle([]float64{0, 61.5188}, "100km"))
+	if err != nil || ok {
+		t.Fatalf("expected the line to leave the geodesic, got %v %v", ok, err)
+	}
+}
+
+func TestParseCRSShapes(t *testing.T) {
+	// a square of 1 degree in Web Mercator meters, and its envelope
+	square := `[[0,-55660.4518654215],[111319.49079327357,-55660.4518654215],` +
+		`[111319.49079327357,55660.45186542052],[0,55660.45186542052],` +
+		`[0,-55660.4518654215]]`
+	crs := `"crs":{"type":"name","properties":{"name":"EPSG:3857"}}`
+
+	shape, err := ParseGeoJSONShapeWithOptions([]byte(`{"type":"Polygon",`+
+		`"coordinates":[`+square+`],`+crs+`}`), ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	area, err := Area(shape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parallels of the square are not geodesics, so the area is that
+	// of the square of 1 degree made of meridians and parallels
+	envelope := NewGeoEnvelope([][]float64{{0, 0.5}, {1, -0.5}})
+	want, _ := Area(envelope)
+	if !approxEqual(area, want, 1e-5) {
+		t.Fatalf("expected an area of %v, got %v", want, area)
+	}
+
+	shape, err = ParseGeoJSONShape([]byte(`{"type":"Envelope",` +
+		`"coordinates":[[0,55660.45186542052],[111319.49079327357,-55660.4518654215]],` +
+		crs + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := shape.(*Envelope).Vertices
+	if !closeTo(got[0], []float64{0, 0.5}, 1e-9) || !closeTo(got[1], []float64{1, -0.5}, 1e-9) {
+		t.Fatalf("expected the envelope of the square, got %v", got)
+	}
+
+	// the crs of a collection applies to its members
+	shape, err = ParseGeoJSONShape([]byte(`{"type":"FeatureCollection",` + crs +
+		`,"features":[{"type":"Feature","properties":{},"geometry":` +
+		`{"type":"GeometryCollection","geometries":[` +
+		`{"type":"Point","coordinates":[1113194.9079327357,1118889.9748579597]}]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := shape.(*GeometryCollection).Shapes[0].(*GeometryCollection).Shapes
+	if got := members[0].(*Point).Vertices; !closeTo(got, []float64{10, 10}, 1e-9) {
+		t.Fatalf("expected the point at [10, 10], got %v", got)
+	}
+
+	// as to the shapes built from coordinates
+	shape, _, err = NewGeoJsonShapeWithOptions([][][][]float64{{{
+		{1113194.9079327357, 1118889.9748579597}}}}, PointType,
+		ParseOptions{CRS: "EPSG:3857"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := shape.(*Point).Vertices; !closeTo(got, []float64{10, 10}, 1e-9) {
+		t.Fatalf("expected the point at [10, 10], got %v", got)
+	}
+
+	// and to the shapes parsed from WKT
+	shape, err = ParseWKTShapeWithOptions("POINT (1113194.9079327357 1118889.9748579597)",
+		ParseOptions{CRS: "EPSG:3857"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := shape.(*Point).Vertices; !closeTo(got, []float64{10, 10}, 1e-9) {
+		t.Fatalf("expected the point at [10, 10], got %v", got)
+	}
+
+	// the center of a buffer is unprojected once
+	shape, err = ParseWKTShapeWithOptions("BUFFER (POINT (1000000 1000000), 1)",
+		ParseOptions{CRS: "EPSG:3857"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := []float64{8.983152841195214, 8.946573850543412}
+	if got := shape.(*Circle).Vertices; !closeTo(got, center, 1e-9) {
+		t.Fatalf("expected the circle at %v, got %v", center, got)
+	}
+}
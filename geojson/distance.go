@@ -0,0 +1,312 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s1"
+	"github.com/blevesearch/geo/s2"
+)
+
+// dwithinRelation is the prefix of the "dwithin <distance>" relation.
+const dwithinRelation = "dwithin "
+
+// parseDWithinRelation returns the distance in meters of a
+// "dwithin <distance>" relation, ok being false for other relations.
+func parseDWithinRelation(relation string) (meters float64, ok bool, err error) {
+	d, ok := strings.CutPrefix(relation, dwithinRelation)
+	if !ok {
+		return 0, false, nil
+	}
+	meters, err = ParseDistance(strings.TrimSpace(d))
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid distance in relation %q: %v",
+			relation, err)
+	}
+	if meters < 0 || math.IsNaN(meters) {
+		return 0, true, fmt.Errorf("invalid distance in relation %q", relation)
+	}
+	return meters, true, nil
+}
+
+// WithinDistance returns whether the shapes come within the given
+// distance in meters of each other, the distance between a point inside
+// a polygon and that polygon being zero.
+//
+// The distances are computed exactly on the s2 representation of the
+// shapes, with circles measured from their center less their radius.
+// Envelopes are measured as the polygon of their corners, as in the
+// other predicates of the package.
+func WithinDistance(a, b index.GeoJSON, meters float64) (bool, error) {
+	ga, err := newDistanceGeometry(a)
+	if err != nil {
+		return false, err
+	}
+	gb, err := newDistanceGeometry(b)
+	if err != nil {
+		return false, err
+	}
+	return ga.withinDistance(gb, radiusInMetersToS1Angle(meters)), nil
+}
+
+// distanceGeometry is a shape split in the s2 shapes of an index and
+// its circles, whose distances are computed from their center.
+type distanceGeometry struct {
+	index *s2.ShapeIndex
+	caps  []s2.Cap
+}
+
+func newDistanceGeometry(shape index.GeoJSON) (*distanceGeometry, error) {
+	g := &distanceGeometry{index: s2.NewShapeIndex()}
+	var points s2.PointVector
+	err := g.add(shape, &points)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > 0 {
+		g.index.Add(&points)
+	}
+	return g, nil
+}
+
+// add adds the shape to the geometry, its points being collected in a
+// single s2.PointVector.
+func (g *distanceGeometry) add(shape index.GeoJSON, points *s2.PointVector) error {
+	switch s := shape.(type) {
+	case *Point:
+		s.init()
+		if s.s2point != nil {
+			*points = append(*points, *s.s2point)
+		}
+
+	case *MultiPoint:
+		s.init()
+		for _, p := range s.s2points {
+			*points = append(*points, *p)
+		}
+
+	case *LineString:
+		s.init()
+		if s.pl != nil && len(*s.pl) > 0 {
+			g.index.Add(s.pl)
+		}
+
+	case *MultiLineString:
+		s.init()
+		for _, pl := range s.pls {
+			if len(*pl) > 0 {
+				g.index.Add(pl)
+			}
+		}
+
+	case *Polygon:
+		s.init()
+		if s.s2pgn != nil && !s.s2pgn.IsEmpty() {
+			g.index.Add(s.s2pgn)
+		}
+
+	case *MultiPolygon:
+		s.init()
+		for _, pgn := range s.s2pgns {
+			if !pgn.IsEmpty() {
+				g.index.Add(pgn)
+			}
+		}
+
+	case *Circle:
+		s.init()
+		g.caps = append(g.caps, *s.s2cap)
+
+	case *Envelope:
+		s.init()
+		g.index.Add(s2PolygonFromS2Rectangle(s.r))
+
+	case *GeometryCollection:
+		for _, member := range s.Members() {
+			if member == nil {
+				continue
+			}
+			if err := g.add(member, points); err != nil {
+				return err
+			}
+		}
+
+	default:
+		if shape == nil {
+			return fmt.Errorf("nil shape")
+		}
+		return fmt.Errorf("unknown geojson type: %s", shape.Type())
+	}
+
+	return nil
+}
+
+// isDistanceLessOrEqual returns whether the edges and interiors of the
+// index come within limit of the target.
+func isDistanceLessOrEqual(idx *s2.ShapeIndex, target s2.Point, limit s1.Angle) bool {
+	if idx.Len() == 0 || limit < 0 {
+		return false
+	}
+	if limit >= math.Pi {
+		return true
+	}
+	query := s2.NewClosestEdgeQuery(idx, nil)
+	return query.IsDistanceLess(s2.NewMinDistanceToPointTarget(target),
+		s1.ChordAngleFromAngle(limit).Successor())
+}
+
+// withinDistance returns whether the geometries come within limit of
+// each other.
+func (g *distanceGeometry) withinDistance(other *distanceGeometry, limit s1.Angle) bool {
+	if g.index.Len() > 0 && other.index.Len() > 0 {
+		if limit >= math.Pi {
+			return true
+		}
+		query := s2.NewClosestEdgeQuery(g.index, nil)
+		target := s2.NewMinDistanceToShapeIndexTarget(other.index)
+		if query.IsDistanceLess(target, s1.ChordAngleFromAngle(limit).Successor()) {
+			return true
+		}
+	}
+
+	// a point is within limit of a circle when it is within limit plus
+	// the radius of its center
+	for _, c := range other.caps {
+		if isDistanceLessOrEqual(g.index, c.Center(), limit+c.Radius()) {
+			return true
+		}
+	}
+	for _, c := range g.caps {
+		if isDistanceLessOrEqual(other.index, c.Center(), limit+c.Radius()) {
+			return true
+		}
+		for _, oc := range other.caps {
+			if c.Center().Distance(oc.Center()) <= limit+c.Radius()+oc.Radius() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MinDistance returns the minimum distance in meters between the
+// shapes, zero when they intersect or when one lies in the interior of
+// a polygon of the other, and +Inf when one of them is empty.
+//
+// It accepts the shapes decoded by ExtractShapesFromBytes and measures
+// them like WithinDistance.
+func MinDistance(a, b index.GeoJSON) (float64, error) {
+	ga, err := newDistanceGeometry(a)
+	if err != nil {
+		return 0, err
+	}
+	gb, err := newDistanceGeometry(b)
+	if err != nil {
+		return 0, err
+	}
+	return ga.minDistance(gb).Radians() * earthRadiusInMeter, nil
+}
+
+// MaxDistance returns the maximum distance in meters between a point of
+// one shape and a point of the other, and -Inf when one of them is
+// empty.
+func MaxDistance(a, b index.GeoJSON) (float64, error) {
+	ga, err := newDistanceGeometry(a)
+	if err != nil {
+		return 0, err
+	}
+	gb, err := newDistanceGeometry(b)
+	if err != nil {
+		return 0, err
+	}
+	return ga.maxDistance(gb).Radians() * earthRadiusInMeter, nil
+}
+
+// minDistanceToPoint returns the distance from the point to the edges
+// and interiors of the index.
+func minDistanceToPoint(idx *s2.ShapeIndex, target s2.Point) s1.Angle {
+	query := s2.NewClosestEdgeQuery(idx, nil)
+	return query.Distance(s2.NewMinDistanceToPointTarget(target)).Angle()
+}
+
+// maxDistanceToPoint returns the distance from the point to the
+// furthest point of the edges and interiors of the index.
+func maxDistanceToPoint(idx *s2.ShapeIndex, target s2.Point) s1.Angle {
+	query := s2.NewFurthestEdgeQuery(idx, nil)
+	return query.Distance(s2.NewMaxDistanceToPointTarget(target)).Angle()
+}
+
+// minDistance returns the minimum distance between the geometries, or
+// +Inf if one of them is empty.
+func (g *distanceGeometry) minDistance(other *distanceGeometry) s1.Angle {
+	rv := s1.Angle(math.Inf(1))
+	update := func(d s1.Angle) {
+		rv = min(rv, max(d, 0))
+	}
+
+	if g.index.Len() > 0 && other.index.Len() > 0 {
+		query := s2.NewClosestEdgeQuery(g.index, nil)
+		update(query.Distance(
+			s2.NewMinDistanceToShapeIndexTarget(other.index)).Angle())
+	}
+	for _, c := range other.caps {
+		if g.index.Len() > 0 {
+			update(minDistanceToPoint(g.index, c.Center()) - c.Radius())
+		}
+	}
+	for _, c := range g.caps {
+		if other.index.Len() > 0 {
+			update(minDistanceToPoint(other.index, c.Center()) - c.Radius())
+		}
+		for _, oc := range other.caps {
+			update(c.Center().Distance(oc.Center()) - c.Radius() - oc.Radius())
+		}
+	}
+	return rv
+}
+
+// maxDistance returns the maximum distance between the geometries, or
+// -Inf if one of them is empty.
+func (g *distanceGeometry) maxDistance(other *distanceGeometry) s1.Angle {
+	rv := s1.Angle(math.Inf(-1))
+	update := func(d s1.Angle) {
+		rv = max(rv, min(d, math.Pi))
+	}
+
+	if g.index.Len() > 0 && other.index.Len() > 0 {
+		query := s2.NewFurthestEdgeQuery(g.index, nil)
+		update(query.Distance(
+			s2.NewMaxDistanceToShapeIndexTarget(other.index)).Angle())
+	}
+	for _, c := range other.caps {
+		if g.index.Len() > 0 {
+			update(maxDistanceToPoint(g.index, c.Center()) + c.Radius())
+		}
+	}
+	for _, c := range g.caps {
+		if other.index.Len() > 0 {
+			update(maxDistanceToPoint(other.index, c.Center()) + c.Radius())
+		}
+		for _, oc := range other.caps {
+			update(c.Center().Distance(oc.Center()) + c.Radius() + oc.Radius())
+		}
+	}
+	return rv
+}
@@ -0,0 +1,186 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// oneDegree is the length of one degree of a great circle in meters.
+const oneDegree = 111319.49
+
+func TestWithinDistance(t *testing.T) {
+	square := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	tests := []struct {
+		a, b index.GeoJSON
+		dist float64
+		want bool
+	}{
+		{NewGeoJsonPoint([]float64{0, 0}), NewGeoJsonPoint([]float64{1, 0}), oneDegree + 1, true},
+		{NewGeoJsonPoint([]float64{0, 0}), NewGeoJsonPoint([]float64{1, 0}), oneDegree - 1, false},
+		// a point inside a polygon
+		{NewGeoJsonPoint([]float64{5, 5}), square, 0, true},
+		{square, NewGeoJsonPoint([]float64{5, 5}), 0, true},
+		{NewGeoJsonPoint([]float64{-1, 0}), square, oneDegree - 100, false},
+		{NewGeoJsonPoint([]float64{-1, 0}), square, oneDegree + 100, true},
+		// lines measured to their edges, not only their vertices
+		{NewGeoJsonLinestring([][]float64{{-10, 0}, {10, 0}}),
+			NewGeoJsonLinestring([][]float64{{0, -10}, {0, -2}}), 2*oneDegree + 1, true},
+		{NewGeoJsonLinestring([][]float64{{-10, 0}, {10, 0}}),
+			NewGeoJsonLinestring([][]float64{{0, -10}, {0, -2}}), 2*oneDegree - 1, false},
+		{NewGeoJsonLinestring([][]float64{{-20, 0}, {20, 0}}), square, 0, true},
+		{NewGeoJsonMultiPolygon([][][][]float64{{testSquare(20, 30)}}), square, 9 * oneDegree, false},
+		// circles measured from their center less their radius
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoJsonPoint([]float64{1, 0}),
+			oneDegree - 100000 + 1, true},
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoJsonPoint([]float64{1, 0}),
+			oneDegree - 100000 - 1, false},
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoCircle([]float64{0, 2}, "100km"),
+			2*oneDegree - 200000 + 1, true},
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoCircle([]float64{0, 2}, "100km"),
+			2*oneDegree - 200000 - 1, false},
+		{NewGeoJsonPoint([]float64{5, 5}), NewGeoCircle([]float64{0, 0}, "10m"), 0, false},
+		{NewGeoEnvelope([][]float64{{0, 10}, {10, 0}}), NewGeoJsonPoint([]float64{11, 0}),
+			oneDegree + 1, true},
+		{&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{50, 50}), NewGeoJsonPoint([]float64{1, 0})}},
+			NewGeoJsonPoint([]float64{0, 0}), oneDegree + 1, true},
+		{&Point{Typ: PointType}, square, 1e7, false},
+	}
+
+	for i, test := range tests {
+		got, err := WithinDistance(test.a, test.b, test.dist)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %v, got %v", i, test.want, got)
+		}
+	}
+}
+
+func TestFilterGeoShapesOnRelationDWithin(t *testing.T) {
+	square := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	data, err := square.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		query    index.GeoJSON
+		relation string
+		want     bool
+	}{
+		{NewGeoJsonPoint([]float64{-1, 0}), "dwithin 112km", true},
+		{NewGeoJsonPoint([]float64{-1, 0}), "dwithin 110km", false},
+		{NewGeoJsonPoint([]float64{-1, 0}), "dwithin 70mi", true},
+		{NewGeoJsonPoint([]float64{-1, 0}), "dwithin 111000", false},
+		{NewGeoCircle([]float64{-2, 5}, "250km"), "dwithin 0m", true},
+	}
+
+	for i, test := range tests {
+		var reader *bytes.Reader
+		got, err := FilterGeoShapesOnRelation(test.query, data, test.relation, &reader, nil)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %v for %s, got %v", i, test.want, test.relation, got)
+		}
+	}
+
+	for _, relation := range []string{"dwithin", "dwithin ", "dwithin abc", "dwithin -5km"} {
+		var reader *bytes.Reader
+		_, err := FilterGeoShapesOnRelation(square, data, relation, &reader, nil)
+		if err == nil {
+			t.Fatalf("expected an error for %q", relation)
+		}
+	}
+}
+
+// degreesDistance returns the distance in meters between two [lng, lat]
+// positions.
+func degreesDistance(a, b []float64) float64 {
+	return s2.LatLngFromDegrees(a[1], a[0]).Distance(
+		s2.LatLngFromDegrees(b[1], b[0])).Radians() * earthRadiusInMeter
+}
+
+func TestMinMaxDistance(t *testing.T) {
+	square := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	tests := []struct {
+		a, b     index.GeoJSON
+		min, max float64
+	}{
+		{NewGeoJsonPoint([]float64{0, 0}), NewGeoJsonPoint([]float64{1, 0}),
+			oneDegree, oneDegree},
+		{NewGeoJsonMultiPoint([][]float64{{0, 0}, {3, 0}}), NewGeoJsonPoint([]float64{1, 0}),
+			oneDegree, 2 * oneDegree},
+		// a point in the interior of a polygon
+		{NewGeoJsonPoint([]float64{0, 0}), square,
+			0, degreesDistance([]float64{0, 0}, []float64{10, 10})},
+		{square, NewGeoJsonPoint([]float64{-1, 0}),
+			oneDegree, degreesDistance([]float64{-1, 0}, []float64{10, 10})},
+		{NewGeoJsonLinestring([][]float64{{-10, 0}, {10, 0}}), NewGeoJsonPoint([]float64{0, -2}),
+			2 * oneDegree, degreesDistance([]float64{0, -2}, []float64{10, 0})},
+		{NewGeoJsonMultilinestring([][][]float64{{{-10, 0}, {10, 0}}}),
+			NewGeoJsonLinestring([][]float64{{0, -10}, {0, -2}}),
+			2 * oneDegree, degreesDistance([]float64{0, -10}, []float64{10, 0})},
+		// circles are measured from their center
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoJsonPoint([]float64{1, 0}),
+			oneDegree - 100000, oneDegree + 100000},
+		{NewGeoCircle([]float64{0, 0}, "100km"), NewGeoCircle([]float64{0, 2}, "50km"),
+			2*oneDegree - 150000, 2*oneDegree + 150000},
+		{NewGeoCircle([]float64{0, 0}, "500km"), NewGeoJsonPoint([]float64{1, 0}),
+			0, oneDegree + 500000},
+		{NewGeoEnvelope([][]float64{{0, 10}, {10, 0}}), NewGeoJsonPoint([]float64{11, 0}),
+			oneDegree, degreesDistance([]float64{11, 0}, []float64{0, 10})},
+		{&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{3, 0}), NewGeoCircle([]float64{0, 0}, "100km")}},
+			NewGeoJsonPoint([]float64{1, 0}), oneDegree - 100000, 2 * oneDegree},
+	}
+
+	for i, test := range tests {
+		// the shape in the document is decoded from its binary encoding
+		for _, b := range []index.GeoJSON{test.b, decodeShape(t, test.b)} {
+			got, err := MinDistance(test.a, b)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if math.Abs(got-test.min) > 0.01 {
+				t.Fatalf("case %d: expected a minimum distance of %v, got %v", i, test.min, got)
+			}
+			got, err = MaxDistance(test.a, b)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if math.Abs(got-test.max) > 0.01 {
+				t.Fatalf("case %d: expected a maximum distance of %v, got %v", i, test.max, got)
+			}
+		}
+	}
+
+	empty := &Point{Typ: PointType}
+	if got, err := MinDistance(empty, square); err != nil || !math.IsInf(got, 1) {
+		t.Fatalf("expected +Inf for an empty shape, got %v %v", got, err)
+	}
+	if got, err := MaxDistance(square, empty); err != nil || !math.IsInf(got, -1) {
+		t.Fatalf("expected -Inf for an empty shape, got %v %v", got, err)
+	}
+}
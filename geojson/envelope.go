@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -30,6 +31,9 @@ type Envelope struct {
 	Typ      string      `json:"type"`
 	Vertices [][]float64 `json:"coordinates"`
 	r        *s2.Rect
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 func NewGeoEnvelope(points [][]float64) index.GeoJSON {
@@ -47,10 +51,23 @@ func (e *Envelope) Value() ([]byte, error) {
 	return jsoniter.Marshal(e)
 }
 
-func (e *Envelope) init() {
-	if e.r == nil {
-		e.r = s2RectFromBounds(e.Vertices[0], e.Vertices[1])
+// MarshalJSON returns the envelope with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	if e.Vertices != nil {
+		type envelope Envelope
+		return jsoniter.Marshal((*envelope)(e))
 	}
+	return ToGeoJSON(e, GeoJSONOptions{})
+}
+
+func (e *Envelope) init() {
+	e.once.Do(func() {
+		if e.r == nil {
+			e.r = s2RectFromBounds(e.Vertices[0], e.Vertices[1])
+		}
+	})
 }
 
 func (e *Envelope) Marshal() ([]byte, error) {
@@ -65,17 +82,20 @@ func (e *Envelope) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{EnvelopeTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{EnvelopeTypePrefix}, b.Bytes()...),
+		e.positions()), nil
 }
 
 func (e *Envelope) Intersects(other index.GeoJSON) (bool, error) {
 	e.init()
+	initShape(other)
 
 	return checkEnvelopeIntersectsShape(e.r, e, other)
 }
 
 func (e *Envelope) Contains(other index.GeoJSON) (bool, error) {
 	e.init()
+	initShape(other)
 
 	return checkEnvelopeContainsShape(e.r, e, other)
 }
@@ -84,21 +104,22 @@ func (e *Envelope) Contains(other index.GeoJSON) (bool, error) {
 // (fully contained in the rectangle) and cross cells (overlapping its
 // boundary).
 func (e *Envelope) IndexCells() (inner, cross []uint64) {
-	e.init()
-	if e.r == nil {
-		return nil, nil
-	}
-	return indexCellsFromRegion(*e.r)
+	return e.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the envelope's query-time covering, partitioned the
 // same way as IndexCells.
 func (e *Envelope) QueryCells() (inner, cross []uint64) {
+	return e.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the envelope computed with the coverer.
+func (e *Envelope) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	e.init()
 	if e.r == nil {
 		return nil, nil
 	}
-	return queryCellsFromRegion(*e.r)
+	return cellsFromRegion(*e.r, coverer)
 }
 
 func (e *Envelope) BoundingBox() index.GeoJSON {
@@ -125,6 +146,9 @@ func checkEnvelopeIntersectsShape(s2rect *s2.Rect, shapeIn,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if s2rect.ContainsPoint(*p2.s2point) {
 			return true, nil
 		}
@@ -223,6 +247,9 @@ func checkEnvelopeContainsShape(s2rect *s2.Rect, shapeIn,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if s2rect.ContainsPoint(*p2.s2point) {
 			return true, nil
 		}
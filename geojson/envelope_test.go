@@ -292,3 +292,39 @@ func TestEnvelopeBoundingBox(t *testing.T) {
 			"rect %v", env.r, e.r)
 	}
 }
+
+func TestEnvelopeAntimeridian(t *testing.T) {
+	tests := []struct {
+		vertices [][]float64
+		in       [][]float64
+		out      [][]float64
+	}{
+		// the west edge east of the east edge crosses the antimeridian
+		{[][]float64{{170, 10}, {-170, -10}},
+			[][]float64{{175, 0}, {180, 0}, {-175, 0}}, [][]float64{{0, 0}, {160, 0}}},
+		{[][]float64{{100, 10}, {-100, -10}},
+			[][]float64{{120, 0}, {180, 0}, {-120, 0}}, [][]float64{{0, 0}, {-90, 0}}},
+		// an envelope wider than 180 degrees not crossing the antimeridian
+		{[][]float64{{-100, 10}, {100, -10}},
+			[][]float64{{-90, 0}, {0, 0}, {90, 0}}, [][]float64{{120, 0}, {180, 0}}},
+		// longitudes beyond 180
+		{[][]float64{{170, 10}, {190, -10}},
+			[][]float64{{175, 0}, {-175, 0}}, [][]float64{{0, 0}, {-160, 0}}},
+		{[][]float64{{-180, 10}, {180, -10}},
+			[][]float64{{0, 0}, {180, 0}, {-90, 0}}, [][]float64{{0, 20}}},
+	}
+
+	for i, test := range tests {
+		e := NewGeoEnvelope(test.vertices)
+		for _, p := range test.in {
+			if ok, _ := e.Intersects(NewGeoJsonPoint(p)); !ok {
+				t.Fatalf("case %d: expected the envelope to contain %v", i, p)
+			}
+		}
+		for _, p := range test.out {
+			if ok, _ := e.Intersects(NewGeoJsonPoint(p)); ok {
+				t.Fatalf("case %d: expected the envelope not to contain %v", i, p)
+			}
+		}
+	}
+}
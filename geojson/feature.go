@@ -0,0 +1,187 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// Feature represents the geoJSON Feature type, a geometry along with
+// the optional identifier and properties of the object it describes.
+type Feature struct {
+	Typ string `json:"type"`
+
+	// ID is the optional identifier of the feature, either a string
+	// or a number (decoded as a float64).
+	ID interface{} `json:"id,omitempty"`
+
+	// Geometry is the shape of the feature, nil for an unlocated
+	// feature.
+	Geometry index.GeoJSON `json:"geometry"`
+
+	// Properties holds the feature's properties as decoded by
+	// encoding/json.
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// MarshalJSON returns the RFC 7946 representation of the feature, with
+// its geometry output by ToGeoJSON.
+func (f *Feature) MarshalJSON() ([]byte, error) {
+	geometry := json.RawMessage("null")
+	if f.Geometry != nil {
+		var err error
+		geometry, err = ToGeoJSON(f.Geometry, GeoJSONOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jsoniter.Marshal(struct {
+		Typ        string                 `json:"type"`
+		ID         interface{}            `json:"id,omitempty"`
+		Geometry   json.RawMessage        `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}{f.Typ, f.ID, geometry, f.Properties})
+}
+
+// FeatureCollection represents the geoJSON FeatureCollection type.
+type FeatureCollection struct {
+	Typ      string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// GeometryCollection returns a geometrycollection with the geometries
+// of the features in the collection. Unlocated features are skipped.
+func (fc *FeatureCollection) GeometryCollection() *GeometryCollection {
+	gc := &GeometryCollection{Typ: GeometryCollectionType,
+		Shapes: make([]index.GeoJSON, 0, len(fc.Features))}
+	for _, f := range fc.Features {
+		if f != nil && f.Geometry != nil {
+			gc.Shapes = append(gc.Shapes, f.Geometry)
+		}
+	}
+	return gc
+}
+
+// ParseGeoJSONFeature unmarshals the geoJSON Feature embedded in the
+// given bytes, keeping its id and properties.
+func ParseGeoJSONFeature(input []byte) (*Feature, error) {
+	return ParseGeoJSONFeatureWithOptions(input, ParseOptions{})
+}
+
+// ParseGeoJSONFeatureWithOptions unmarshals the geoJSON Feature embedded
+// in the given bytes using the given options for its geometry.
+func ParseGeoJSONFeatureWithOptions(input []byte, opts ParseOptions) (
+	*Feature, error) {
+	tmp := struct {
+		Typ        string                 `json:"type"`
+		ID         interface{}            `json:"id"`
+		Geometry   json.RawMessage        `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+		CRS        *geoJSONCRS            `json:"crs"`
+	}{}
+
+	err := jsoniter.Unmarshal(input, &tmp)
+	if err != nil {
+		return nil, err
+	}
+	opts, err = opts.withCRS(tmp.CRS)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(tmp.Typ) != FeatureType {
+		return nil, fmt.Errorf("expected a feature, got type: %s", tmp.Typ)
+	}
+
+	switch id := tmp.ID.(type) {
+	case nil, string, float64:
+	default:
+		return nil, fmt.Errorf("invalid feature id: %v", id)
+	}
+
+	f := &Feature{Typ: tmp.Typ, ID: tmp.ID, Properties: tmp.Properties}
+
+	raw := bytes.TrimSpace(tmp.Geometry)
+	if len(raw) == 0 || string(raw) == "null" {
+		return f, nil
+	}
+
+	var geom struct {
+		Typ string `json:"type"`
+	}
+	err = jsoniter.Unmarshal(tmp.Geometry, &geom)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(geom.Typ) {
+	case FeatureType, FeatureCollectionType:
+		return nil, fmt.Errorf("invalid feature geometry type: %s", geom.Typ)
+	}
+
+	f.Geometry, err = ParseGeoJSONShapeWithOptions(tmp.Geometry, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// ParseGeoJSONFeatureCollection unmarshals the geoJSON FeatureCollection
+// embedded in the given bytes, keeping the id and properties of every
+// feature.
+func ParseGeoJSONFeatureCollection(input []byte) (*FeatureCollection, error) {
+	return ParseGeoJSONFeatureCollectionWithOptions(input, ParseOptions{})
+}
+
+// ParseGeoJSONFeatureCollectionWithOptions unmarshals the geoJSON
+// FeatureCollection embedded in the given bytes using the given options
+// for the features' geometries.
+func ParseGeoJSONFeatureCollectionWithOptions(input []byte,
+	opts ParseOptions) (*FeatureCollection, error) {
+	tmp := struct {
+		Typ      string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+		CRS      *geoJSONCRS       `json:"crs"`
+	}{}
+
+	err := jsoniter.Unmarshal(input, &tmp)
+	if err != nil {
+		return nil, err
+	}
+	opts, err = opts.withCRS(tmp.CRS)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(tmp.Typ) != FeatureCollectionType {
+		return nil, fmt.Errorf("expected a featurecollection, got type: %s",
+			tmp.Typ)
+	}
+
+	fc := &FeatureCollection{Typ: tmp.Typ,
+		Features: make([]*Feature, 0, len(tmp.Features))}
+	for i, raw := range tmp.Features {
+		f, err := ParseGeoJSONFeatureWithOptions(raw, opts)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		fc.Features = append(fc.Features, f)
+	}
+
+	return fc, nil
+}
@@ -0,0 +1,154 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseGeoJSONFeature(t *testing.T) {
+	tests := []struct {
+		input          string
+		wantID         interface{}
+		wantProperties map[string]interface{}
+		wantType       string
+	}{
+		{`{"type": "Feature", "id": "f1",
+			"geometry": {"type": "Point", "coordinates": [1, 2]},
+			"properties": {"name": "a", "count": 3}}`,
+			"f1", map[string]interface{}{"name": "a", "count": float64(3)}, PointType},
+		{`{"type": "feature", "id": 7,
+			"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]},
+			"properties": null}`,
+			float64(7), nil, PolygonType},
+		// an unlocated feature
+		{`{"type": "Feature", "geometry": null, "properties": {"name": "b"}}`,
+			nil, map[string]interface{}{"name": "b"}, ""},
+	}
+
+	for i, test := range tests {
+		f, err := ParseGeoJSONFeature([]byte(test.input))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(f.ID, test.wantID) {
+			t.Fatalf("case %d: expected id %v, got %v", i, test.wantID, f.ID)
+		}
+		if !reflect.DeepEqual(f.Properties, test.wantProperties) {
+			t.Fatalf("case %d: expected properties %v, got %v", i,
+				test.wantProperties, f.Properties)
+		}
+		if test.wantType == "" {
+			if f.Geometry != nil {
+				t.Fatalf("case %d: expected no geometry, got %v", i, f.Geometry)
+			}
+			continue
+		}
+		if f.Geometry == nil || f.Geometry.Type() != test.wantType {
+			t.Fatalf("case %d: expected a %s geometry, got %v", i, test.wantType, f.Geometry)
+		}
+	}
+}
+
+func TestParseGeoJSONFeatureErrors(t *testing.T) {
+	tests := []string{
+		`{"type": "Point", "coordinates": [1, 2]}`,
+		`{"type": "Feature", "id": [1], "geometry": null}`,
+		`{"type": "Feature", "geometry": {"type": "Feature", "geometry": null}}`,
+		`{"type": "Feature", "geometry": {"type": "hexagon", "coordinates": []}}`,
+	}
+
+	for i, test := range tests {
+		if _, err := ParseGeoJSONFeature([]byte(test)); err == nil {
+			t.Fatalf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestParseGeoJSONFeatureCollection(t *testing.T) {
+	input := []byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": "a",
+			"geometry": {"type": "Point", "coordinates": [5, 5]},
+			"properties": {"kind": "poi"}},
+		{"type": "Feature", "id": "b", "geometry": null, "properties": {}},
+		{"type": "Feature", "id": "c",
+			"geometry": {"type": "LineString", "coordinates": [[20, 20], [30, 30]]},
+			"properties": {"kind": "road"}}]}`)
+
+	fc, err := ParseGeoJSONFeatureCollection(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fc.Features) != 3 {
+		t.Fatalf("expected 3 features, got %d", len(fc.Features))
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if fc.Features[i].ID != id {
+			t.Fatalf("feature %d: expected id %q, got %v", i, id, fc.Features[i].ID)
+		}
+	}
+	if kind := fc.Features[2].Properties["kind"]; kind != "road" {
+		t.Fatalf("expected the kind property to be road, got %v", kind)
+	}
+
+	gc := fc.GeometryCollection()
+	if len(gc.Shapes) != 2 {
+		t.Fatalf("expected 2 geometries, got %d", len(gc.Shapes))
+	}
+
+	// ParseGeoJSONShape indexes the collection as a geometrycollection
+	shape, err := ParseGeoJSONShape(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shape.Type() != GeometryCollectionType {
+		t.Fatalf("expected a geometrycollection, got %s", shape.Type())
+	}
+	query := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	if ok, err := shape.Intersects(query); err != nil || !ok {
+		t.Fatalf("expected the collection to intersect the query, got %v %v", ok, err)
+	}
+}
+
+func TestParseGeoJSONShapeFeature(t *testing.T) {
+	shape, err := ParseGeoJSONShape([]byte(`{"type": "Feature",
+		"geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shape.Type() != PointType {
+		t.Fatalf("expected a point, got %s", shape.Type())
+	}
+
+	if _, err := ParseGeoJSONShape([]byte(`{"type": "Feature", "geometry": null}`)); err == nil {
+		t.Fatal("expected an error for a feature without a geometry")
+	}
+}
+
+func TestParseGeoJSONFeatureCollectionStrict(t *testing.T) {
+	input := []byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [5, 5]}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [5, 95]}}]}`)
+
+	if _, err := ParseGeoJSONFeatureCollection(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := ParseGeoJSONFeatureCollectionWithOptions(input, ParseOptions{Strict: true})
+	if !errors.Is(err, ErrLatitudeOutOfRange) {
+		t.Fatalf("expected %v, got %v", ErrLatitudeOutOfRange, err)
+	}
+}
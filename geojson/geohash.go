@@ -0,0 +1,410 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// geohashAlphabet is the base32 alphabet of geohashes, where every
+// character holds five bits, alternately of the longitude and of the
+// latitude, starting with the longitude.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision is the length of the longest geohashes, whose
+// cells are a few centimeters wide.
+const MaxGeohashPrecision = 12
+
+// maxGeohashes is the number of geohashes beyond which the cells
+// covering a shape aren't listed.
+const maxGeohashes = 4096
+
+// geohashTermMaxCells is the number of geohashes covering a shape
+// beyond which its geohash terms use a shorter precision.
+const geohashTermMaxCells = 16
+
+// geohashEpsilon is the margin in degrees, a fraction of a millimeter
+// and of the smallest cells, which absorbs the rounding of the edges of
+// the bounding boxes converted from the radians of s2 when they are
+// covered with cells.
+const geohashEpsilon = 1e-9
+
+// geohashCell is the cell of a geohash: its column and row among the
+// cells of the same precision, from the south west corner of the world.
+type geohashCell struct {
+	x, y      uint64
+	precision int
+}
+
+// geohashBits returns the number of longitude and latitude bits of the
+// geohashes of the precision.
+func geohashBits(precision int) (lngBits, latBits uint) {
+	bits := uint(5 * precision)
+	return (bits + 1) / 2, bits / 2
+}
+
+// geohashCellSize returns the width and the height in degrees of the
+// cells of the geohashes of the precision.
+func geohashCellSize(precision int) (width, height float64) {
+	lngBits, latBits := geohashBits(precision)
+	return 360 / float64(uint64(1)<<lngBits), 180 / float64(uint64(1)<<latBits)
+}
+
+func checkGeohashPrecision(precision int) error {
+	if precision < 1 || precision > MaxGeohashPrecision {
+		return fmt.Errorf("invalid geohash precision: %d", precision)
+	}
+	return nil
+}
+
+// parseGeohash returns the cell of the geohash.
+func parseGeohash(hash string) (geohashCell, error) {
+	if err := checkGeohashPrecision(len(hash)); err != nil {
+		return geohashCell{}, fmt.Errorf("invalid geohash: %q", hash)
+	}
+	c := geohashCell{precision: len(hash)}
+	even := true
+	for i := 0; i < len(hash); i++ {
+		v := strings.IndexByte(geohashAlphabet, hash[i]|0x20)
+		if v < 0 {
+			return geohashCell{}, fmt.Errorf("invalid geohash: %q", hash)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			b := uint64(v>>bit) & 1
+			if even {
+				c.x = c.x<<1 | b
+			} else {
+				c.y = c.y<<1 | b
+			}
+			even = !even
+		}
+	}
+	return c, nil
+}
+
+// String returns the geohash of the cell.
+func (c geohashCell) String() string {
+	lngBits, latBits := geohashBits(c.precision)
+	buf := make([]byte, c.precision)
+	v := 0
+	for i := 0; i < 5*c.precision; i++ {
+		var b uint64
+		if i%2 == 0 {
+			lngBits--
+			b = c.x >> lngBits & 1
+		} else {
+			latBits--
+			b = c.y >> latBits & 1
+		}
+		v = v<<1 | int(b)
+		if i%5 == 4 {
+			buf[i/5] = geohashAlphabet[v]
+			v = 0
+		}
+	}
+	return string(buf)
+}
+
+// bounds returns the south west and north east corners of the cell as
+// [lon, lat] positions.
+func (c geohashCell) bounds() (lo, hi []float64) {
+	width, height := geohashCellSize(c.precision)
+	lo = []float64{-180 + float64(c.x)*width, -90 + float64(c.y)*height}
+	hi = []float64{lo[0] + width, lo[1] + height}
+	return lo, hi
+}
+
+// ParseGeohashEnvelope returns the envelope of the cell of the geohash.
+func ParseGeohashEnvelope(hash string) (index.GeoJSON, error) {
+	c, err := parseGeohash(hash)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := c.bounds()
+	return NewGeoEnvelope([][]float64{{lo[0], hi[1]}, {hi[0], lo[1]}}), nil
+}
+
+// ParseGeohashPoint returns the point at the center of the cell of the
+// geohash.
+func ParseGeohashPoint(hash string) (index.GeoJSON, error) {
+	c, err := parseGeohash(hash)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := c.bounds()
+	return NewGeoJsonPoint([]float64{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2}), nil
+}
+
+// EncodeGeohash returns the geohash of the given precision of the cell
+// which contains the position.
+func EncodeGeohash(lng, lat float64, precision int) (string, error) {
+	if err := checkGeohashPrecision(precision); err != nil {
+		return "", err
+	}
+	if math.IsNaN(lng) || math.IsNaN(lat) || math.IsInf(lng, 0) ||
+		math.IsInf(lat, 0) || lat < -90 || lat > 90 {
+		return "", fmt.Errorf("invalid position: [%v, %v]", lng, lat)
+	}
+	return geohashPointCell(lng, lat, precision).String(), nil
+}
+
+// geohashPointCell returns the cell of the precision which contains the
+// position. A position on the edge of two cells is in the east or the
+// north one, except on the antimeridian and the poles.
+func geohashPointCell(lng, lat float64, precision int) geohashCell {
+	if lng < -180 || lng > 180 {
+		lng = math.Remainder(lng, 360)
+	}
+	lngBits, latBits := geohashBits(precision)
+	width, height := geohashCellSize(precision)
+	return geohashCell{
+		x:         geohashIndex(lng+180, width, uint64(1)<<lngBits),
+		y:         geohashIndex(lat+90, height, uint64(1)<<latBits),
+		precision: precision,
+	}
+}
+
+// geohashIndex returns the index of the cell of the given size which
+// contains the offset in degrees, clamped to the n cells.
+func geohashIndex(offset, size float64, n uint64) uint64 {
+	return uint64(math.Max(0, math.Min(math.Floor(offset/size), float64(n-1))))
+}
+
+// geohashPosition returns the position of the shape if it is a point,
+// whose cell is found from its coordinates rather than from its
+// bounding box.
+func geohashPosition(shape index.GeoJSON) ([]float64, bool) {
+	p, ok := shape.(*Point)
+	if !ok || len(p.Vertices) < 2 {
+		return nil, false
+	}
+	return p.Vertices, true
+}
+
+// GeohashesForShape returns the geohashes of the given precision of the
+// cells which intersect the bounding box of the shape: the cell which
+// contains a point, or the cells which cover an envelope. The east and
+// north edges of the bounding box are left out, so that an envelope
+// parsed from a geohash is encoded as that geohash again. An error is
+// returned when more than 4096 geohashes would be needed.
+func GeohashesForShape(shape index.GeoJSON, precision int) ([]string, error) {
+	if err := checkGeohashPrecision(precision); err != nil {
+		return nil, err
+	}
+	if pos, ok := geohashPosition(shape); ok {
+		return []string{geohashPointCell(pos[0], pos[1], precision).String()}, nil
+	}
+	r, err := geohashRect(shape)
+	if err != nil {
+		return nil, err
+	}
+	if r.IsEmpty() {
+		return nil, nil
+	}
+	if n := geohashCellCount(r, precision); n > maxGeohashes {
+		return nil, fmt.Errorf("too many geohashes of precision %d: %d",
+			precision, n)
+	}
+	cells := geohashCells(r, precision)
+	rv := make([]string, len(cells))
+	for i, c := range cells {
+		rv[i] = c.String()
+	}
+	return rv, nil
+}
+
+// GeohashTerms returns the geohash terms of the shape: the geohashes
+// covering its bounding box, as GeohashesForShape returns them, along
+// with all of their prefixes, like the terms of a geohash prefix tree.
+// A precision shorter than the given one is used when more than a few
+// geohashes are needed to cover the shape.
+func GeohashTerms(shape index.GeoJSON, precision int) ([]string, error) {
+	if err := checkGeohashPrecision(precision); err != nil {
+		return nil, err
+	}
+	if pos, ok := geohashPosition(shape); ok {
+		return geohashPrefixes(geohashPointCell(pos[0], pos[1], precision).String()), nil
+	}
+	r, err := geohashRect(shape)
+	if err != nil {
+		return nil, err
+	}
+	if r.IsEmpty() {
+		return nil, nil
+	}
+
+	for precision > 1 && geohashCellCount(r, precision) > geohashTermMaxCells {
+		precision--
+	}
+	var terms []string
+	for _, c := range geohashCells(r, precision) {
+		terms = append(terms, geohashPrefixes(c.String())...)
+	}
+	return DeduplicateTerms(terms), nil
+}
+
+// geohashPrefixes returns the prefixes of the geohash, down to itself.
+func geohashPrefixes(hash string) []string {
+	rv := make([]string, len(hash))
+	for i := range rv {
+		rv[i] = hash[:i+1]
+	}
+	return rv
+}
+
+// IndexTokensWithGeohash returns the s2 index terms of the shape from
+// the indexer, followed by its geohash terms of the given precision,
+// for the indexes migrating from geohash terms. The members of a
+// geometrycollection are tokenized in turn. A geohash term may equal an
+// s2 term, which only adds candidates for the filtering phase.
+func IndexTokensWithGeohash(shape index.GeoJSON, s *s2.RegionTermIndexer,
+	precision int) ([]string, error) {
+	return tokensWithGeohash(shape, precision, func(t tokenizedShape) []string {
+		return t.IndexTokens(s)
+	})
+}
+
+// QueryTokensWithGeohash returns the s2 query terms of the shape from
+// the indexer, followed by its geohash terms of the given precision. See
+// IndexTokensWithGeohash.
+func QueryTokensWithGeohash(shape index.GeoJSON, s *s2.RegionTermIndexer,
+	precision int) ([]string, error) {
+	return tokensWithGeohash(shape, precision, func(t tokenizedShape) []string {
+		return t.QueryTokens(s)
+	})
+}
+
+// tokenizedShape is implemented by the shapes with s2 terms.
+type tokenizedShape interface {
+	IndexTokens(s *s2.RegionTermIndexer) []string
+	QueryTokens(s *s2.RegionTermIndexer) []string
+}
+
+func tokensWithGeohash(shape index.GeoJSON, precision int,
+	tokens func(tokenizedShape) []string) ([]string, error) {
+	geohashes, err := GeohashTerms(shape, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []string
+	var walk func(index.GeoJSON)
+	walk = func(shape index.GeoJSON) {
+		switch s := shape.(type) {
+		case *GeometryCollection:
+			for _, member := range s.Members() {
+				walk(member)
+			}
+		case tokenizedShape:
+			terms = append(terms, tokens(s)...)
+		}
+	}
+	walk(shape)
+
+	return DeduplicateTerms(append(terms, geohashes...)), nil
+}
+
+// geohashRect returns the bounding box of the shape.
+func geohashRect(shape index.GeoJSON) (s2.Rect, error) {
+	if shape == nil {
+		return s2.EmptyRect(), fmt.Errorf("nil shape")
+	}
+	e, ok := shape.BoundingBox().(*Envelope)
+	if !ok {
+		return s2.EmptyRect(), fmt.Errorf("no bounding box for the %s", shape.Type())
+	}
+	e.init()
+	if e.r == nil {
+		return s2.EmptyRect(), nil
+	}
+	return *e.r, nil
+}
+
+// geohashSpans returns the ranges of the cells of the given size which
+// intersect the intervals in degrees from lo to hi, starting at origin.
+// A cell is counted from the low edge of the intervals but not from the
+// high one, unless they are empty, and the indices are clamped to the n
+// cells.
+func geohashSpans(lo, hi []float64, origin, size float64, n uint64) [][2]uint64 {
+	rv := make([][2]uint64, 0, len(lo))
+	for i := range lo {
+		first := math.Floor((lo[i] - origin + geohashEpsilon) / size)
+		last := math.Ceil((hi[i]-origin-geohashEpsilon)/size) - 1
+		first = math.Max(0, math.Min(first, float64(n-1)))
+		last = math.Max(first, math.Min(last, float64(n-1)))
+		rv = append(rv, [2]uint64{uint64(first), uint64(last)})
+	}
+	return rv
+}
+
+// geohashRanges returns the ranges of the columns and rows of the cells
+// of the precision which intersect the rectangle, which is split in two
+// when it crosses the antimeridian.
+func geohashRanges(r s2.Rect, precision int) (xs, ys [][2]uint64) {
+	lngBits, latBits := geohashBits(precision)
+	width, height := geohashCellSize(precision)
+
+	lng := r.Lng
+	var lngLo, lngHi []float64
+	switch {
+	case lng.IsFull():
+		lngLo, lngHi = []float64{-180}, []float64{180}
+	case lng.IsInverted() && lng.Lo == math.Pi:
+		// s1 turns a west edge at -180 into one at 180
+		lngLo, lngHi = []float64{-180}, []float64{lng.Hi * 180 / math.Pi}
+	case lng.IsInverted():
+		lngLo = []float64{lng.Lo * 180 / math.Pi, -180}
+		lngHi = []float64{180, lng.Hi * 180 / math.Pi}
+	default:
+		lngLo, lngHi = []float64{lng.Lo * 180 / math.Pi}, []float64{lng.Hi * 180 / math.Pi}
+	}
+
+	xs = geohashSpans(lngLo, lngHi, -180, width, uint64(1)<<lngBits)
+	ys = geohashSpans([]float64{r.Lo().Lat.Degrees()}, []float64{r.Hi().Lat.Degrees()},
+		-90, height, uint64(1)<<latBits)
+	return xs, ys
+}
+
+// geohashCellCount returns the number of the cells of the precision
+// which intersect the rectangle.
+func geohashCellCount(r s2.Rect, precision int) uint64 {
+	xs, ys := geohashRanges(r, precision)
+	var columns uint64
+	for _, x := range xs {
+		columns += x[1] - x[0] + 1
+	}
+	return columns * (ys[0][1] - ys[0][0] + 1)
+}
+
+// geohashCells returns the cells of the precision which intersect the
+// rectangle, which mustn't be empty, from west to east and south to
+// north.
+func geohashCells(r s2.Rect, precision int) []geohashCell {
+	var rv []geohashCell
+	xs, ys := geohashRanges(r, precision)
+	for _, x := range xs {
+		for cx := x[0]; cx <= x[1]; cx++ {
+			for cy := ys[0][0]; cy <= ys[0][1]; cy++ {
+				rv = append(rv, geohashCell{x: cx, y: cy, precision: precision})
+			}
+		}
+	}
+	return rv
+}
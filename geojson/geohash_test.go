@@ -0,0 +1,236 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+func TestParseGeohash(t *testing.T) {
+	tests := []struct {
+		hash   string
+		center []float64
+		lo, hi []float64
+	}{
+		{"ezs42", []float64{-5.60302734375, 42.60498046875},
+			[]float64{-5.625, 42.5830078125}, []float64{-5.5810546875, 42.626953125}},
+		{"EZS42", []float64{-5.60302734375, 42.60498046875},
+			[]float64{-5.625, 42.5830078125}, []float64{-5.5810546875, 42.626953125}},
+		{"s", []float64{22.5, 22.5}, []float64{0, 0}, []float64{45, 45}},
+		{"0", []float64{-157.5, -67.5}, []float64{-180, -90}, []float64{-135, -45}},
+		{"zzz", []float64{179.296875, 89.296875},
+			[]float64{178.59375, 88.59375}, []float64{180, 90}},
+	}
+
+	for i, test := range tests {
+		point, err := ParseGeohashPoint(test.hash)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got := point.(*Point).Vertices; !reflect.DeepEqual(got, test.center) {
+			t.Fatalf("case %d: expected center %v, got %v", i, test.center, got)
+		}
+
+		envelope, err := ParseGeohashEnvelope(test.hash)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		want := [][]float64{{test.lo[0], test.hi[1]}, {test.hi[0], test.lo[1]}}
+		if got := envelope.(*Envelope).Vertices; !reflect.DeepEqual(got, want) {
+			t.Fatalf("case %d: expected envelope %v, got %v", i, want, got)
+		}
+
+		ok, err := envelope.Contains(point)
+		if err != nil || !ok {
+			t.Fatalf("case %d: expected the envelope to contain the center, got %v %v",
+				i, ok, err)
+		}
+	}
+
+	for _, hash := range []string{"", "ezs4a", "ezs4i", "ezs 2", "ezs4é",
+		"0123456789bcd"} {
+		if _, err := ParseGeohashEnvelope(hash); err == nil {
+			t.Fatalf("expected an error for %q", hash)
+		}
+		if _, err := ParseGeohashPoint(hash); err == nil {
+			t.Fatalf("expected an error for %q", hash)
+		}
+	}
+}
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		lng, lat  float64
+		precision int
+		want      string
+	}{
+		{10.40744, 57.64911, 11, "u4pruydqqvj"},
+		{-5.6, 42.6, 5, "ezs42"},
+		{0, 0, 1, "s"},
+		{-180, -90, 3, "000"},
+		{180, 90, 3, "zzz"},
+		// longitudes are normalized
+		{190, 0, 2, "80"},
+		{-170, 0, 2, "80"},
+		// positions next to the edges of cells
+		{44.99999, 10, 1, "s"},
+		{45, 10, 1, "t"},
+		{10, 44.99999, 1, "s"},
+		{10, 45, 1, "u"},
+		{-1e-6, 10, 2, "ec"},
+		{0, 10, 2, "s1"},
+		{-5.625, 42.5830078125, 5, "ezs42"},
+		{-5.6250001, 42.5830078125, 5, "ezefr"},
+	}
+
+	for i, test := range tests {
+		got, err := EncodeGeohash(test.lng, test.lat, test.precision)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %s, got %s", i, test.want, got)
+		}
+	}
+
+	for i, test := range []struct {
+		lng, lat  float64
+		precision int
+	}{
+		{0, 0, 0},
+		{0, 0, 13},
+		{0, 91, 5},
+		{math.NaN(), 0, 5},
+		{math.Inf(1), 0, 5},
+		{math.Inf(-1), 0, 5},
+		{0, math.Inf(1), 5},
+	} {
+		if _, err := EncodeGeohash(test.lng, test.lat, test.precision); err == nil {
+			t.Fatalf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestGeohashesForShape(t *testing.T) {
+	// an envelope parsed from a geohash is encoded as that geohash again
+	for _, hash := range []string{"s", "ezs42", "u4pruydqqvj", "zzzz", "0000"} {
+		envelope, err := ParseGeohashEnvelope(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := GeohashesForShape(envelope, len(hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, []string{hash}) {
+			t.Fatalf("expected [%s], got %v", hash, got)
+		}
+	}
+
+	tests := []struct {
+		shape     index.GeoJSON
+		precision int
+		want      []string
+	}{
+		{NewGeoJsonPoint([]float64{-5.6, 42.6}), 5, []string{"ezs42"}},
+		{NewGeoJsonPoint([]float64{44.99999, 10}), 1, []string{"s"}},
+		{NewGeoJsonPoint([]float64{45, 10}), 1, []string{"t"}},
+		// the four cells of precision 1 around the origin
+		{NewGeoEnvelope([][]float64{{-1, 1}, {1, -1}}), 1,
+			[]string{"7", "e", "k", "s"}},
+		// an envelope across the antimeridian
+		{NewGeoEnvelope([][]float64{{170, 10}, {-170, 5}}), 1,
+			[]string{"x", "8"}},
+		{NewGeoJsonLinestring([][]float64{{1, 1}, {50, 1}}), 1,
+			[]string{"s", "t"}},
+		{&GeometryCollection{Typ: GeometryCollectionType}, 5, nil},
+	}
+
+	for i, test := range tests {
+		got, err := GeohashesForShape(test.shape, test.precision)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("case %d: expected %v, got %v", i, test.want, got)
+		}
+	}
+
+	world := NewGeoEnvelope([][]float64{{-180, 90}, {180, -90}})
+	if _, err := GeohashesForShape(world, 5); err == nil {
+		t.Fatal("expected an error for too many geohashes")
+	}
+	if _, err := GeohashesForShape(world, 0); err == nil {
+		t.Fatal("expected an error for an invalid precision")
+	}
+}
+
+func TestGeohashTerms(t *testing.T) {
+	got, err := GeohashTerms(NewGeoJsonPoint([]float64{-5.6, 42.6}), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{"e", "ez", "ezs", "ezs4", "ezs42"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// a large shape is covered with a shorter precision
+	got, err = GeohashTerms(NewGeoEnvelope([][]float64{{-1, 1}, {1, -1}}), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range got {
+		if len(term) >= 12 {
+			t.Fatalf("expected a shorter precision, got %s", term)
+		}
+	}
+	if len(got) > geohashTermMaxCells*12 {
+		t.Fatalf("expected at most %d terms, got %d", geohashTermMaxCells*12, len(got))
+	}
+
+	// the terms come with the s2 terms
+	s := s2.NewRegionTermIndexer()
+	point := NewGeoJsonPoint([]float64{-5.6, 42.6})
+	tokens, err := IndexTokensWithGeohash(point, s, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2Tokens := point.(*Point).IndexTokens(s)
+	if !reflect.DeepEqual(tokens[:len(s2Tokens)], s2Tokens) {
+		t.Fatalf("expected the s2 terms %v first, got %v", s2Tokens, tokens)
+	}
+	if tokens[len(tokens)-1] != "ezs42" {
+		t.Fatalf("expected the geohash terms last, got %v", tokens)
+	}
+
+	collection := &GeometryCollection{Typ: GeometryCollectionType,
+		Shapes: []index.GeoJSON{point}}
+	queryTokens, err := QueryTokensWithGeohash(collection, s, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2Tokens = point.(*Point).QueryTokens(s)
+	if len(queryTokens) != len(s2Tokens)+5 {
+		t.Fatalf("expected the s2 and geohash terms of the member, got %v", queryTokens)
+	}
+}
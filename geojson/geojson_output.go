@@ -0,0 +1,263 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// GeoJSONOptions controls the output of ToGeoJSON.
+type GeoJSONOptions struct {
+	// BBox adds a "bbox" member holding the bounding box of the shape,
+	// as [west, south, east, north], to the top level object.
+	BBox bool
+}
+
+// geoJSONGeometry is the RFC 7946 representation of a geometry, also
+// used for the circle and envelope types.
+type geoJSONGeometry struct {
+	Typ         string      `json:"type"`
+	BBox        []float64   `json:"bbox,omitempty"`
+	Coordinates interface{} `json:"coordinates"`
+	Radius      string      `json:"radius,omitempty"`
+}
+
+// geoJSONGeometryCollection is the RFC 7946 representation of a
+// geometrycollection.
+type geoJSONGeometryCollection struct {
+	Typ        string        `json:"type"`
+	BBox       []float64     `json:"bbox,omitempty"`
+	Geometries []interface{} `json:"geometries"`
+}
+
+// ToGeoJSON returns the RFC 7946 representation of the given shape.
+// The coordinates are rebuilt from the shape's s2 representation, so
+// this also works for the shapes returned by ExtractShapesFromBytes.
+// Polygon exterior rings are counterclockwise and holes clockwise.
+// Altitudes and other extra ordinates kept by the shape are added back
+// to the positions.
+func ToGeoJSON(shape index.GeoJSON, opts GeoJSONOptions) ([]byte, error) {
+	obj, err := geoJSONObject(shape)
+	if err != nil {
+		return nil, err
+	}
+	addExtraOrdinates(obj, shape)
+
+	if opts.BBox {
+		bbox := geoJSONBBox(shape)
+		switch obj := obj.(type) {
+		case *geoJSONGeometry:
+			obj.BBox = bbox
+		case *geoJSONGeometryCollection:
+			obj.BBox = bbox
+		}
+	}
+
+	return jsoniter.Marshal(obj)
+}
+
+// geoJSONObject builds the object to be marshalled for the given shape.
+func geoJSONObject(shape index.GeoJSON) (interface{}, error) {
+	switch s := shape.(type) {
+	case *Point:
+		s.init()
+		if s.s2point == nil {
+			return &geoJSONGeometry{Typ: "Point", Coordinates: []float64{}}, nil
+		}
+		return &geoJSONGeometry{Typ: "Point",
+			Coordinates: positionFromS2Point(*s.s2point)}, nil
+
+	case *MultiPoint:
+		s.init()
+		coords := make([][]float64, 0, len(s.s2points))
+		for _, p := range s.s2points {
+			coords = append(coords, positionFromS2Point(*p))
+		}
+		return &geoJSONGeometry{Typ: "MultiPoint", Coordinates: coords}, nil
+
+	case *LineString:
+		s.init()
+		return &geoJSONGeometry{Typ: "LineString",
+			Coordinates: positionsFromS2Points(*s.pl)}, nil
+
+	case *MultiLineString:
+		s.init()
+		coords := make([][][]float64, 0, len(s.pls))
+		for _, pl := range s.pls {
+			coords = append(coords, positionsFromS2Points(*pl))
+		}
+		return &geoJSONGeometry{Typ: "MultiLineString", Coordinates: coords}, nil
+
+	case *Polygon:
+		s.init()
+		polygons, err := ringsFromS2Polygon(s.s2pgn)
+		if err != nil {
+			return nil, err
+		}
+		switch len(polygons) {
+		case 0:
+			return &geoJSONGeometry{Typ: "Polygon",
+				Coordinates: [][][]float64{}}, nil
+		case 1:
+			return &geoJSONGeometry{Typ: "Polygon",
+				Coordinates: polygons[0]}, nil
+		}
+		// an s2 polygon may have several shells, which can only
+		// be represented as a multipolygon
+		return &geoJSONGeometry{Typ: "MultiPolygon", Coordinates: polygons}, nil
+
+	case *MultiPolygon:
+		s.init()
+		coords := make([][][][]float64, 0, len(s.s2pgns))
+		for _, pgn := range s.s2pgns {
+			polygons, err := ringsFromS2Polygon(pgn)
+			if err != nil {
+				return nil, err
+			}
+			coords = append(coords, polygons...)
+		}
+		return &geoJSONGeometry{Typ: "MultiPolygon", Coordinates: coords}, nil
+
+	case *Circle:
+		s.init()
+		radius := s.Radius
+		if radius == "" {
+			meters := s.s2cap.Radius().Radians() * earthRadiusInMeter
+			radius = strconv.FormatFloat(math.Round(meters*1e3)/1e3,
+				'f', -1, 64) + "m"
+		}
+		return &geoJSONGeometry{Typ: "Circle",
+			Coordinates: positionFromS2Point(s.s2cap.Center()),
+			Radius:      radius}, nil
+
+	case *Envelope:
+		s.init()
+		lo, hi := s.r.Lo(), s.r.Hi()
+		return &geoJSONGeometry{Typ: "Envelope",
+			Coordinates: [][]float64{
+				{roundDegrees(lo.Lng.Degrees()), roundDegrees(hi.Lat.Degrees())},
+				{roundDegrees(hi.Lng.Degrees()), roundDegrees(lo.Lat.Degrees())},
+			}}, nil
+
+	case *GeometryCollection:
+		geometries := make([]interface{}, 0, len(s.Shapes))
+		for _, member := range s.Shapes {
+			if member == nil {
+				continue
+			}
+			obj, err := geoJSONObject(member)
+			if err != nil {
+				return nil, err
+			}
+			geometries = append(geometries, obj)
+		}
+		return &geoJSONGeometryCollection{Typ: "GeometryCollection",
+			Geometries: geometries}, nil
+	}
+
+	if shape == nil {
+		return nil, fmt.Errorf("nil shape")
+	}
+
+	// shapes from outside this package are responsible for their
+	// own representation
+	value, err := shape.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(value), nil
+}
+
+// geoJSONBBox returns the bounding box of the shape as
+// [west, south, east, north], or nil for an empty shape.
+func geoJSONBBox(shape index.GeoJSON) []float64 {
+	env, ok := shape.BoundingBox().(*Envelope)
+	if !ok || env == nil || env.r == nil || env.r.IsEmpty() {
+		return nil
+	}
+	lo, hi := env.r.Lo(), env.r.Hi()
+	return []float64{
+		roundDegrees(lo.Lng.Degrees()), roundDegrees(lo.Lat.Degrees()),
+		roundDegrees(hi.Lng.Degrees()), roundDegrees(hi.Lat.Degrees()),
+	}
+}
+
+// roundDegrees drops the noise added to the coordinates by the round
+// trip through s2.Point, which is far below 1e-12 degrees.
+func roundDegrees(d float64) float64 {
+	d = math.Round(d*1e12) / 1e12
+	if d == 0 {
+		// avoid -0
+		return 0
+	}
+	return d
+}
+
+// positionFromS2Point returns the [lon, lat] position of the point.
+func positionFromS2Point(p s2.Point) []float64 {
+	ll := s2.LatLngFromPoint(p)
+	return []float64{roundDegrees(ll.Lng.Degrees()), roundDegrees(ll.Lat.Degrees())}
+}
+
+// positionsFromS2Points returns the positions of the given points.
+func positionsFromS2Points(points []s2.Point) [][]float64 {
+	rv := make([][]float64, 0, len(points))
+	for _, p := range points {
+		rv = append(rv, positionFromS2Point(p))
+	}
+	return rv
+}
+
+// ringsFromS2Polygon returns the rings of every shell of the polygon,
+// each followed by the rings of its holes. Rings are closed and oriented
+// as required by RFC 7946: shells counterclockwise and holes clockwise.
+func ringsFromS2Polygon(pgn *s2.Polygon) ([][][][]float64, error) {
+	if pgn == nil || pgn.IsEmpty() {
+		return nil, nil
+	}
+	if pgn.IsFull() {
+		return nil, fmt.Errorf("the full polygon has no geoJSON representation")
+	}
+
+	var polygons [][][][]float64
+	// shellPolygon maps the index of a shell loop to its polygon.
+	shellPolygon := make(map[int]int)
+	for i, loop := range pgn.Loops() {
+		// OrientedVertex reverses holes, so the interior of the polygon
+		// is always on the left of the ring.
+		n := loop.NumVertices()
+		ring := make([][]float64, 0, n+1)
+		for j := 0; j < n; j++ {
+			ring = append(ring, positionFromS2Point(loop.OrientedVertex(j)))
+		}
+		ring = append(ring, ring[0])
+
+		if !loop.IsHole() {
+			shellPolygon[i] = len(polygons)
+			polygons = append(polygons, [][][]float64{ring})
+			continue
+		}
+		parent, _ := pgn.Parent(i)
+		k := shellPolygon[parent]
+		polygons[k] = append(polygons[k], ring)
+	}
+	return polygons, nil
+}
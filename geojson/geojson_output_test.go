@@ -0,0 +1,240 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// decodeShape round trips the shape through its binary encoding, which
+// only keeps the s2 representation.
+func decodeShape(t *testing.T, shape index.GeoJSON) index.GeoJSON {
+	t.Helper()
+	data, err := shape.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatalf("%T: marshal failed: %v", shape, err)
+	}
+	var reader *bytes.Reader
+	rv, err := ExtractShapesFromBytes(data, &reader, nil)
+	if err != nil {
+		t.Fatalf("%T: extract failed: %v", shape, err)
+	}
+	return rv
+}
+
+func TestToGeoJSONDecodedShapes(t *testing.T) {
+	hole := [][]float64{{2, 2}, {2, 8}, {8, 8}, {8, 2}, {2, 2}}
+	tests := []struct {
+		shape index.GeoJSON
+		want  string
+	}{
+		{NewGeoJsonPoint([]float64{4.5, 22.5}),
+			`{"type":"Point","coordinates":[4.5,22.5]}`},
+		{NewGeoJsonMultiPoint([][]float64{{1, 1}, {50, 50}}),
+			`{"type":"MultiPoint","coordinates":[[1,1],[50,50]]}`},
+		{NewGeoJsonLinestring([][]float64{{0, 0}, {10, 10}}),
+			`{"type":"LineString","coordinates":[[0,0],[10,10]]}`},
+		{NewGeoJsonMultilinestring([][][]float64{{{0, 0}, {5, 5}}, {{50, 50}, {55, 55}}}),
+			`{"type":"MultiLineString","coordinates":[[[0,0],[5,5]],[[50,50],[55,55]]]}`},
+		{NewGeoJsonPolygon([][][]float64{testSquare(0, 10), hole}),
+			`{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],` +
+				`[[2,2],[2,8],[8,8],[8,2],[2,2]]]}`},
+		{NewGeoJsonMultiPolygon([][][][]float64{{testSquare(0, 10)}, {testSquare(40, 50)}}),
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[10,0],[10,10],[0,10],[0,0]]],` +
+				`[[[40,40],[50,40],[50,50],[40,50],[40,40]]]]}`},
+		{NewGeoCircle([]float64{10, 10}, "1000m"),
+			`{"type":"Circle","coordinates":[10,10],"radius":"1000m"}`},
+		{NewGeoEnvelope([][]float64{{0, 20}, {20, 0}}),
+			`{"type":"Envelope","coordinates":[[0,20],[20,0]]}`},
+	}
+
+	for i, test := range tests {
+		got, err := decodeShape(t, test.shape).(interface {
+			MarshalJSON() ([]byte, error)
+		}).MarshalJSON()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if string(got) != test.want {
+			t.Fatalf("case %d: expected %s, got %s", i, test.want, got)
+		}
+
+		// the output must parse back into the same kind of shape
+		shape, err := ParseGeoJSONShape(got)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error parsing %s: %v", i, got, err)
+		}
+		if shape.Type() != test.shape.Type() {
+			t.Fatalf("case %d: expected type %s, got %s", i, test.shape.Type(), shape.Type())
+		}
+	}
+}
+
+// ringArea returns twice the signed planar area of the ring, positive
+// for a counterclockwise ring.
+func ringArea(ring [][]float64) float64 {
+	var area float64
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area
+}
+
+func TestToGeoJSONRingOrientation(t *testing.T) {
+	// the hole is given counterclockwise, unlike what RFC 7946 expects
+	input := []byte(`{"type": "polygon", "coordinates": [
+		[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+		[[2, 2], [8, 2], [8, 8], [2, 8], [2, 2]]]}`)
+	shape, err := ParseGeoJSONShapeWithOptions(input, ParseOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, s := range []index.GeoJSON{shape, decodeShape(t, shape)} {
+		data, err := ToGeoJSON(s, GeoJSONOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := ParseGeoJSONShape(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rings := got.(*Polygon).Vertices
+		if len(rings) != 2 {
+			t.Fatalf("expected 2 rings, got %d", len(rings))
+		}
+		if ringArea(rings[0]) <= 0 {
+			t.Fatalf("expected a counterclockwise exterior ring, got %v", rings[0])
+		}
+		if ringArea(rings[1]) >= 0 {
+			t.Fatalf("expected a clockwise hole, got %v", rings[1])
+		}
+	}
+}
+
+func TestToGeoJSONBBox(t *testing.T) {
+	tests := []struct {
+		shape index.GeoJSON
+		want  []float64
+	}{
+		{NewGeoJsonPoint([]float64{4.5, 22.5}), []float64{4.5, 22.5, 4.5, 22.5}},
+		{NewGeoJsonLinestring([][]float64{{0, 0}, {10, 0}}), []float64{0, 0, 10, 0}},
+		{NewGeoJsonPolygon([][][]float64{testSquare(-10, 10)}), nil},
+		{NewGeoEnvelope([][]float64{{170, 20}, {-170, 0}}), []float64{170, 0, -170, 20}},
+	}
+
+	for i, test := range tests {
+		data, err := ToGeoJSON(decodeShape(t, test.shape), GeoJSONOptions{BBox: true})
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		var out struct {
+			BBox []float64 `json:"bbox"`
+		}
+		if err := jsoniter.Unmarshal(data, &out); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if len(out.BBox) != 4 {
+			t.Fatalf("case %d: expected a bbox, got %s", i, data)
+		}
+		if test.want != nil && !reflect.DeepEqual(out.BBox, test.want) {
+			t.Fatalf("case %d: expected bbox %v, got %v", i, test.want, out.BBox)
+		}
+	}
+
+	// without the option there is no bbox member
+	data, err := ToGeoJSON(NewGeoJsonPoint([]float64{1, 1}), GeoJSONOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "bbox") {
+		t.Fatalf("expected no bbox member, got %s", data)
+	}
+}
+
+func TestToGeoJSONGeometryCollection(t *testing.T) {
+	input := []byte(`{"type": "geometrycollection", "geometries": [
+		{"type": "point", "coordinates": [1, 2]},
+		{"type": "linestring", "coordinates": [[0, 0], [3, 3]]}]}`)
+	shape, err := ParseGeoJSONShape(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"type":"GeometryCollection","bbox":[0,0,3,3],"geometries":[` +
+		`{"type":"Point","coordinates":[1,2]},` +
+		`{"type":"LineString","coordinates":[[0,0],[3,3]]}]}`
+	for _, s := range []index.GeoJSON{shape, decodeShape(t, shape)} {
+		got, err := ToGeoJSON(s, GeoJSONOptions{BBox: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestValueUnchanged(t *testing.T) {
+	// the shapes built from coordinates are output with them, as given
+	tests := []string{
+		`{"type":"point","coordinates":[0.123456789012345,1]}`,
+		`{"type":"LineString","coordinates":[[0.123456789012345,1],[2,3,4]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]],` +
+			`[[10,10],[11,10],[11,11],[10,11],[10,10]]]}`,
+		`{"type":"circle","coordinates":[1,2],"radius":"10km"}`,
+		`{"type":"envelope","coordinates":[[0,20],[20,0]]}`,
+		`{"type":"geometrycollection","geometries":[` +
+			`{"type":"point","coordinates":[1,2]},` +
+			`{"type":"multipoint","coordinates":[[1,2],[3,4]]}]}`,
+	}
+
+	for i, test := range tests {
+		shape, err := ParseGeoJSONShape([]byte(test))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		got, err := shape.Value()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if string(got) != test {
+			t.Fatalf("case %d: expected %s, got %s", i, test, got)
+		}
+	}
+}
+
+func TestFeatureMarshalJSON(t *testing.T) {
+	f, err := ParseGeoJSONFeature([]byte(`{"type": "Feature", "id": "a",
+		"geometry": {"type": "point", "coordinates": [1, 2]},
+		"properties": {"name": "x"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := jsoniter.Marshal(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[1,2]},` +
+		`"properties":{"name":"x"}}`
+	if string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
@@ -15,15 +15,27 @@
 package geojson
 
 import (
+	"math"
 	"strconv"
 	"strings"
 
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/r1"
 	"github.com/blevesearch/geo/s1"
 	"github.com/blevesearch/geo/s2"
 )
 
 // ------------------------------------------------------------------------
 
+// initShape builds the s2 fields of the shape if it is one of the
+// shapes of this package, as the checks of the other shapes read them
+// directly.
+func initShape(shape index.GeoJSON) {
+	if s, ok := shape.(interface{ init() }); ok {
+		s.init()
+	}
+}
+
 // project the point to all of the linestrings and check if
 // any of the projections are equal to the point.
 func polylineIntersectsPoint(pls []*s2.Polyline,
@@ -194,13 +206,10 @@ func rectangleIntersectsWithLineStrings(s2rect *s2.Rect,
 func s2PolygonFromCoordinates(coordinates [][][]float64) *s2.Polygon {
 	loops := make([]*s2.Loop, 0, len(coordinates))
 	for _, loop := range coordinates {
-		var points []s2.Point
-		if loop[0][0] == loop[len(loop)-1][0] && loop[0][1] == loop[len(loop)-1][1] {
-			loop = loop[:len(loop)-1]
-		}
-		for _, point := range loop {
-			p := s2.PointFromLatLng(s2.LatLngFromDegrees(point[1], point[0]))
-			points = append(points, p)
+		points, _ := s2PointsFromPositions(loop)
+		points, _ = dedupS2Points(points, nil)
+		if len(points) > 1 && points[0] == points[len(points)-1] {
+			points = points[:len(points)-1]
 		}
 		s2loop := s2.LoopFromPoints(points)
 		loops = append(loops, s2loop)
@@ -210,6 +219,80 @@ func s2PolygonFromCoordinates(coordinates [][][]float64) *s2.Polygon {
 	return rv
 }
 
+// maxEdgeLngSpan is the longitude span of the pieces that the edges
+// spanning 180 degrees or more are split into.
+const maxEdgeLngSpan = 90.0
+
+// s2PointsFromPositions returns the points of the given line or ring of
+// [lon, lat] positions, taking care of the edges across the antimeridian.
+//
+// s2 joins consecutive points with the shortest geodesic. This matches
+// the usual reading of an edge between two longitudes in [-180, 180]
+// that are more than 180 degrees apart, like 170 to -170, which crosses
+// the antimeridian. Positions beyond that range (RFC 7946 §3.1.9) are
+// followed literally instead, and edges spanning 180 degrees or more,
+// like 100 to 300, or -180 to 180 around the whole globe, are split into
+// pieces so that they keep going in the direction of the input.
+//
+// vertexIDs maps every returned point to the index of the position that
+// starts its edge.
+func s2PointsFromPositions(positions [][]float64) (points []s2.Point, vertexIDs []int) {
+	points = make([]s2.Point, 0, len(positions))
+	vertexIDs = make([]int, 0, len(positions))
+	var prevLng, prevLat float64
+	for i, pos := range positions {
+		lng, lat := pos[0], pos[1]
+		if i > 0 {
+			d := lng - positions[i-1][0]
+			if math.Abs(d) > 180 && math.Abs(d) < 360 &&
+				math.Abs(lng) <= 180 && math.Abs(positions[i-1][0]) <= 180 {
+				d -= math.Copysign(360, d)
+			}
+			lng = prevLng + d
+			if math.Abs(d) >= 180 {
+				n := math.Ceil(math.Abs(d) / maxEdgeLngSpan)
+				for k := 1.0; k < n; k++ {
+					points = append(points, s2PointFromDegrees(
+						prevLat+(lat-prevLat)*k/n, prevLng+d*k/n))
+					vertexIDs = append(vertexIDs, i-1)
+				}
+			}
+		}
+		points = append(points, s2PointFromDegrees(lat, lng))
+		vertexIDs = append(vertexIDs, i)
+		prevLng, prevLat = lng, lat
+	}
+	return points, vertexIDs
+}
+
+// s2PointFromDegrees returns the point at the given latitude and
+// longitude, which is normalized so that the same meridian always
+// gives the same point.
+func s2PointFromDegrees(lat, lng float64) s2.Point {
+	lng = math.Remainder(lng, 360)
+	if lng == -180 {
+		lng = 180
+	}
+	return s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng))
+}
+
+// dedupS2Points drops the consecutive duplicate points, along with their
+// entries in vertexIDs if it is not nil.
+func dedupS2Points(points []s2.Point, vertexIDs []int) ([]s2.Point, []int) {
+	rvPoints := make([]s2.Point, 0, len(points))
+	var rvIDs []int
+	for i, p := range points {
+		if len(rvPoints) > 0 && rvPoints[len(rvPoints)-1] == p {
+			continue
+		}
+		rvPoints = append(rvPoints, p)
+		if vertexIDs != nil {
+			rvIDs = append(rvIDs, vertexIDs[i])
+		}
+	}
+	return rvPoints, rvIDs
+}
+
 func s2PolygonFromS2Rectangle(s2rect *s2.Rect) *s2.Polygon {
 	loops := make([]*s2.Loop, 0, 1)
 	var points []s2.Point
@@ -245,20 +328,40 @@ func radiusInMetersToS1Angle(radius float64) s1.Angle {
 func s2PolylinesFromCoordinates(coordinates [][][]float64) []*s2.Polyline {
 	var polylines []*s2.Polyline
 	for _, lines := range coordinates {
-		var latlngs []s2.LatLng
-		for _, line := range lines {
-			v := s2.LatLngFromDegrees(line[1], line[0])
-			latlngs = append(latlngs, v)
-		}
-		polylines = append(polylines, s2.PolylineFromLatLngs(latlngs))
+		polylines = append(polylines, s2PolylineFromPositions(lines))
 	}
 	return polylines
 }
 
+// s2PolylineFromPositions returns the polyline through the given
+// [lon, lat] positions (see s2PointsFromPositions).
+func s2PolylineFromPositions(positions [][]float64) *s2.Polyline {
+	points, _ := s2PointsFromPositions(positions)
+	pl := s2.Polyline(points)
+	return &pl
+}
+
+// s2RectFromBounds returns the rectangle with the given top left and
+// bottom right corners, which may also be given the other way around.
+// A west edge east of the east edge means that the rectangle crosses
+// the antimeridian, and corners 360 degrees apart span all longitudes.
 func s2RectFromBounds(topLeft, bottomRight []float64) *s2.Rect {
-	rect := s2.EmptyRect()
-	rect = rect.AddPoint(s2.LatLngFromDegrees(topLeft[1], topLeft[0]))
-	rect = rect.AddPoint(s2.LatLngFromDegrees(bottomRight[1], bottomRight[0]))
+	if topLeft[1] < bottomRight[1] {
+		topLeft, bottomRight = bottomRight, topLeft
+	}
+	lat := r1.Interval{Lo: bottomRight[1] * math.Pi / 180,
+		Hi: topLeft[1] * math.Pi / 180}
+	west, east := topLeft[0], bottomRight[0]
+
+	var lng s1.Interval
+	if math.Abs(east-west) >= 360 {
+		lng = s1.FullInterval()
+	} else {
+		lng = s1.IntervalFromEndpoints(math.Remainder(west, 360)*math.Pi/180,
+			math.Remainder(east, 360)*math.Pi/180)
+	}
+
+	rect := s2.Rect{Lat: lat, Lng: lng}
 	return &rect
 }
 
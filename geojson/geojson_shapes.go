@@ -38,6 +38,8 @@ const (
 	GeometryCollectionType = "geometrycollection"
 	CircleType             = "circle"
 	EnvelopeType           = "envelope"
+	FeatureType            = "feature"
+	FeatureCollectionType  = "featurecollection"
 )
 
 // These are the byte prefixes for identifying the
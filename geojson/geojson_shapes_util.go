@@ -45,6 +45,10 @@ type GeoShape struct {
 // FilterGeoShapesOnRelation extracts the shapes in the document, apply
 // the `relation` filter and confirms whether the shape in the document
 // satisfies the given relation.
+//
+// The relation is one of intersects, contains, within, disjoint,
+// touches, crosses, overlaps, equals, covers and coveredby, or
+// "dwithin <distance>" with a distance accepted by ParseDistance.
 func FilterGeoShapesOnRelation(shape index.GeoJSON, targetShapeBytes []byte,
 	relation string, reader **bytes.Reader, bufPool *s2.GeoBufferPool) (bool, error) {
 
@@ -56,6 +60,25 @@ func FilterGeoShapesOnRelation(shape index.GeoJSON, targetShapeBytes []byte,
 	return filterShapes(shape, shapeInDoc, relation)
 }
 
+// FilterGeoShapesOnRelationWithAltitude is like FilterGeoShapesOnRelation,
+// and when altitudes is not nil the altitudes of the shape in the
+// document must also overlap the range, see AltitudeRange.Matches.
+func FilterGeoShapesOnRelationWithAltitude(shape index.GeoJSON,
+	targetShapeBytes []byte, relation string, altitudes *AltitudeRange,
+	reader **bytes.Reader, bufPool *s2.GeoBufferPool) (bool, error) {
+
+	shapeInDoc, err := ExtractShapesFromBytes(targetShapeBytes, reader, bufPool)
+	if err != nil {
+		return false, err
+	}
+
+	if altitudes != nil && !altitudes.Matches(shapeInDoc) {
+		return false, nil
+	}
+
+	return filterShapes(shape, shapeInDoc, relation)
+}
+
 // ExtractShapesFromBytes unmarshal the bytes to retrieve the
 // embedded geojson shape.
 func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *s2.GeoBufferPool) (
@@ -66,9 +89,21 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		(*r).Reset(targetShapeBytes[1:])
 	}
 
-	switch targetShapeBytes[0] {
+	prefix := targetShapeBytes[0]
+	shape, err := extractShape(prefix&^AltitudeFlag, targetShapeBytes, r, bufPool)
+	if err != nil || prefix&AltitudeFlag == 0 {
+		return shape, err
+	}
+	return readPositions(shape, *r)
+}
+
+// extractShape decodes the s2 encoding of a shape of the given type
+// prefix, leaving the reader after it.
+func extractShape(prefix byte, targetShapeBytes []byte, r **bytes.Reader,
+	bufPool *s2.GeoBufferPool) (index.GeoJSON, error) {
+	switch prefix {
 	case PointTypePrefix:
-		point := &Point{s2point: &s2.Point{}}
+		point := &Point{Typ: PointType, s2point: &s2.Point{}}
 		err := point.s2point.Decode(*r)
 		if err != nil {
 			return nil, err
@@ -82,6 +117,7 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 			return nil, err
 		}
 		multipoint := &MultiPoint{
+			Typ:      MultiPointType,
 			s2points: make([]*s2.Point, 0, numPoints),
 		}
 		for i := 0; i < int(numPoints); i++ {
@@ -96,7 +132,7 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		return multipoint, nil
 
 	case LineStringTypePrefix:
-		ls := &LineString{pl: &s2.Polyline{}}
+		ls := &LineString{Typ: LineStringType, pl: &s2.Polyline{}}
 		err := ls.pl.Decode(*r)
 		if err != nil {
 			return nil, err
@@ -110,7 +146,8 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 			return nil, err
 		}
 
-		mls := &MultiLineString{pls: make([]*s2.Polyline, 0, numLineStrings)}
+		mls := &MultiLineString{Typ: MultiLineStringType,
+			pls: make([]*s2.Polyline, 0, numLineStrings)}
 
 		for i := 0; i < int(numLineStrings); i++ {
 			pl := &s2.Polyline{}
@@ -124,7 +161,7 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		return mls, nil
 
 	case PolygonTypePrefix:
-		pgn := &Polygon{s2pgn: &s2.Polygon{BufPool: bufPool}}
+		pgn := &Polygon{Typ: PolygonType, s2pgn: &s2.Polygon{BufPool: bufPool}}
 		err := pgn.s2pgn.Decode(*r)
 		if err != nil {
 			return nil, err
@@ -138,7 +175,8 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		if err != nil {
 			return nil, err
 		}
-		mpgns := &MultiPolygon{s2pgns: make([]*s2.Polygon, 0, numPolygons)}
+		mpgns := &MultiPolygon{Typ: MultiPolygonType,
+			s2pgns: make([]*s2.Polygon, 0, numPolygons)}
 		for i := 0; i < int(numPolygons); i++ {
 			pgn := &s2.Polygon{}
 			err := pgn.Decode(*r)
@@ -168,7 +206,8 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		}
 
 		inputBytes := targetShapeBytes[len(targetShapeBytes)-(*r).Len():]
-		gc := &GeometryCollection{Shapes: make([]index.GeoJSON, numShapes)}
+		gc := &GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: make([]index.GeoJSON, numShapes)}
 
 		for i := int32(0); i < numShapes; i++ {
 			shape, err := ExtractShapesFromBytes(inputBytes[:lengths[i]], r, nil)
@@ -183,7 +222,7 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		return gc, nil
 
 	case CircleTypePrefix:
-		c := &Circle{s2cap: &s2.Cap{}}
+		c := &Circle{Typ: CircleType, s2cap: &s2.Cap{}}
 		err := c.s2cap.Decode(*r)
 		if err != nil {
 			return nil, err
@@ -192,7 +231,7 @@ func ExtractShapesFromBytes(targetShapeBytes []byte, r **bytes.Reader, bufPool *
 		return c, nil
 
 	case EnvelopeTypePrefix:
-		e := &Envelope{r: &s2.Rect{}}
+		e := &Envelope{Typ: EnvelopeType, r: &s2.Rect{}}
 		err := e.r.Decode(*r)
 		if err != nil {
 			return nil, err
@@ -226,20 +265,122 @@ func filterShapes(shape index.GeoJSON,
 		return !intersects, err
 	}
 
+	meters, ok, err := parseDWithinRelation(relation)
+	if ok {
+		if err != nil {
+			return false, err
+		}
+		return WithinDistance(shapeInDoc, shape, meters)
+	}
+
+	// the other relations are given for the shape in the document
+	// against the query shape, like contains and within above.
+	if predicate, ok := relatePredicates[relation]; ok {
+		m, err := Relate(shapeInDoc, shape)
+		if err != nil {
+			return false, err
+		}
+		return predicate(m), nil
+	}
+
 	return false, fmt.Errorf("unknown relation: %s", relation)
 }
 
+// ParseOptions controls how ParseGeoJSONShapeWithOptions parses
+// a shape.
+type ParseOptions struct {
+	// Strict enables the validation of the shape's coordinates before
+	// the shape is built. Shapes with unclosed rings, rings with fewer
+	// than four positions, out of range latitudes, NaN coordinates or
+	// self-intersecting polygons are rejected with an error wrapping a
+	// *ValidationError instead of being indexed as they are.
+	Strict bool
+
+	// SimplifyTolerance, when positive, is the distance in meters within
+	// which the linestrings and polygons of the shape are simplified once
+	// it is built, which shrinks the encoding and speeds up the relations
+	// of shapes with many vertices. See Simplify. Zero disables it.
+	SimplifyTolerance float64
+
+	// KeepOriginal keeps the original coordinates of a simplified shape,
+	// as returned by Coordinates and Value, e.g. for display, while its
+	// s2 representation, which is encoded and related, is simplified.
+	// The shapes decoded from the encoding are the simplified ones, and
+	// so are the positions encoded with their altitudes.
+	KeepOriginal bool
+
+	// CRS names the coordinate reference system of the coordinates, like
+	// EPSG:3857 for Web Mercator meters, when they aren't the longitudes
+	// and latitudes of RFC 7946. The coordinates are then unprojected,
+	// along with the straight edges between them (see
+	// s2.EdgeTessellator). The crs member of a geoJSON input, as defined
+	// before RFC 7946, takes precedence. EPSG:3857 and its aliases, and
+	// the plate carree EPSG:4087 and EPSG:32662 are supported. Other crs
+	// members are ignored, unless CRS is set or Strict is enabled.
+	CRS string
+}
+
+// validate validates the given shape if strict parsing is enabled.
+func (o ParseOptions) validate(shape interface{ Validate() error }) error {
+	if !o.Strict {
+		return nil
+	}
+	return shape.Validate()
+}
+
+// simplify simplifies the given shape if a tolerance is set.
+func (o ParseOptions) simplify(shape index.GeoJSON) (index.GeoJSON, error) {
+	if o.SimplifyTolerance == 0 {
+		return shape, nil
+	}
+	return simplifyShape(shape, o.SimplifyTolerance, o.KeepOriginal)
+}
+
+// build unprojects the coordinates of the given shape if they have
+// a CRS and validates them if strict parsing is enabled, then builds
+// its s2 representation with init and simplifies it if a tolerance is
+// set.
+func (o ParseOptions) build(shape interface {
+	index.GeoJSON
+	Validate() error
+}, init func()) (index.GeoJSON, error) {
+	if err := o.unprojectShape(shape); err != nil {
+		return nil, err
+	}
+	if err := o.validate(shape); err != nil {
+		return nil, err
+	}
+	init()
+	return o.simplify(shape)
+}
+
 // ParseGeoJSONShape unmarshals the geojson/circle/envelope shape
-// embedded in the given bytes.
+// embedded in the given bytes. For a geoJSON Feature the feature's
+// geometry is returned, and a FeatureCollection is returned as a
+// geometrycollection of its features' geometries; use
+// ParseGeoJSONFeature and ParseGeoJSONFeatureCollection to keep the
+// features' ids and properties.
 func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
+	return ParseGeoJSONShapeWithOptions(input, ParseOptions{})
+}
+
+// ParseGeoJSONShapeWithOptions unmarshals the geojson/circle/envelope
+// shape embedded in the given bytes using the given options.
+func ParseGeoJSONShapeWithOptions(input []byte, opts ParseOptions) (
+	index.GeoJSON, error) {
 	var sType string
 	var tmp struct {
-		Typ string `json:"type"`
+		Typ string      `json:"type"`
+		CRS *geoJSONCRS `json:"crs"`
 	}
 	err := jsoniter.Unmarshal(input, &tmp)
 	if err != nil {
 		return nil, err
 	}
+	opts, err = opts.withCRS(tmp.CRS)
+	if err != nil {
+		return nil, err
+	}
 
 	sType = strings.ToLower(tmp.Typ)
 
@@ -250,8 +391,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case MultiPolygonType:
 		var rv MultiPolygon
@@ -259,8 +399,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case PointType:
 		var rv Point
@@ -268,8 +407,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case MultiPointType:
 		var rv MultiPoint
@@ -277,8 +415,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case LineStringType:
 		var rv LineString
@@ -286,8 +423,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case MultiLineStringType:
 		var rv MultiLineString
@@ -295,10 +431,12 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case GeometryCollectionType:
+		if opts.Strict || opts.SimplifyTolerance != 0 || opts.CRS != "" {
+			return parseGeometryCollectionWithOptions(input, opts)
+		}
 		var rv GeometryCollection
 		err := jsoniter.Unmarshal(input, &rv)
 		if err != nil {
@@ -312,8 +450,7 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
 
 	case EnvelopeType:
 		var rv Envelope
@@ -321,8 +458,24 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 		if err != nil {
 			return nil, err
 		}
-		rv.init()
-		return &rv, nil
+		return opts.build(&rv, rv.init)
+
+	case FeatureType:
+		f, err := ParseGeoJSONFeatureWithOptions(input, opts)
+		if err != nil {
+			return nil, err
+		}
+		if f.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		return f.Geometry, nil
+
+	case FeatureCollectionType:
+		fc, err := ParseGeoJSONFeatureCollectionWithOptions(input, opts)
+		if err != nil {
+			return nil, err
+		}
+		return fc.GeometryCollection(), nil
 
 	default:
 		return nil, fmt.Errorf("unknown shape type: %s", sType)
@@ -333,110 +486,170 @@ func ParseGeoJSONShape(input []byte) (index.GeoJSON, error) {
 // an envelope from the given coordinates and type.
 func NewGeoJsonShape(coordinates [][][][]float64, typ string) (
 	index.GeoJSON, []byte, error) {
+	return NewGeoJsonShapeWithOptions(coordinates, typ, ParseOptions{})
+}
+
+// NewGeoJsonShapeWithOptions instantiate a geojson shape/circle or
+// an envelope from the given coordinates and type, validating and
+// simplifying it as the given options require.
+func NewGeoJsonShapeWithOptions(coordinates [][][][]float64, typ string,
+	opts ParseOptions) (index.GeoJSON, []byte, error) {
 	if len(coordinates) == 0 {
 		return nil, nil, fmt.Errorf("missing coordinates")
 	}
 
 	typ = strings.ToLower(typ)
 
+	if unprojected, err := opts.unproject(coordinates, typ); err != nil {
+		return nil, nil, err
+	} else if unprojected != nil {
+		coordinates = unprojected
+	}
+
+	var shape index.GeoJSON
 	switch typ {
 	case PointType:
-		point := NewGeoJsonPoint(coordinates[0][0][0])
-		value, err := point.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return point, value, nil
-
+		shape = NewGeoJsonPoint(coordinates[0][0][0])
 	case MultiPointType:
-		multipoint := NewGeoJsonMultiPoint(coordinates[0][0])
-		value, err := multipoint.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return multipoint, value, nil
-
+		shape = NewGeoJsonMultiPoint(coordinates[0][0])
 	case LineStringType:
-		linestring := NewGeoJsonLinestring(coordinates[0][0])
-		value, err := linestring.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return linestring, value, nil
-
+		shape = NewGeoJsonLinestring(coordinates[0][0])
 	case MultiLineStringType:
-		multilinestring := NewGeoJsonMultilinestring(coordinates[0])
-		value, err := multilinestring.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return multilinestring, value, nil
-
+		shape = NewGeoJsonMultilinestring(coordinates[0])
 	case PolygonType:
-		polygon := NewGeoJsonPolygon(coordinates[0])
-		value, err := polygon.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return polygon, value, nil
-
+		shape = NewGeoJsonPolygon(coordinates[0])
 	case MultiPolygonType:
-		multipolygon := NewGeoJsonMultiPolygon(coordinates)
-		value, err := multipolygon.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return multipolygon, value, nil
-
+		shape = NewGeoJsonMultiPolygon(coordinates)
 	case EnvelopeType:
-		envelope := NewGeoEnvelope(coordinates[0][0])
-		value, err := envelope.(s2Serializable).Marshal()
-		if err != nil {
-			return nil, nil, err
-		}
-		return envelope, value, nil
+		shape = NewGeoEnvelope(coordinates[0][0])
+	default:
+		return nil, nil, fmt.Errorf("unknown shape type: %s", typ)
 	}
 
-	return nil, nil, fmt.Errorf("unknown shape type: %s", typ)
+	if err := opts.validate(shape.(interface{ Validate() error })); err != nil {
+		return nil, nil, err
+	}
+	shape, err := opts.simplify(shape)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := shape.(s2Serializable).Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	return shape, value, nil
 }
 
 // GlueBytes primarily for quicker filtering of docvalues
 // during the filtering phase.
 var GlueBytes = []byte("##")
 
+// MemberError describes a member of a geometrycollection that could
+// not be built and the reason why.
+type MemberError struct {
+	// Index is the position of the member in the given shapes.
+	Index int
+
+	// Type is the type of the member.
+	Type string
+
+	Err error
+}
+
+func (e *MemberError) Error() string {
+	return fmt.Sprintf("member %d (%s): %v", e.Index, e.Type, e.Err)
+}
+
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// GeometryCollectionError is returned by NewGeometryCollectionStrict
+// when some members of the geometrycollection could not be built. It
+// lists every failing member.
+type GeometryCollectionError struct {
+	Members []*MemberError
+}
+
+func (e *GeometryCollectionError) Error() string {
+	msgs := make([]string, len(e.Members))
+	for i, m := range e.Members {
+		msgs[i] = m.Error()
+	}
+	return fmt.Sprintf("geometrycollection has %d invalid members: %s",
+		len(e.Members), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failing members, so that errors.Is
+// and errors.As look through them.
+func (e *GeometryCollectionError) Unwrap() []error {
+	errs := make([]error, len(e.Members))
+	for i, m := range e.Members {
+		errs[i] = m
+	}
+	return errs
+}
+
 // NewGeometryCollection instantiate a geometrycollection
 // and prefix the byte contents with certain glue bytes that
 // can be used later while filtering the doc values.
+// Members that cannot be built are skipped, use
+// NewGeometryCollectionLenient to find out about them or
+// NewGeometryCollectionStrict to reject the collection instead.
 func NewGeometryCollection(shapes []*GeoShape) (
 	index.GeoJSON, []byte, error) {
+	gc, vbytes, _, err := NewGeometryCollectionLenient(shapes)
+	return gc, vbytes, err
+}
+
+// NewGeometryCollectionStrict is like NewGeometryCollection, but it
+// fails with a *GeometryCollectionError when any member cannot be built.
+func NewGeometryCollectionStrict(shapes []*GeoShape) (
+	index.GeoJSON, []byte, error) {
+	gc, vbytes, warnings, err := NewGeometryCollectionLenient(shapes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(warnings) > 0 {
+		return nil, nil, &GeometryCollectionError{Members: warnings}
+	}
+	return gc, vbytes, nil
+}
+
+// NewGeometryCollectionLenient is like NewGeometryCollection, and it
+// also returns a warning for every member that could not be built and
+// was left out of the collection.
+func NewGeometryCollectionLenient(shapes []*GeoShape) (
+	index.GeoJSON, []byte, []*MemberError, error) {
 	for _, shape := range shapes {
 		if shape == nil {
-			return nil, nil, fmt.Errorf("nil shape")
+			return nil, nil, nil, fmt.Errorf("nil shape")
 		}
 		if shape.Type == CircleType && shape.Radius == "" && shape.Center == nil {
-			return nil, nil, fmt.Errorf("missing radius or center information for some circles")
+			return nil, nil, nil, fmt.Errorf("missing radius or center information for some circles")
 		}
 		if shape.Type != CircleType && shape.Coordinates == nil {
-			return nil, nil, fmt.Errorf("missing coordinates for some shapes")
+			return nil, nil, nil, fmt.Errorf("missing coordinates for some shapes")
 		}
 	}
 
 	childShapes := make([]index.GeoJSON, 0, len(shapes))
+	var warnings []*MemberError
 
-	for _, shape := range shapes {
+	for i, shape := range shapes {
+		var child index.GeoJSON
+		var err error
 		if shape.Type == CircleType {
-			circle, _, err := NewGeoCircleShape(shape.Center, shape.Radius)
-			if err != nil {
-				continue
-			}
-			childShapes = append(childShapes, circle)
+			child, _, err = NewGeoCircleShape(shape.Center, shape.Radius)
 		} else {
-			geoShape, _, err := NewGeoJsonShape(shape.Coordinates, shape.Type)
-			if err != nil {
-				continue
-			}
-			childShapes = append(childShapes, geoShape)
+			child, _, err = NewGeoJsonShape(shape.Coordinates, shape.Type)
+		}
+		if err != nil {
+			warnings = append(warnings,
+				&MemberError{Index: i, Type: shape.Type, Err: err})
+			continue
 		}
+		childShapes = append(childShapes, child)
 	}
 
 	var gc GeometryCollection
@@ -444,10 +657,10 @@ func NewGeometryCollection(shapes []*GeoShape) (
 	gc.Shapes = childShapes
 	vbytes, err := gc.Marshal()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return &gc, vbytes, nil
+	return &gc, vbytes, warnings, nil
 }
 
 // NewGeoCircleShape instantiate a circle shape and
@@ -16,6 +16,7 @@ package geojson
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -140,3 +141,96 @@ func TestFilterGeoShapesOnRelation(t *testing.T) {
 		t.Fatal("expected an error for an unknown relation")
 	}
 }
+
+func TestNewGeometryCollectionModes(t *testing.T) {
+	shapes := []*GeoShape{
+		{Type: PointType, Coordinates: [][][][]float64{{{{4.5, 22.5}}}}},
+		{Type: "hexagon", Coordinates: [][][][]float64{{{{1, 1}}}}},
+		{Type: CircleType, Center: []float64{10, 10}, Radius: "10 parsecs"},
+		{Type: PolygonType, Coordinates: [][][][]float64{{testSquare(0, 10)}}},
+	}
+
+	// the default mode silently skips the failing members
+	gc, _, err := NewGeometryCollection(shapes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(gc.(*GeometryCollection).Members()); n != 2 {
+		t.Fatalf("expected 2 members, got %d", n)
+	}
+
+	// the lenient mode reports them
+	gc, value, warnings, err := NewGeometryCollectionLenient(shapes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(gc.(*GeometryCollection).Members()); n != 2 {
+		t.Fatalf("expected 2 members, got %d", n)
+	}
+	if len(value) == 0 {
+		t.Fatal("expected the encoded collection")
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warnings)
+	}
+	for i, want := range []struct {
+		index int
+		typ   string
+	}{{1, "hexagon"}, {2, CircleType}} {
+		if warnings[i].Index != want.index || warnings[i].Type != want.typ ||
+			warnings[i].Err == nil {
+			t.Fatalf("warning %d: expected member %d (%s), got %v",
+				i, want.index, want.typ, warnings[i])
+		}
+	}
+
+	// the strict mode rejects the collection
+	gc, value, err = NewGeometryCollectionStrict(shapes)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if gc != nil || value != nil {
+		t.Fatalf("expected no collection, got %v", gc)
+	}
+	var gcErr *GeometryCollectionError
+	if !errors.As(err, &gcErr) || len(gcErr.Members) != 2 {
+		t.Fatalf("expected a *GeometryCollectionError with 2 members, got %v", err)
+	}
+	var memberErr *MemberError
+	if !errors.As(err, &memberErr) || memberErr.Index != 1 {
+		t.Fatalf("expected the error to wrap the first failing member, got %v", err)
+	}
+
+	// without failing members the modes agree
+	valid := []*GeoShape{shapes[0], shapes[3]}
+	_, want, err := NewGeometryCollection(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, got, err := NewGeometryCollectionStrict(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("expected the strict mode to encode the same collection")
+	}
+	_, got, warnings, err = NewGeometryCollectionLenient(valid)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no error or warnings, got %v, %v", err, warnings)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("expected the lenient mode to encode the same collection")
+	}
+
+	// shapes missing their coordinates are rejected in every mode
+	missing := []*GeoShape{shapes[0], {Type: PolygonType}}
+	if _, _, err := NewGeometryCollection(missing); err == nil {
+		t.Fatal("expected an error for a shape without coordinates")
+	}
+	if _, _, _, err := NewGeometryCollectionLenient(missing); err == nil {
+		t.Fatal("expected an error for a shape without coordinates")
+	}
+	if _, _, err := NewGeometryCollectionStrict(missing); err == nil {
+		t.Fatal("expected an error for a shape without coordinates")
+	}
+}
@@ -14,7 +14,12 @@
 
 package geojson
 
-import "github.com/blevesearch/geo/s2"
+import (
+	"fmt"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
 
 // Region coverer configuration for the geo shape v2 index.
 const (
@@ -39,9 +44,134 @@ var regionCovererQueryV2 = &s2.RegionCoverer{
 	MaxCells: maxQueryCells,
 }
 
-// pointCell returns the single maxCellLevel cell that contains a point.
-func pointCell(p s2.Point) uint64 {
-	return uint64(s2.CellIDFromLatLng(s2.LatLngFromPoint(p)).Parent(maxCellLevel))
+// CovererOptions configures the region coverers of a geo shape v2 index
+// field. MinLevel, MaxLevel and LevelMod restrict the levels of the
+// covering cells as in s2.RegionCoverer, MaxIndexCells and MaxQueryCells
+// bound the number of cells of the index-time and query-time coverings.
+type CovererOptions struct {
+	MinLevel      int
+	MaxLevel      int
+	LevelMod      int
+	MaxIndexCells int
+	MaxQueryCells int
+}
+
+// DefaultCovererOptions returns the options used by the IndexCells and
+// QueryCells methods of the shapes.
+func DefaultCovererOptions() CovererOptions {
+	return CovererOptions{
+		MinLevel:      minCellLevel,
+		MaxLevel:      maxCellLevel,
+		LevelMod:      cellLevelMod,
+		MaxIndexCells: maxIndexCells,
+		MaxQueryCells: maxQueryCells,
+	}
+}
+
+// Validate returns an error when the options are out of the ranges
+// supported by s2.RegionCoverer, which would otherwise silently clamp them.
+func (o CovererOptions) Validate() error {
+	if o.MinLevel < 0 || o.MinLevel > s2.MaxLevel {
+		return fmt.Errorf("invalid coverer min level: %d, expected 0 to %d",
+			o.MinLevel, s2.MaxLevel)
+	}
+	if o.MaxLevel < o.MinLevel || o.MaxLevel > s2.MaxLevel {
+		return fmt.Errorf("invalid coverer max level: %d, expected %d to %d",
+			o.MaxLevel, o.MinLevel, s2.MaxLevel)
+	}
+	if o.LevelMod < 1 || o.LevelMod > 3 {
+		return fmt.Errorf("invalid coverer level mod: %d, expected 1 to 3",
+			o.LevelMod)
+	}
+	if o.MaxIndexCells < 1 {
+		return fmt.Errorf("invalid coverer max index cells: %d", o.MaxIndexCells)
+	}
+	if o.MaxQueryCells < 1 {
+		return fmt.Errorf("invalid coverer max query cells: %d", o.MaxQueryCells)
+	}
+	return nil
+}
+
+func (o CovererOptions) indexCoverer() *s2.RegionCoverer {
+	return &s2.RegionCoverer{
+		MinLevel: o.MinLevel,
+		MaxLevel: o.MaxLevel,
+		LevelMod: o.LevelMod,
+		MaxCells: o.MaxIndexCells,
+	}
+}
+
+func (o CovererOptions) queryCoverer() *s2.RegionCoverer {
+	return &s2.RegionCoverer{
+		MinLevel: o.MinLevel,
+		MaxLevel: o.MaxLevel,
+		LevelMod: o.LevelMod,
+		MaxCells: o.MaxQueryCells,
+	}
+}
+
+// IndexCellsWithOptions returns the index-time covering of the shape like
+// its IndexCells method, using the coverer configured by opts.
+func IndexCellsWithOptions(shape index.GeoJSON, opts CovererOptions) (
+	inner, cross []uint64, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	inner, cross = shapeCells(shape, opts.indexCoverer(), index.GeoJSON.IndexCells)
+	return inner, cross, nil
+}
+
+// QueryCellsWithOptions returns the query-time covering of the shape like
+// its QueryCells method, using the coverer configured by opts.
+func QueryCellsWithOptions(shape index.GeoJSON, opts CovererOptions) (
+	inner, cross []uint64, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	inner, cross = shapeCells(shape, opts.queryCoverer(), index.GeoJSON.QueryCells)
+	return inner, cross, nil
+}
+
+// coveredShape is implemented by the shapes of the package, whose
+// covering can be computed with any coverer.
+type coveredShape interface {
+	cells(coverer *s2.RegionCoverer) (inner, cross []uint64)
+}
+
+// shapeCells returns the covering of the shape computed with the coverer.
+// Shapes from outside the package only know their own covering, which is
+// returned by fallback.
+func shapeCells(shape index.GeoJSON, coverer *s2.RegionCoverer,
+	fallback func(index.GeoJSON) (inner, cross []uint64)) (inner, cross []uint64) {
+	switch s := shape.(type) {
+	case nil:
+		return nil, nil
+	case *GeometryCollection:
+		return s.cells(coverer, func(member index.GeoJSON) ([]uint64, []uint64) {
+			return shapeCells(member, coverer, fallback)
+		})
+	case coveredShape:
+		return s.cells(coverer)
+	}
+	return fallback(shape)
+}
+
+// pointCellLevel returns the level of the cells of points for the
+// coverer: its max level, lowered to the nearest level allowed by its
+// min level and level mod.
+func pointCellLevel(coverer *s2.RegionCoverer) int {
+	levelMod := coverer.LevelMod
+	if levelMod < 1 {
+		levelMod = 1
+	}
+	return coverer.MaxLevel - (coverer.MaxLevel-coverer.MinLevel)%levelMod
+}
+
+// pointCell returns the single cell that contains a point, at the point
+// cell level of the coverer.
+func pointCell(p s2.Point, coverer *s2.RegionCoverer) uint64 {
+	return uint64(s2.CellIDFromLatLng(s2.LatLngFromPoint(p)).
+		Parent(pointCellLevel(coverer)))
 }
 
 // envelopeFromRect builds an Envelope GeoJSON from an s2.Rect.
@@ -80,13 +210,3 @@ func cellsFromRegion(region s2.Region, coverer *s2.RegionCoverer) (inner, cross
 	}
 	return inner, cross
 }
-
-// indexCellsFromRegion partitions the index-time covering of the region.
-func indexCellsFromRegion(region s2.Region) (inner, cross []uint64) {
-	return cellsFromRegion(region, regionCovererIndexV2)
-}
-
-// queryCellsFromRegion partitions the query-time covering of the region.
-func queryCellsFromRegion(region s2.Region) (inner, cross []uint64) {
-	return cellsFromRegion(region, regionCovererQueryV2)
-}
@@ -18,6 +18,7 @@ import (
 	"math"
 	"testing"
 
+	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
 )
 
@@ -142,8 +143,8 @@ func TestIndexVsQueryCells(t *testing.T) {
 	// produce a finer covering than the index-time coverer (maxIndexCells)
 	pgn := s2PolygonFromCoordinates([][][]float64{testSquare(0, 60)})
 
-	indexInner, indexCross := indexCellsFromRegion(pgn)
-	queryInner, queryCross := queryCellsFromRegion(pgn)
+	indexInner, indexCross := cellsFromRegion(pgn, regionCovererIndexV2)
+	queryInner, queryCross := cellsFromRegion(pgn, regionCovererQueryV2)
 
 	indexTotal := len(indexInner) + len(indexCross)
 	queryTotal := len(queryInner) + len(queryCross)
@@ -166,7 +167,7 @@ func TestPointCell(t *testing.T) {
 	lat, lng := 22.5, 4.5
 	pt := s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng))
 
-	cell := s2.CellID(pointCell(pt))
+	cell := s2.CellID(pointCell(pt, regionCovererIndexV2))
 	if !cell.IsValid() {
 		t.Fatalf("pointCell returned an invalid cell: %d", cell)
 	}
@@ -178,6 +179,167 @@ func TestPointCell(t *testing.T) {
 	}
 }
 
+func TestCellsWithOptionsDefaults(t *testing.T) {
+	shapes := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{4.5, 22.5}),
+		NewGeoJsonMultiPoint([][]float64{{4.5, 22.5}, {-70, 40}}),
+		NewGeoJsonLinestring([][]float64{{0, 0}, {10, 10}, {20, 10}}),
+		NewGeoJsonPolygon([][][]float64{testSquare(0, 20)}),
+		NewGeoCircle([]float64{10, 10}, "100km"),
+		NewGeoEnvelope([][]float64{{-10, 25}, {15, 5}}),
+		&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{30, 30}),
+			NewGeoJsonPolygon([][][]float64{testSquare(0, 20)}),
+		}},
+	}
+
+	opts := DefaultCovererOptions()
+	for i, shape := range shapes {
+		inner, cross, err := IndexCellsWithOptions(shape, opts)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		wantInner, wantCross := shape.IndexCells()
+		if !sameCells(inner, wantInner) || !sameCells(cross, wantCross) {
+			t.Fatalf("case %d: index cells with the default options differ "+
+				"from IndexCells", i)
+		}
+
+		inner, cross, err = QueryCellsWithOptions(shape, opts)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		wantInner, wantCross = shape.QueryCells()
+		if !sameCells(inner, wantInner) || !sameCells(cross, wantCross) {
+			t.Fatalf("case %d: query cells with the default options differ "+
+				"from QueryCells", i)
+		}
+	}
+}
+
+func TestCellsWithOptionsPointLevel(t *testing.T) {
+	tests := []struct {
+		opts  CovererOptions
+		level int
+	}{
+		{opts: CovererOptions{MaxLevel: 20, LevelMod: 1,
+			MaxIndexCells: 8, MaxQueryCells: 8}, level: 20},
+		{opts: CovererOptions{MaxLevel: 10, LevelMod: 1,
+			MaxIndexCells: 8, MaxQueryCells: 8}, level: 10},
+		// the point level must be reachable from the min level in steps
+		// of level mod, as the levels of the other coverings are
+		{opts: CovererOptions{MinLevel: 1, MaxLevel: 20, LevelMod: 2,
+			MaxIndexCells: 8, MaxQueryCells: 8}, level: 19},
+	}
+
+	shapes := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{4.5, 22.5}),
+		NewGeoJsonMultiPoint([][]float64{{4.5, 22.5}, {-70, 40}}),
+		&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{4.5, 22.5}),
+		}},
+	}
+
+	for i, test := range tests {
+		for j, shape := range shapes {
+			for _, cellsWithOptions := range []func(index.GeoJSON,
+				CovererOptions) ([]uint64, []uint64, error){
+				IndexCellsWithOptions, QueryCellsWithOptions} {
+				inner, cross, err := cellsWithOptions(shape, test.opts)
+				if err != nil {
+					t.Fatalf("case %d, shape %d: unexpected error: %v", i, j, err)
+				}
+				if len(inner) != 0 || len(cross) == 0 {
+					t.Fatalf("case %d, shape %d: expected only cross cells, "+
+						"got %v, %v", i, j, inner, cross)
+				}
+				for _, cell := range cross {
+					if level := s2.CellID(cell).Level(); level != test.level {
+						t.Fatalf("case %d, shape %d: expected level %d cells, "+
+							"got level %d", i, j, test.level, level)
+					}
+				}
+				if !cellsCoverLatLng(cross, 22.5, 4.5) {
+					t.Fatalf("case %d, shape %d: cells do not cover the point", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestCellsWithOptionsMaxCells(t *testing.T) {
+	pgn := NewGeoJsonPolygon([][][]float64{testSquare(0, 60)})
+	opts := DefaultCovererOptions()
+	opts.MaxIndexCells = 10
+	opts.MaxQueryCells = 20
+
+	inner, cross, err := IndexCellsWithOptions(pgn, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(inner) + len(cross); n == 0 || n > opts.MaxIndexCells {
+		t.Fatalf("expected 1 to %d index cells, got %d", opts.MaxIndexCells, n)
+	}
+	verifyCellPartition(t, pgn.(*Polygon).s2pgn, inner, cross)
+
+	inner, cross, err = QueryCellsWithOptions(pgn, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(inner) + len(cross); n == 0 || n > opts.MaxQueryCells {
+		t.Fatalf("expected 1 to %d query cells, got %d", opts.MaxQueryCells, n)
+	}
+	verifyCellPartition(t, pgn.(*Polygon).s2pgn, inner, cross)
+}
+
+func TestCovererOptionsValidate(t *testing.T) {
+	if err := DefaultCovererOptions().Validate(); err != nil {
+		t.Fatalf("unexpected error for the default options: %v", err)
+	}
+
+	invalid := []func(*CovererOptions){
+		func(o *CovererOptions) { o.MinLevel = -1 },
+		func(o *CovererOptions) { o.MaxLevel = s2.MaxLevel + 1 },
+		func(o *CovererOptions) { o.MinLevel, o.MaxLevel = 10, 5 },
+		func(o *CovererOptions) { o.LevelMod = 0 },
+		func(o *CovererOptions) { o.LevelMod = 4 },
+		func(o *CovererOptions) { o.MaxIndexCells = 0 },
+		func(o *CovererOptions) { o.MaxQueryCells = -1 },
+	}
+	pt := NewGeoJsonPoint([]float64{4.5, 22.5})
+	for i, modify := range invalid {
+		opts := DefaultCovererOptions()
+		modify(&opts)
+		if err := opts.Validate(); err == nil {
+			t.Fatalf("case %d: expected an error for options %+v", i, opts)
+		}
+		if _, _, err := IndexCellsWithOptions(pt, opts); err == nil {
+			t.Fatalf("case %d: expected an error from IndexCellsWithOptions", i)
+		}
+		if _, _, err := QueryCellsWithOptions(pt, opts); err == nil {
+			t.Fatalf("case %d: expected an error from QueryCellsWithOptions", i)
+		}
+	}
+}
+
+// sameCells checks whether two cell lists hold the same cells, in any
+// order.
+func sameCells(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[uint64]struct{}, len(a))
+	for _, cell := range a {
+		set[cell] = struct{}{}
+	}
+	for _, cell := range b {
+		if _, ok := set[cell]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func TestEnvelopeFromRect(t *testing.T) {
 	minLng, minLat, maxLng, maxLat := -10.0, 5.0, 15.0, 25.0
 	rect := s2RectFromBounds([]float64{minLng, maxLat}, []float64{maxLng, minLat})
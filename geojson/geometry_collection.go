@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	index "github.com/blevesearch/bleve_index_api"
@@ -41,9 +42,57 @@ func (gc *GeometryCollection) Value() ([]byte, error) {
 	return jsoniter.Marshal(gc)
 }
 
+// MarshalJSON returns the geometrycollection with the coordinates its
+// members were built from, or its RFC 7946 representation when it was
+// decoded and its members only have their s2 representation.
+func (gc *GeometryCollection) MarshalJSON() ([]byte, error) {
+	if len(gc.Shapes) == 0 || hasCoordinates(gc) {
+		type geometryCollection GeometryCollection
+		return jsoniter.Marshal((*geometryCollection)(gc))
+	}
+	return ToGeoJSON(gc, GeoJSONOptions{})
+}
+
+// hasCoordinates reports whether the shape, or any member of it, has
+// the coordinates it was built from, which the shapes decoded by
+// ExtractShapesFromBytes don't have.
+func hasCoordinates(shape index.GeoJSON) bool {
+	switch s := shape.(type) {
+	case *GeometryCollection:
+		for _, member := range s.Shapes {
+			if hasCoordinates(member) {
+				return true
+			}
+		}
+		return false
+	case *Point:
+		return s.Vertices != nil
+	case *MultiPoint:
+		return s.Vertices != nil
+	case *LineString:
+		return s.Vertices != nil
+	case *MultiLineString:
+		return s.Vertices != nil
+	case *Polygon:
+		return s.Vertices != nil
+	case *MultiPolygon:
+		return s.Vertices != nil
+	case *Circle:
+		return s.Vertices != nil
+	case *Envelope:
+		return s.Vertices != nil
+	}
+	return false
+}
+
+// Members returns the member shapes of the geometrycollection, with
+// composite members, nested geometrycollections included, flattened.
 func (gc *GeometryCollection) Members() []index.GeoJSON {
 	shapes := make([]index.GeoJSON, 0, len(gc.Shapes))
 	for _, shape := range gc.Shapes {
+		if shape == nil {
+			continue
+		}
 		if cs, ok := shape.(compositeShape); ok {
 			shapes = append(shapes, cs.Members()...)
 		} else {
@@ -53,33 +102,43 @@ func (gc *GeometryCollection) Members() []index.GeoJSON {
 	return shapes
 }
 
+// Marshal encodes the geometrycollection as the number of its members
+// and their lengths followed by the members' own encodings. Nested
+// geometrycollections are members encoded the same way.
 func (gc *GeometryCollection) Marshal() ([]byte, error) {
+	members := make([][]byte, 0, len(gc.Shapes))
+	for _, shape := range gc.Shapes {
+		// members which can't be encoded are left out of the count,
+		// so that the lengths stay in step with the contents
+		if s, ok := shape.(s2Serializable); ok {
+			sb, err := s.Marshal()
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, sb)
+		}
+	}
+
 	var b bytes.Buffer
 	b.Grow(512)
 	w := bufio.NewWriter(&b)
 
 	// first write the number of shapes.
-	count := int32(len(gc.Shapes))
+	count := int32(len(members))
 	err := binary.Write(w, binary.BigEndian, count)
 	if err != nil {
 		return nil, err
 	}
 
 	var res []byte
-	for _, shape := range gc.Shapes {
-		if s, ok := shape.(s2Serializable); ok {
-			sb, err := s.Marshal()
-			if err != nil {
-				return nil, err
-			}
-			// write the length of each shape.
-			err = binary.Write(w, binary.BigEndian, int32(len(sb)))
-			if err != nil {
-				return nil, err
-			}
-			// track the shape contents.
-			res = append(res, sb...)
+	for _, sb := range members {
+		// write the length of each shape.
+		err = binary.Write(w, binary.BigEndian, int32(len(sb)))
+		if err != nil {
+			return nil, err
 		}
+		// track the shape contents.
+		res = append(res, sb...)
 	}
 	w.Flush()
 
@@ -127,23 +186,93 @@ func (gc *GeometryCollection) Contains(other index.GeoJSON) (bool, error) {
 	return false, nil
 }
 
-// aggregateCells merges the member shapes' inner/cross cell coverings into a
-// single deduplicated pair. A cell reported as inner by any member is fully
-// contained in the union of the members, so it stays inner even when another
-// member reports the same cell as a cross cell. The results are in no
-// particular order.
-func (gc *GeometryCollection) aggregateCells(
-	cells func(index.GeoJSON) (inner, cross []uint64)) (inner, cross []uint64) {
+// cells partitions the covering of the geometrycollection computed with
+// the coverer. Its lines and polygons are covered together as the region
+// of a ShapeIndex, along with the caps of its circles and the rectangles
+// of its envelopes, so that the cell budget of the coverer applies to the
+// collection as a whole rather than to each member. Its points keep their
+// point cells, and the other members the cells returned by memberCells.
+func (gc *GeometryCollection) cells(coverer *s2.RegionCoverer,
+	memberCells func(index.GeoJSON) (inner, cross []uint64)) (inner, cross []uint64) {
+	idx := s2.NewShapeIndex()
+	var region s2.RegionUnion
+	var inners, crosses [][]uint64
+	var add func(shape index.GeoJSON)
+	add = func(shape index.GeoJSON) {
+		switch s := shape.(type) {
+		case nil:
+		case *LineString:
+			s.init()
+			if s.pl != nil && len(*s.pl) > 0 {
+				idx.Add(s.pl)
+			}
+		case *MultiLineString:
+			s.init()
+			for _, pl := range s.pls {
+				if pl != nil && len(*pl) > 0 {
+					idx.Add(pl)
+				}
+			}
+		case *Polygon:
+			s.init()
+			if s.s2pgn != nil && !s.s2pgn.IsEmpty() {
+				idx.Add(s.s2pgn)
+			}
+		case *MultiPolygon:
+			s.init()
+			for _, pgn := range s.s2pgns {
+				if pgn != nil && !pgn.IsEmpty() {
+					idx.Add(pgn)
+				}
+			}
+		case *Circle:
+			s.init()
+			if s.s2cap != nil {
+				region = append(region, *s.s2cap)
+			}
+		case *Envelope:
+			s.init()
+			if s.r != nil {
+				region = append(region, *s.r)
+			}
+		case *GeometryCollection:
+			for _, member := range s.Shapes {
+				add(member)
+			}
+		default:
+			in, cr := memberCells(shape)
+			inners = append(inners, in)
+			crosses = append(crosses, cr)
+		}
+	}
+	for _, shape := range gc.Shapes {
+		add(shape)
+	}
+
+	if idx.Len() > 0 {
+		region = append(region, idx.Region())
+	}
+	if len(region) > 0 {
+		in, cr := cellsFromRegion(region, coverer)
+		inners = append(inners, in)
+		crosses = append(crosses, cr)
+	}
+	return mergeCells(inners, crosses)
+}
+
+// mergeCells merges inner/cross cell coverings into a single deduplicated
+// pair. A cell reported as inner by any covering is fully contained in the
+// union of the shapes, so it stays inner even when another covering reports
+// the same cell as a cross cell. The results are in no particular order.
+func mergeCells(inners, crosses [][]uint64) (inner, cross []uint64) {
 	innerSet := make(map[uint64]struct{})
 	crossSet := make(map[uint64]struct{})
-	for _, s := range gc.Shapes {
-		if s == nil {
-			continue
-		}
-		in, cr := cells(s)
+	for _, in := range inners {
 		for _, cell := range in {
 			innerSet[cell] = struct{}{}
 		}
+	}
+	for _, cr := range crosses {
 		for _, cell := range cr {
 			crossSet[cell] = struct{}{}
 		}
@@ -155,7 +284,7 @@ func (gc *GeometryCollection) aggregateCells(
 	}
 	cross = make([]uint64, 0, len(crossSet))
 	for cell := range crossSet {
-		// inner wins: the cell is fully contained in some member,
+		// inner wins: the cell is fully contained in some shape,
 		// hence in the collection as a whole
 		if _, ok := innerSet[cell]; ok {
 			continue
@@ -165,20 +294,15 @@ func (gc *GeometryCollection) aggregateCells(
 	return inner, cross
 }
 
-// IndexCells returns the union of the member shapes' coverings, deduplicated
-// and reconciled (see aggregateCells).
+// IndexCells returns the covering of the geometrycollection (see cells).
 func (gc *GeometryCollection) IndexCells() (inner, cross []uint64) {
-	return gc.aggregateCells(func(s index.GeoJSON) ([]uint64, []uint64) {
-		return s.IndexCells()
-	})
+	return gc.cells(regionCovererIndexV2, index.GeoJSON.IndexCells)
 }
 
-// QueryCells returns the union of the member shapes' query-time coverings,
-// deduplicated and reconciled (see aggregateCells).
+// QueryCells returns the query-time covering of the geometrycollection,
+// computed the same way as IndexCells.
 func (gc *GeometryCollection) QueryCells() (inner, cross []uint64) {
-	return gc.aggregateCells(func(s index.GeoJSON) ([]uint64, []uint64) {
-		return s.QueryCells()
-	})
+	return gc.cells(regionCovererQueryV2, index.GeoJSON.QueryCells)
 }
 
 func (gc *GeometryCollection) BoundingBox() index.GeoJSON {
@@ -307,8 +431,42 @@ func (gc *GeometryCollection) UnmarshalJSON(data []byte) error {
 			}
 			env.init()
 			gc.Shapes = append(gc.Shapes, &env)
+		case GeometryCollectionType:
+			var nested GeometryCollection
+			err := jsoniter.Unmarshal(shape, &nested)
+			if err != nil {
+				return err
+			}
+			gc.Shapes = append(gc.Shapes, &nested)
 		}
 	}
 
 	return nil
 }
+
+// parseGeometryCollectionWithOptions parses a geometry collection,
+// parsing every member geometry with the given options.
+func parseGeometryCollectionWithOptions(input []byte, opts ParseOptions) (
+	*GeometryCollection, error) {
+	tmp := struct {
+		Typ    string            `json:"type"`
+		Shapes []json.RawMessage `json:"geometries"`
+	}{}
+
+	err := jsoniter.Unmarshal(input, &tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	gc := &GeometryCollection{Typ: tmp.Typ,
+		Shapes: make([]index.GeoJSON, 0, len(tmp.Shapes))}
+	for i, shape := range tmp.Shapes {
+		s, err := ParseGeoJSONShapeWithOptions(shape, opts)
+		if err != nil {
+			return nil, fmt.Errorf("geometry %d: %w", i, err)
+		}
+		gc.Shapes = append(gc.Shapes, s)
+	}
+
+	return gc, nil
+}
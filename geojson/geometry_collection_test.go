@@ -132,6 +132,43 @@ func TestGeometryCollectionCellsRealShapes(t *testing.T) {
 	}
 }
 
+func TestGeometryCollectionCellsBudget(t *testing.T) {
+	// the members are covered as one region, within the cell budget of
+	// the coverer, rather than each within its own budget
+	var shapes []index.GeoJSON
+	for i := 0; i < 10; i++ {
+		lo := float64(i*15 - 75)
+		shapes = append(shapes,
+			NewGeoJsonPolygon([][][]float64{testSquare(lo, lo+10)}),
+			NewGeoJsonLinestring([][]float64{{lo, lo + 12}, {lo + 10, lo + 14}}))
+	}
+	shapes = append(shapes, NewGeoCircle([]float64{100, -20}, "500km"),
+		NewGeoEnvelope([][]float64{{-150, 40}, {-120, 20}}))
+	gc := &GeometryCollection{Typ: GeometryCollectionType, Shapes: shapes}
+
+	inner, cross := gc.IndexCells()
+	if n := len(inner) + len(cross); n > maxIndexCells {
+		t.Fatalf("expected at most %d cells, got %d", maxIndexCells, n)
+	}
+	qInner, qCross := gc.QueryCells()
+	if n := len(qInner) + len(qCross); n > maxQueryCells || n < len(inner)+len(cross) {
+		t.Fatalf("expected up to %d query cells, got %d", maxQueryCells, n)
+	}
+
+	all := append(append([]uint64{}, inner...), cross...)
+	for _, ll := range [][2]float64{{-70, -70}, {-63, -75}, {-20, 100}, {30, -135}} {
+		if !cellsCoverLatLng(all, ll[0], ll[1]) {
+			t.Fatalf("covering does not cover %v", ll)
+		}
+	}
+	if len(inner) == 0 {
+		t.Fatal("expected inner cells from the polygons")
+	}
+	if cellsCoverLatLng(inner, -63, -75) {
+		t.Fatal("expected no inner cells on a line")
+	}
+}
+
 func TestGeometryCollectionNestedCells(t *testing.T) {
 	innerGC := &GeometryCollection{
 		Typ:    GeometryCollectionType,
@@ -236,3 +273,177 @@ func TestGeometryCollectionMarshalRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+// nestedCollectionJSON holds a polygon and a collection of a point and
+// another collection of a linestring.
+var nestedCollectionJSON = []byte(`{"type":"GeometryCollection","geometries":[
+	{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]]]},
+	{"type":"GeometryCollection","geometries":[
+		{"type":"Point","coordinates":[50,50]},
+		{"type":"GeometryCollection","geometries":[
+			{"type":"LineString","coordinates":[[-40,-40],[-30,-30]]}
+		]}
+	]}
+]}`)
+
+func TestGeometryCollectionNestedUnmarshal(t *testing.T) {
+	shape, err := ParseGeoJSONShape(nestedCollectionJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc := shape.(*GeometryCollection)
+	if len(gc.Shapes) != 2 {
+		t.Fatalf("expected 2 member shapes, got %d", len(gc.Shapes))
+	}
+	nested, ok := gc.Shapes[1].(*GeometryCollection)
+	if !ok || len(nested.Shapes) != 2 {
+		t.Fatalf("expected a nested collection of 2 shapes, got %v", gc.Shapes[1])
+	}
+	if _, ok := nested.Shapes[1].(*GeometryCollection); !ok {
+		t.Fatalf("expected a doubly nested collection, got %T", nested.Shapes[1])
+	}
+
+	members := gc.Members()
+	for i, want := range []interface{}{&Polygon{}, &Point{}, &LineString{}} {
+		if reflect.TypeOf(members[i]) != reflect.TypeOf(want) {
+			t.Fatalf("expected member %d to be %T, got %T", i, want, members[i])
+		}
+	}
+
+	// the strict parser agrees
+	strict, err := ParseGeoJSONShapeWithOptions(nestedCollectionJSON,
+		ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strict.(*GeometryCollection).Members()); n != len(members) {
+		t.Fatalf("expected %d members from the strict parser, got %d",
+			len(members), n)
+	}
+}
+
+func TestGeometryCollectionNestedMarshalRoundTrip(t *testing.T) {
+	shape, err := ParseGeoJSONShape(nestedCollectionJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nil members are left out of the encoding
+	gc := shape.(*GeometryCollection)
+	gc.Shapes = append(gc.Shapes, nil)
+
+	data, err := gc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reader *bytes.Reader
+	decoded, err := ExtractShapesFromBytes(data, &reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := decoded.(*GeometryCollection)
+	if len(got.Shapes) != 2 {
+		t.Fatalf("expected 2 member shapes, got %d", len(got.Shapes))
+	}
+	nested, ok := got.Shapes[1].(*GeometryCollection)
+	if !ok || len(nested.Shapes) != 2 {
+		t.Fatalf("expected a nested collection of 2 shapes, got %v", got.Shapes[1])
+	}
+	if _, ok := nested.Shapes[1].(*GeometryCollection); !ok {
+		t.Fatalf("expected a doubly nested collection, got %T", nested.Shapes[1])
+	}
+	if len(got.Members()) != 3 {
+		t.Fatalf("expected 3 members, got %d", len(got.Members()))
+	}
+
+	// the geojson output keeps the nesting
+	out, err := ToGeoJSON(got, GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseGeoJSONShape(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := again.(*GeometryCollection).Shapes[1].(*GeometryCollection); !ok {
+		t.Fatalf("expected the output to keep the nested collection: %s", out)
+	}
+}
+
+func TestGeometryCollectionNestedRelations(t *testing.T) {
+	shape, err := ParseGeoJSONShape(nestedCollectionJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := shape.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reader *bytes.Reader
+	decoded, err := ExtractShapesFromBytes(data, &reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, gc := range []index.GeoJSON{shape, decoded} {
+		// the linestring two levels down intersects
+		line := NewGeoJsonLinestring([][]float64{{-40, -30}, {-30, -40}})
+		if ok, err := gc.Intersects(line); err != nil || !ok {
+			t.Fatalf("case %d: expected an intersection with the nested "+
+				"linestring, got %v %v", i, ok, err)
+		}
+		if ok, err := line.Intersects(gc); err != nil || !ok {
+			t.Fatalf("case %d: expected the linestring to intersect the "+
+				"collection, got %v %v", i, ok, err)
+		}
+		// the nested point is contained, a point elsewhere is not
+		if ok, err := gc.Contains(NewGeoJsonPoint([]float64{50, 50})); err != nil || !ok {
+			t.Fatalf("case %d: expected the nested point to be contained, "+
+				"got %v %v", i, ok, err)
+		}
+		other := &GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{
+				&GeometryCollection{Typ: GeometryCollectionType,
+					Shapes: []index.GeoJSON{
+						NewGeoJsonPoint([]float64{5, 5}),
+						NewGeoJsonPoint([]float64{50, 50}),
+					}},
+			}}
+		if ok, err := gc.Contains(other); err != nil || !ok {
+			t.Fatalf("case %d: expected the nested points to be contained, "+
+				"got %v %v", i, ok, err)
+		}
+		other.Shapes = append(other.Shapes, NewGeoJsonPoint([]float64{60, 60}))
+		if ok, err := gc.Contains(other); err != nil || ok {
+			t.Fatalf("case %d: expected a point outside not to be contained, "+
+				"got %v %v", i, ok, err)
+		}
+
+		// the cells and bounding box cover the nested members
+		inner, cross := gc.IndexCells()
+		cells := append(inner, cross...)
+		for _, ll := range [][2]float64{{5, 5}, {50, 50}, {-40, -40}} {
+			if !cellsCoverLatLng(cells, ll[0], ll[1]) {
+				t.Fatalf("case %d: cells do not cover (%v, %v)", i, ll[0], ll[1])
+			}
+		}
+		env := gc.BoundingBox().(*Envelope)
+		for _, ll := range [][2]float64{{0, 0}, {50, 50}, {-40, -40}} {
+			if !rectContainsDegrees(*env.r, ll[0], ll[1]) {
+				t.Fatalf("case %d: bounding box does not contain (%v, %v)",
+					i, ll[0], ll[1])
+			}
+		}
+	}
+
+	// documents holding nested collections are filtered
+	query := NewGeoJsonPoint([]float64{50, 50})
+	for relation, want := range map[string]bool{
+		"intersects": true, "contains": true, "disjoint": false} {
+		var reader *bytes.Reader
+		got, err := FilterGeoShapesOnRelation(query, data, relation, &reader, nil)
+		if err != nil || got != want {
+			t.Fatalf("relation %q: expected %v, got %v %v", relation, want, got, err)
+		}
+	}
+}
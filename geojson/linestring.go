@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"strings"
+	"sync"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -31,6 +32,13 @@ type LineString struct {
 	Typ      string      `json:"type"`
 	Vertices [][]float64 `json:"coordinates"`
 	pl       *s2.Polyline
+
+	// simplified holds the positions encoded in place of Vertices,
+	// see Polygon.
+	simplified [][][][]float64
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 // NewGeoJsonLinestring instantiates a LineString from the given coordinates.
@@ -41,13 +49,11 @@ func NewGeoJsonLinestring(points [][]float64) index.GeoJSON {
 }
 
 func (ls *LineString) init() {
-	if ls.pl == nil {
-		latlngs := make([]s2.LatLng, len(ls.Vertices))
-		for i, vertex := range ls.Vertices {
-			latlngs[i] = s2.LatLngFromDegrees(vertex[1], vertex[0])
+	ls.once.Do(func() {
+		if ls.pl == nil {
+			ls.pl = s2PolylineFromPositions(ls.Vertices)
 		}
-		ls.pl = s2.PolylineFromLatLngs(latlngs)
-	}
+	})
 }
 
 func (ls *LineString) Type() string {
@@ -58,6 +64,17 @@ func (ls *LineString) Value() ([]byte, error) {
 	return jsoniter.Marshal(ls)
 }
 
+// MarshalJSON returns the linestring with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (ls *LineString) MarshalJSON() ([]byte, error) {
+	if ls.Vertices != nil {
+		type lineString LineString
+		return jsoniter.Marshal((*lineString)(ls))
+	}
+	return ToGeoJSON(ls, GeoJSONOptions{})
+}
+
 func (ls *LineString) Marshal() ([]byte, error) {
 	ls.init()
 
@@ -70,17 +87,20 @@ func (ls *LineString) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{LineStringTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{LineStringTypePrefix}, b.Bytes()...),
+		encodedPositions(ls, ls.simplified)), nil
 }
 
 func (ls *LineString) Intersects(other index.GeoJSON) (bool, error) {
 	ls.init()
+	initShape(other)
 
 	return checkLineStringsIntersectsShape([]*s2.Polyline{ls.pl}, ls, other)
 }
 
 func (ls *LineString) Contains(other index.GeoJSON) (bool, error) {
 	ls.init()
+	initShape(other)
 	return checkLineStringsContainsShape([]*s2.Polyline{ls.pl}, other)
 }
 
@@ -91,21 +111,22 @@ func (ls *LineString) Coordinates() [][]float64 {
 // IndexCells returns the linestring's covering: a polyline has no area, so
 // every covering cell is a cross cell and inner is always nil.
 func (ls *LineString) IndexCells() (inner, cross []uint64) {
-	ls.init()
-	if ls.pl == nil {
-		return nil, nil
-	}
-	return indexCellsFromRegion(ls.pl)
+	return ls.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the linestring's query-time covering; like IndexCells,
 // it can only contain cross cells.
 func (ls *LineString) QueryCells() (inner, cross []uint64) {
+	return ls.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the linestring computed with the coverer.
+func (ls *LineString) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	ls.init()
 	if ls.pl == nil {
 		return nil, nil
 	}
-	return queryCellsFromRegion(ls.pl)
+	return cellsFromRegion(ls.pl, coverer)
 }
 
 func (ls *LineString) BoundingBox() index.GeoJSON {
@@ -134,6 +155,13 @@ type MultiLineString struct {
 	Typ      string        `json:"type"`
 	Vertices [][][]float64 `json:"coordinates"`
 	pls      []*s2.Polyline
+
+	// simplified holds the positions encoded in place of Vertices,
+	// see Polygon.
+	simplified [][][][]float64
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 // NewGeoJsonMultilinestring instantiates a MultiLineString from the given
@@ -145,9 +173,11 @@ func NewGeoJsonMultilinestring(points [][][]float64) index.GeoJSON {
 }
 
 func (mls *MultiLineString) init() {
-	if mls.pls == nil {
-		mls.pls = s2PolylinesFromCoordinates(mls.Vertices)
-	}
+	mls.once.Do(func() {
+		if mls.pls == nil {
+			mls.pls = s2PolylinesFromCoordinates(mls.Vertices)
+		}
+	})
 }
 
 func (mls *MultiLineString) Type() string {
@@ -158,6 +188,17 @@ func (mls *MultiLineString) Value() ([]byte, error) {
 	return jsoniter.Marshal(mls)
 }
 
+// MarshalJSON returns the multilinestring with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (mls *MultiLineString) MarshalJSON() ([]byte, error) {
+	if mls.Vertices != nil {
+		type multiLineString MultiLineString
+		return jsoniter.Marshal((*multiLineString)(mls))
+	}
+	return ToGeoJSON(mls, GeoJSONOptions{})
+}
+
 func (mls *MultiLineString) Marshal() ([]byte, error) {
 	mls.init()
 
@@ -180,16 +221,19 @@ func (mls *MultiLineString) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{MultiLineStringTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{MultiLineStringTypePrefix}, b.Bytes()...),
+		encodedPositions(mls, mls.simplified)), nil
 }
 
 func (mls *MultiLineString) Intersects(other index.GeoJSON) (bool, error) {
 	mls.init()
+	initShape(other)
 	return checkLineStringsIntersectsShape(mls.pls, mls, other)
 }
 
 func (mls *MultiLineString) Contains(other index.GeoJSON) (bool, error) {
 	mls.init()
+	initShape(other)
 	return checkLineStringsContainsShape(mls.pls, other)
 }
 
@@ -228,23 +272,23 @@ func polylinesRegionUnion(pls []*s2.Polyline) s2.RegionUnion {
 // covering of the union of its polylines. Polylines have no area, so every
 // covering cell is a cross cell and inner is always nil.
 func (mls *MultiLineString) IndexCells() (inner, cross []uint64) {
-	mls.init()
-	ru := polylinesRegionUnion(mls.pls)
-	if len(ru) == 0 {
-		return nil, nil
-	}
-	return indexCellsFromRegion(ru)
+	return mls.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the multilinestring's query-time covering; like
 // IndexCells, it can only contain cross cells.
 func (mls *MultiLineString) QueryCells() (inner, cross []uint64) {
+	return mls.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the multilinestring computed with the coverer.
+func (mls *MultiLineString) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	mls.init()
 	ru := polylinesRegionUnion(mls.pls)
 	if len(ru) == 0 {
 		return nil, nil
 	}
-	return queryCellsFromRegion(ru)
+	return cellsFromRegion(ru, coverer)
 }
 
 func (mls *MultiLineString) BoundingBox() index.GeoJSON {
@@ -287,6 +331,9 @@ func checkLineStringsIntersectsShape(pls []*s2.Polyline, shapeIn,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if polylineIntersectsPoint(pls, p2.s2point) {
 			return true, nil
 		}
@@ -393,6 +440,9 @@ func checkLineStringsContainsShape(pls []*s2.Polyline,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if polylineIntersectsPoint(pls, p2.s2point) {
 			return true, nil
 		}
@@ -588,3 +588,32 @@ func TestEmptyMultiLineStringCells(t *testing.T) {
 		t.Fatalf("expected an empty rect for an empty multilinestring, got %v", env.r)
 	}
 }
+
+func TestLineStringAntimeridian(t *testing.T) {
+	tests := []struct {
+		vertices [][]float64
+		in       [][]float64
+		out      [][]float64
+	}{
+		// the short way across the antimeridian
+		{[][]float64{{170, 0}, {-170, 0}}, [][]float64{{180, 0}}, [][]float64{{0, 0}}},
+		// longitudes beyond 180 are followed literally
+		{[][]float64{{100, 0}, {300, 0}}, [][]float64{{180, 0}, {-90, 0}}, [][]float64{{0, 0}}},
+		// in [-180, 180], edges over 180 degrees wide cross the antimeridian
+		{[][]float64{{-100, 0}, {100, 0}}, [][]float64{{180, 0}}, [][]float64{{0, 0}}},
+	}
+
+	for i, test := range tests {
+		ls := NewGeoJsonLinestring(test.vertices)
+		for _, p := range test.in {
+			if ok, _ := ls.Intersects(NewGeoJsonPoint(p)); !ok {
+				t.Fatalf("case %d: expected the linestring to go through %v", i, p)
+			}
+		}
+		for _, p := range test.out {
+			if ok, _ := ls.Intersects(NewGeoJsonPoint(p)); ok {
+				t.Fatalf("case %d: expected the linestring not to go through %v", i, p)
+			}
+		}
+	}
+}
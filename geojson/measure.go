@@ -0,0 +1,205 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/r3"
+	"github.com/blevesearch/geo/s2"
+)
+
+// measures accumulates the measurements of the components of a shape.
+// Lengths and areas are on the unit sphere, and the centroids of each
+// dimension are scaled by the size of their components, as returned by
+// the Centroid methods of s2, so that they can be summed.
+type measures struct {
+	area      float64
+	length    float64
+	perimeter float64
+
+	// centroids of the points, lines and surfaces, and the number of
+	// points, length and area they are weighted by
+	centroids [3]r3.Vector
+	weights   [3]float64
+}
+
+// Area returns the area of the shape in square meters. Points and
+// linestrings have no area, and the areas of the members of a
+// geometrycollection are summed, even where they overlap.
+func Area(shape index.GeoJSON) (float64, error) {
+	m, err := measure(shape)
+	if err != nil {
+		return 0, err
+	}
+	return m.area * earthRadiusInMeter * earthRadiusInMeter, nil
+}
+
+// Length returns the length of the linestrings of the shape in meters.
+// The boundaries of polygons, circles and envelopes are measured by
+// Perimeter instead.
+func Length(shape index.GeoJSON) (float64, error) {
+	m, err := measure(shape)
+	if err != nil {
+		return 0, err
+	}
+	return m.length * earthRadiusInMeter, nil
+}
+
+// Perimeter returns the length of the boundaries of the polygons,
+// circles and envelopes of the shape in meters, holes included. The
+// sides of an envelope follow its meridians and parallels.
+func Perimeter(shape index.GeoJSON) (float64, error) {
+	m, err := measure(shape)
+	if err != nil {
+		return 0, err
+	}
+	return m.perimeter * earthRadiusInMeter, nil
+}
+
+// Centroid returns a representative [lon, lat] position of the shape:
+// the centroid of its components of the highest dimension, weighted by
+// their area, length or count. It may lie outside of the shape, like
+// the centroid of a ring. It is nil for an empty shape, or when the
+// centroid is undefined, as for two antipodal points.
+func Centroid(shape index.GeoJSON) ([]float64, error) {
+	m, err := measure(shape)
+	if err != nil {
+		return nil, err
+	}
+	for dim := 2; dim >= 0; dim-- {
+		// a centroid much shorter than its weight is only left by
+		// components cancelling each other out
+		c := m.centroids[dim]
+		if m.weights[dim] > 0 && c.Norm() > 1e-12*m.weights[dim] {
+			return positionFromS2Point(s2.Point{Vector: c.Normalize()}), nil
+		}
+	}
+	return nil, nil
+}
+
+// measure returns the measurements of the shape.
+func measure(shape index.GeoJSON) (*measures, error) {
+	m := &measures{}
+	if err := m.add(shape); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *measures) add(shape index.GeoJSON) error {
+	switch s := shape.(type) {
+	case *Point:
+		s.init()
+		if s.s2point != nil {
+			m.centroids[0] = m.centroids[0].Add(s.s2point.Vector)
+			m.weights[0]++
+		}
+
+	case *MultiPoint:
+		s.init()
+		for _, p := range s.s2points {
+			m.centroids[0] = m.centroids[0].Add(p.Vector)
+			m.weights[0]++
+		}
+
+	case *LineString:
+		s.init()
+		m.addPolyline(s.pl)
+
+	case *MultiLineString:
+		s.init()
+		for _, pl := range s.pls {
+			m.addPolyline(pl)
+		}
+
+	case *Polygon:
+		s.init()
+		m.addPolygon(s.s2pgn)
+
+	case *MultiPolygon:
+		s.init()
+		for _, pgn := range s.s2pgns {
+			m.addPolygon(pgn)
+		}
+
+	case *Circle:
+		s.init()
+		if s.s2cap.IsEmpty() {
+			break
+		}
+		m.area += s.s2cap.Area()
+		// the circumference of a circle of angular radius r on the
+		// unit sphere is 2π sin(r)
+		m.perimeter += 2 * math.Pi * math.Sin(s.s2cap.Radius().Radians())
+		m.centroids[2] = m.centroids[2].Add(s.s2cap.Centroid().Vector)
+		m.weights[2] += s.s2cap.Area()
+
+	case *Envelope:
+		s.init()
+		if s.r.IsEmpty() {
+			break
+		}
+		m.area += s.r.Area()
+		lat, lng := s.r.Lat, s.r.Lng
+		m.perimeter += 2*lat.Length() + lng.Length()*
+			(math.Cos(lat.Lo)+math.Cos(lat.Hi))
+		m.centroids[2] = m.centroids[2].Add(s.r.Centroid().Vector)
+		m.weights[2] += s.r.Area()
+
+	case *GeometryCollection:
+		for _, member := range s.Members() {
+			if err := m.add(member); err != nil {
+				return err
+			}
+		}
+
+	default:
+		if shape == nil {
+			return fmt.Errorf("nil shape")
+		}
+		return fmt.Errorf("unknown geojson type: %s", shape.Type())
+	}
+
+	return nil
+}
+
+func (m *measures) addPolyline(pl *s2.Polyline) {
+	if pl == nil {
+		return
+	}
+	m.length += pl.Length().Radians()
+	m.centroids[1] = m.centroids[1].Add(pl.Centroid().Vector)
+	m.weights[1] += pl.Length().Radians()
+}
+
+func (m *measures) addPolygon(pgn *s2.Polygon) {
+	if pgn == nil || pgn.IsEmpty() {
+		return
+	}
+	m.area += pgn.Area()
+	for _, loop := range pgn.Loops() {
+		if loop.IsFull() {
+			continue
+		}
+		for i := 0; i < loop.NumVertices(); i++ {
+			m.perimeter += loop.Vertex(i).Distance(loop.Vertex(i + 1)).Radians()
+		}
+	}
+	m.centroids[2] = m.centroids[2].Add(pgn.Centroid().Vector)
+	m.weights[2] += pgn.Area()
+}
@@ -0,0 +1,148 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestMeasures(t *testing.T) {
+	// a square of 1 degree on the equator, and the same square with a
+	// hole of half its side
+	square := [][]float64{{0, -0.5}, {1, -0.5}, {1, 0.5}, {0, 0.5}, {0, -0.5}}
+	hole := [][]float64{{0.25, -0.25}, {0.25, 0.25}, {0.75, 0.25}, {0.75, -0.25},
+		{0.25, -0.25}}
+	squareArea := oneDegree * oneDegree
+	circleRadius := 10000.0
+
+	tests := []struct {
+		shape     index.GeoJSON
+		area      float64
+		length    float64
+		perimeter float64
+		centroid  []float64
+	}{
+		{shape: NewGeoJsonPoint([]float64{4.5, 22.5}),
+			centroid: []float64{4.5, 22.5}},
+		{shape: NewGeoJsonMultiPoint([][]float64{{10, 0}, {20, 0}}),
+			centroid: []float64{15, 0}},
+		{shape: NewGeoJsonLinestring([][]float64{{0, 0}, {10, 0}}),
+			length: 10 * oneDegree, centroid: []float64{5, 0}},
+		{shape: NewGeoJsonMultilinestring([][][]float64{
+			{{0, 0}, {10, 0}}, {{20, 0}, {30, 0}}}),
+			length: 20 * oneDegree, centroid: []float64{15, 0}},
+		{shape: NewGeoJsonPolygon([][][]float64{square}),
+			area: squareArea, perimeter: 4 * oneDegree,
+			centroid: []float64{0.5, 0}},
+		{shape: NewGeoJsonPolygon([][][]float64{square, hole}),
+			area: 0.75 * squareArea, perimeter: 6 * oneDegree,
+			centroid: []float64{0.5, 0}},
+		{shape: NewGeoJsonMultiPolygon([][][][]float64{{square},
+			{{{10, -0.5}, {11, -0.5}, {11, 0.5}, {10, 0.5}, {10, -0.5}}}}),
+			area: 2 * squareArea, perimeter: 8 * oneDegree,
+			centroid: []float64{5.5, 0}},
+		{shape: NewGeoCircle([]float64{10, 10}, "10km"),
+			area: math.Pi * circleRadius * circleRadius, perimeter: 2 * math.Pi * circleRadius,
+			centroid: []float64{10, 10}},
+		{shape: NewGeoEnvelope([][]float64{{0, 0.5}, {1, -0.5}}),
+			area: squareArea, perimeter: 4 * oneDegree,
+			centroid: []float64{0.5, 0}},
+		// the centroid of a collection is that of its members of the
+		// highest dimension
+		{shape: &GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{
+				NewGeoJsonPoint([]float64{50, 50}),
+				NewGeoJsonLinestring([][]float64{{20, 0}, {30, 0}}),
+				NewGeoJsonPolygon([][][]float64{square}),
+			}},
+			area: squareArea, length: 10 * oneDegree, perimeter: 4 * oneDegree,
+			centroid: []float64{0.5, 0}},
+	}
+
+	for i, test := range tests {
+		for j, shape := range []index.GeoJSON{test.shape, decodeShape(t, test.shape)} {
+			area, err := Area(shape)
+			if err != nil {
+				t.Fatalf("case %d, %d: unexpected error: %v", i, j, err)
+			}
+			if !approxEqual(area, test.area, 1e-3) {
+				t.Fatalf("case %d, %d: expected area %v, got %v", i, j, test.area, area)
+			}
+			length, err := Length(shape)
+			if err != nil {
+				t.Fatalf("case %d, %d: unexpected error: %v", i, j, err)
+			}
+			if !approxEqual(length, test.length, 1e-3) {
+				t.Fatalf("case %d, %d: expected length %v, got %v",
+					i, j, test.length, length)
+			}
+			perimeter, err := Perimeter(shape)
+			if err != nil {
+				t.Fatalf("case %d, %d: unexpected error: %v", i, j, err)
+			}
+			if !approxEqual(perimeter, test.perimeter, 1e-3) {
+				t.Fatalf("case %d, %d: expected perimeter %v, got %v",
+					i, j, test.perimeter, perimeter)
+			}
+			centroid, err := Centroid(shape)
+			if err != nil {
+				t.Fatalf("case %d, %d: unexpected error: %v", i, j, err)
+			}
+			if len(centroid) != 2 ||
+				math.Abs(centroid[0]-test.centroid[0]) > 1e-6 ||
+				math.Abs(centroid[1]-test.centroid[1]) > 1e-6 {
+				t.Fatalf("case %d, %d: expected centroid %v, got %v",
+					i, j, test.centroid, centroid)
+			}
+		}
+	}
+}
+
+func TestMeasuresEmpty(t *testing.T) {
+	shapes := []index.GeoJSON{
+		&Point{Typ: PointType},
+		NewGeoJsonMultiPoint([][]float64{{0, 0}, {180, 0}}),
+		&GeometryCollection{Typ: GeometryCollectionType},
+	}
+	for i, shape := range shapes {
+		centroid, err := Centroid(shape)
+		if err != nil || centroid != nil {
+			t.Fatalf("case %d: expected no centroid, got %v %v", i, centroid, err)
+		}
+		area, err := Area(shape)
+		if err != nil || area != 0 {
+			t.Fatalf("case %d: expected no area, got %v %v", i, area, err)
+		}
+	}
+
+	if _, err := Area(nil); err == nil {
+		t.Fatal("expected an error for a nil shape")
+	}
+	if _, err := Centroid(&stubCellShape{}); err == nil {
+		t.Fatal("expected an error for an unknown shape")
+	}
+}
+
+// approxEqual checks whether got is within the relative tolerance of
+// want, or both are zero.
+func approxEqual(got, want, tolerance float64) bool {
+	if want == 0 {
+		return got == 0
+	}
+	return math.Abs(got-want) <= tolerance*math.Abs(want)
+}
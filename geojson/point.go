@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"strings"
+	"sync"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -32,6 +33,10 @@ type Point struct {
 	Typ      string    `json:"type"`
 	Vertices []float64 `json:"coordinates"`
 	s2point  *s2.Point
+
+	// once guards the lazy initialization of the s2 fields, so
+	// that the shape can be shared by concurrent readers.
+	once sync.Once
 }
 
 func NewGeoJsonPoint(v []float64) index.GeoJSON {
@@ -48,16 +53,34 @@ func (p *Point) Value() ([]byte, error) {
 	return jsoniter.Marshal(p)
 }
 
-func (p *Point) init() {
-	if p.s2point == nil {
-		s2point := s2.PointFromLatLng(s2.LatLngFromDegrees(
-			p.Vertices[1], p.Vertices[0]))
-		p.s2point = &s2point
+// MarshalJSON returns the point with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (p *Point) MarshalJSON() ([]byte, error) {
+	if p.Vertices != nil {
+		type point Point
+		return jsoniter.Marshal((*point)(p))
 	}
+	return ToGeoJSON(p, GeoJSONOptions{})
+}
+
+// init builds the s2 point, which stays nil for an empty point
+// (e.g. POINT EMPTY) as it has no position.
+func (p *Point) init() {
+	p.once.Do(func() {
+		if p.s2point == nil && len(p.Vertices) >= 2 {
+			s2point := s2.PointFromLatLng(s2.LatLngFromDegrees(
+				p.Vertices[1], p.Vertices[0]))
+			p.s2point = &s2point
+		}
+	})
 }
 
 func (p *Point) Marshal() ([]byte, error) {
 	p.init()
+	if p.s2point == nil {
+		return nil, fmt.Errorf("cannot encode an empty point")
+	}
 
 	var b bytes.Buffer
 	b.Grow(32)
@@ -68,17 +91,26 @@ func (p *Point) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{PointTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{PointTypePrefix}, b.Bytes()...),
+		p.positions()), nil
 }
 
 func (p *Point) Intersects(other index.GeoJSON) (bool, error) {
 	p.init()
+	initShape(other)
+	if p.s2point == nil {
+		return false, nil
+	}
 
 	return checkPointIntersectsShape(p.s2point, p, other)
 }
 
 func (p *Point) Contains(other index.GeoJSON) (bool, error) {
 	p.init()
+	initShape(other)
+	if p.s2point == nil {
+		return false, nil
+	}
 
 	return checkPointContainsShape([]*s2.Point{p.s2point}, other)
 }
@@ -90,11 +122,16 @@ func (p *Point) Coordinates() []float64 {
 // IndexCells returns the point's covering: a point has no area, so it is a
 // single maxCellLevel cross cell and never an inner cell.
 func (p *Point) IndexCells() (inner, cross []uint64) {
+	return p.cells(regionCovererIndexV2)
+}
+
+// cells returns the point's cell at the point cell level of the coverer.
+func (p *Point) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	p.init()
 	if p.s2point == nil {
 		return nil, nil
 	}
-	return nil, []uint64{pointCell(*p.s2point)}
+	return nil, []uint64{pointCell(*p.s2point, coverer)}
 }
 
 // QueryCells delegates to IndexCells: a point maps to exactly one cell, so
@@ -114,12 +151,18 @@ func (p *Point) BoundingBox() index.GeoJSON {
 
 func (p *Point) IndexTokens(s *s2.RegionTermIndexer) []string {
 	p.init()
+	if p.s2point == nil {
+		return nil
+	}
 	terms := s.GetIndexTermsForPoint(*p.s2point, "")
 	return StripCoveringTerms(terms)
 }
 
 func (p *Point) QueryTokens(s *s2.RegionTermIndexer) []string {
 	p.init()
+	if p.s2point == nil {
+		return nil
+	}
 	terms := s.GetQueryTermsForPoint(*p.s2point, "")
 	return StripCoveringTerms(terms)
 }
@@ -132,6 +175,9 @@ type MultiPoint struct {
 	Typ      string      `json:"type"`
 	Vertices [][]float64 `json:"coordinates"`
 	s2points []*s2.Point
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 func NewGeoJsonMultiPoint(v [][]float64) index.GeoJSON {
@@ -141,14 +187,16 @@ func NewGeoJsonMultiPoint(v [][]float64) index.GeoJSON {
 }
 
 func (mp *MultiPoint) init() {
-	if mp.s2points == nil {
-		mp.s2points = make([]*s2.Point, len(mp.Vertices))
-		for i, point := range mp.Vertices {
-			s2point := s2.PointFromLatLng(s2.LatLngFromDegrees(
-				point[1], point[0]))
-			mp.s2points[i] = &s2point
+	mp.once.Do(func() {
+		if mp.s2points == nil {
+			mp.s2points = make([]*s2.Point, len(mp.Vertices))
+			for i, point := range mp.Vertices {
+				s2point := s2.PointFromLatLng(s2.LatLngFromDegrees(
+					point[1], point[0]))
+				mp.s2points[i] = &s2point
+			}
 		}
-	}
+	})
 }
 
 func (mp *MultiPoint) Marshal() ([]byte, error) {
@@ -173,7 +221,8 @@ func (mp *MultiPoint) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{MultiPointTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{MultiPointTypePrefix}, b.Bytes()...),
+		mp.positions()), nil
 }
 
 func (mp *MultiPoint) Type() string {
@@ -184,8 +233,20 @@ func (mp *MultiPoint) Value() ([]byte, error) {
 	return jsoniter.Marshal(mp)
 }
 
+// MarshalJSON returns the multipoint with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (mp *MultiPoint) MarshalJSON() ([]byte, error) {
+	if mp.Vertices != nil {
+		type multiPoint MultiPoint
+		return jsoniter.Marshal((*multiPoint)(mp))
+	}
+	return ToGeoJSON(mp, GeoJSONOptions{})
+}
+
 func (mp *MultiPoint) Intersects(other index.GeoJSON) (bool, error) {
 	mp.init()
+	initShape(other)
 
 	for _, s2point := range mp.s2points {
 		rv, err := checkPointIntersectsShape(s2point, mp, other)
@@ -199,6 +260,7 @@ func (mp *MultiPoint) Intersects(other index.GeoJSON) (bool, error) {
 
 func (mp *MultiPoint) Contains(other index.GeoJSON) (bool, error) {
 	mp.init()
+	initShape(other)
 
 	rv, err := checkPointContainsShape(mp.s2points, other)
 	if rv && err == nil {
@@ -232,6 +294,12 @@ func (mp *MultiPoint) Members() []index.GeoJSON {
 // result is one maxCellLevel cross cell per distinct point cell and never any
 // inner cells. Cells are deduplicated, since points can share a cell.
 func (mp *MultiPoint) IndexCells() (inner, cross []uint64) {
+	return mp.cells(regionCovererIndexV2)
+}
+
+// cells returns the distinct cells of the points at the point cell level
+// of the coverer.
+func (mp *MultiPoint) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	mp.init()
 	seen := make(map[uint64]struct{}, len(mp.s2points))
 	cross = make([]uint64, 0, len(mp.s2points))
@@ -239,7 +307,7 @@ func (mp *MultiPoint) IndexCells() (inner, cross []uint64) {
 		if pt == nil {
 			continue
 		}
-		cell := pointCell(*pt)
+		cell := pointCell(*pt, coverer)
 		if _, ok := seen[cell]; ok {
 			continue
 		}
@@ -294,6 +362,9 @@ func (mp *MultiPoint) QueryTokens(s *s2.RegionTermIndexer) []string {
 func checkPointIntersectsShape(point *s2.Point, shapeIn, other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		// check if the points are equal
 		if point.ApproxEqual(*p2.s2point) {
 			return true, nil
@@ -398,6 +469,9 @@ func checkPointContainsShape(points []*s2.Point,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		for _, point := range points {
 			if point.ApproxEqual(*p2.s2point) {
 				return true, nil
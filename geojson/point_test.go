@@ -394,8 +394,8 @@ func TestPointCells(t *testing.T) {
 	if len(cross) != 1 {
 		t.Fatalf("expected exactly one cross cell for a point, got %v", cross)
 	}
-	if cross[0] != pointCell(*p.s2point) {
-		t.Fatalf("expected cross cell %d, got %d", pointCell(*p.s2point), cross[0])
+	if cross[0] != pointCell(*p.s2point, regionCovererIndexV2) {
+		t.Fatalf("expected cross cell %d, got %d", pointCell(*p.s2point, regionCovererIndexV2), cross[0])
 	}
 	if !cellsCoverLatLng(cross, 22.5, 4.5) {
 		t.Fatal("the point's cell does not cover the point")
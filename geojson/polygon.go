@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"strings"
+	"sync"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -31,6 +32,14 @@ type Polygon struct {
 	Typ      string        `json:"type"`
 	Vertices [][][]float64 `json:"coordinates"`
 	s2pgn    *s2.Polygon
+
+	// simplified holds the positions of the simplified s2
+	// representation with their extra ordinates, which are encoded in
+	// place of the original coordinates kept in Vertices.
+	simplified [][][][]float64
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 func NewGeoJsonPolygon(points [][][]float64) index.GeoJSON {
@@ -40,9 +49,11 @@ func NewGeoJsonPolygon(points [][][]float64) index.GeoJSON {
 }
 
 func (pg *Polygon) init() {
-	if pg.s2pgn == nil {
-		pg.s2pgn = s2PolygonFromCoordinates(pg.Vertices)
-	}
+	pg.once.Do(func() {
+		if pg.s2pgn == nil {
+			pg.s2pgn = s2PolygonFromCoordinates(pg.Vertices)
+		}
+	})
 }
 
 func (pg *Polygon) Type() string {
@@ -53,6 +64,17 @@ func (pg *Polygon) Value() ([]byte, error) {
 	return jsoniter.Marshal(pg)
 }
 
+// MarshalJSON returns the polygon with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (pg *Polygon) MarshalJSON() ([]byte, error) {
+	if pg.Vertices != nil {
+		type polygon Polygon
+		return jsoniter.Marshal((*polygon)(pg))
+	}
+	return ToGeoJSON(pg, GeoJSONOptions{})
+}
+
 func (pg *Polygon) Marshal() ([]byte, error) {
 	pg.init()
 
@@ -65,12 +87,14 @@ func (pg *Polygon) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{PolygonTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{PolygonTypePrefix}, b.Bytes()...),
+		encodedPositions(pg, pg.simplified)), nil
 }
 
 func (pg *Polygon) Intersects(other index.GeoJSON) (bool, error) {
 	// lazily build the s2polygon for reuse.
 	pg.init()
+	initShape(other)
 
 	return checkPolygonIntersectsShape(pg.s2pgn, pg, other)
 }
@@ -78,6 +102,7 @@ func (pg *Polygon) Intersects(other index.GeoJSON) (bool, error) {
 func (pg *Polygon) Contains(other index.GeoJSON) (bool, error) {
 	// lazily build the s2polygon for reuse.
 	pg.init()
+	initShape(other)
 
 	return checkMultiPolygonContainsShape([]*s2.Polygon{pg.s2pgn}, pg, other)
 }
@@ -92,21 +117,22 @@ func (pg *Polygon) Coordinates() [][][]float64 {
 // being built from oriented loops, so ContainsCell is false for cells
 // inside interior rings.
 func (pg *Polygon) IndexCells() (inner, cross []uint64) {
-	pg.init()
-	if pg.s2pgn == nil {
-		return nil, nil
-	}
-	return indexCellsFromRegion(pg.s2pgn)
+	return pg.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the polygon's query-time covering, partitioned the same
 // way as IndexCells.
 func (pg *Polygon) QueryCells() (inner, cross []uint64) {
+	return pg.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the polygon computed with the coverer.
+func (pg *Polygon) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	pg.init()
 	if pg.s2pgn == nil {
 		return nil, nil
 	}
-	return queryCellsFromRegion(pg.s2pgn)
+	return cellsFromRegion(pg.s2pgn, coverer)
 }
 
 func (pg *Polygon) BoundingBox() index.GeoJSON {
@@ -139,6 +165,13 @@ type MultiPolygon struct {
 	Typ      string          `json:"type"`
 	Vertices [][][][]float64 `json:"coordinates"`
 	s2pgns   []*s2.Polygon
+
+	// simplified holds the positions encoded in place of Vertices,
+	// see Polygon.
+	simplified [][][][]float64
+
+	// once guards init, see Point.
+	once sync.Once
 }
 
 func NewGeoJsonMultiPolygon(points [][][][]float64) index.GeoJSON {
@@ -148,13 +181,15 @@ func NewGeoJsonMultiPolygon(points [][][][]float64) index.GeoJSON {
 }
 
 func (mp *MultiPolygon) init() {
-	if mp.s2pgns == nil {
-		mp.s2pgns = make([]*s2.Polygon, len(mp.Vertices))
-		for i, vertices := range mp.Vertices {
-			pgn := s2PolygonFromCoordinates(vertices)
-			mp.s2pgns[i] = pgn
+	mp.once.Do(func() {
+		if mp.s2pgns == nil {
+			mp.s2pgns = make([]*s2.Polygon, len(mp.Vertices))
+			for i, vertices := range mp.Vertices {
+				pgn := s2PolygonFromCoordinates(vertices)
+				mp.s2pgns[i] = pgn
+			}
 		}
-	}
+	})
 }
 
 func (mp *MultiPolygon) Type() string {
@@ -165,6 +200,17 @@ func (mp *MultiPolygon) Value() ([]byte, error) {
 	return jsoniter.Marshal(mp)
 }
 
+// MarshalJSON returns the multipolygon with the coordinates it was built
+// from, or its RFC 7946 representation when it was decoded and only has
+// its s2 representation.
+func (mp *MultiPolygon) MarshalJSON() ([]byte, error) {
+	if mp.Vertices != nil {
+		type multiPolygon MultiPolygon
+		return jsoniter.Marshal((*multiPolygon)(mp))
+	}
+	return ToGeoJSON(mp, GeoJSONOptions{})
+}
+
 func (mp *MultiPolygon) Marshal() ([]byte, error) {
 	mp.init()
 
@@ -187,11 +233,13 @@ func (mp *MultiPolygon) Marshal() ([]byte, error) {
 	}
 
 	w.Flush()
-	return append([]byte{MultiPolygonTypePrefix}, b.Bytes()...), nil
+	return appendPositions(append([]byte{MultiPolygonTypePrefix}, b.Bytes()...),
+		encodedPositions(mp, mp.simplified)), nil
 }
 
 func (mp *MultiPolygon) Intersects(other index.GeoJSON) (bool, error) {
 	mp.init()
+	initShape(other)
 
 	for _, pgn := range mp.s2pgns {
 		rv, err := checkPolygonIntersectsShape(pgn, mp, other)
@@ -205,6 +253,7 @@ func (mp *MultiPolygon) Intersects(other index.GeoJSON) (bool, error) {
 
 func (mp *MultiPolygon) Contains(other index.GeoJSON) (bool, error) {
 	mp.init()
+	initShape(other)
 
 	return checkMultiPolygonContainsShape(mp.s2pgns, mp, other)
 }
@@ -244,23 +293,23 @@ func polygonsRegionUnion(pgns []*s2.Polygon) s2.RegionUnion {
 // covering of the union of its polygons and partitioned into inner and
 // cross cells.
 func (mp *MultiPolygon) IndexCells() (inner, cross []uint64) {
-	mp.init()
-	ru := polygonsRegionUnion(mp.s2pgns)
-	if len(ru) == 0 {
-		return nil, nil
-	}
-	return indexCellsFromRegion(ru)
+	return mp.cells(regionCovererIndexV2)
 }
 
 // QueryCells returns the multipolygon's query-time covering, partitioned the
 // same way as IndexCells.
 func (mp *MultiPolygon) QueryCells() (inner, cross []uint64) {
+	return mp.cells(regionCovererQueryV2)
+}
+
+// cells partitions the covering of the multipolygon computed with the coverer.
+func (mp *MultiPolygon) cells(coverer *s2.RegionCoverer) (inner, cross []uint64) {
 	mp.init()
 	ru := polygonsRegionUnion(mp.s2pgns)
 	if len(ru) == 0 {
 		return nil, nil
 	}
-	return queryCellsFromRegion(ru)
+	return cellsFromRegion(ru, coverer)
 }
 
 func (mp *MultiPolygon) BoundingBox() index.GeoJSON {
@@ -304,6 +353,9 @@ func checkPolygonIntersectsShape(s2pgn *s2.Polygon, shapeIn,
 	other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if polygonsIntersectsPoint([]*s2.Polygon{s2pgn}, p2.s2point) {
 			return true, nil
 		}
@@ -402,6 +454,9 @@ func checkMultiPolygonContainsShape(s2pgns []*s2.Polygon,
 	shapeIn, other index.GeoJSON) (bool, error) {
 	// check if the other shape is a point.
 	if p2, ok := other.(*Point); ok {
+		if p2.s2point == nil {
+			return false, nil
+		}
 		if polygonsIntersectsPoint(s2pgns, p2.s2point) {
 			return true, nil
 		}
@@ -855,3 +855,66 @@ func TestMultiPolygonBoundingBox(t *testing.T) {
 		}
 	}
 }
+
+func TestPolygonAntimeridian(t *testing.T) {
+	tests := []struct {
+		rings [][][]float64
+		in    [][]float64
+		out   [][]float64
+	}{
+		// Fiji, crossing the antimeridian with longitudes in [-180, 180]
+		{[][][]float64{{{177, -19}, {-179, -19}, {-179, -16}, {177, -16}, {177, -19}}},
+			[][]float64{{179.5, -17}, {-179.5, -17}}, [][]float64{{0, -17}, {170, -17}}},
+		// the same with longitudes beyond 180
+		{[][][]float64{{{177, -19}, {181, -19}, {181, -16}, {177, -16}, {177, -19}}},
+			[][]float64{{179.5, -17}, {-179.5, -17}}, [][]float64{{0, -17}, {170, -17}}},
+		// a Pacific shipping area spanning more than 180 degrees
+		{[][][]float64{{{100, -10}, {300, -10}, {300, 10}, {100, 10}, {100, -10}}},
+			[][]float64{{120, 0}, {180, 0}, {-80, 0}}, [][]float64{{0, 0}, {80, 0}, {-40, 0}}},
+		// a band around the south pole, from -180 to 180
+		{[][][]float64{{{-180, -90}, {180, -90}, {180, -60}, {-180, -60}, {-180, -90}}},
+			[][]float64{{0, -89}, {90, -80}, {180, -70}}, [][]float64{{0, -30}, {180, 0}}},
+	}
+
+	for i, test := range tests {
+		pg := NewGeoJsonPolygon(test.rings)
+		for _, p := range test.in {
+			if ok, _ := pg.Intersects(NewGeoJsonPoint(p)); !ok {
+				t.Fatalf("case %d: expected the polygon to contain %v", i, p)
+			}
+		}
+		for _, p := range test.out {
+			if ok, _ := pg.Intersects(NewGeoJsonPoint(p)); ok {
+				t.Fatalf("case %d: expected the polygon not to contain %v", i, p)
+			}
+		}
+
+		inner, cross := pg.IndexCells()
+		for _, p := range test.in {
+			if !cellsCoverLatLng(append(inner, cross...), p[1], p[0]) {
+				t.Fatalf("case %d: covering does not cover %v", i, p)
+			}
+		}
+		for _, p := range test.out {
+			if cellsCoverLatLng(inner, p[1], p[0]) {
+				t.Fatalf("case %d: inner cells cover %v", i, p)
+			}
+		}
+	}
+}
+
+func TestMultiPolygonSplitAtAntimeridian(t *testing.T) {
+	// RFC 7946 §3.1.9: a polygon crossing the antimeridian split in two
+	mp := NewGeoJsonMultiPolygon([][][][]float64{
+		{{{170, -10}, {180, -10}, {180, 10}, {170, 10}, {170, -10}}},
+		{{{-180, -10}, {-170, -10}, {-170, 10}, {-180, 10}, {-180, -10}}},
+	})
+	for _, p := range [][]float64{{175, 0}, {180, 0}, {-175, 0}} {
+		if ok, _ := mp.Intersects(NewGeoJsonPoint(p)); !ok {
+			t.Fatalf("expected the multipolygon to contain %v", p)
+		}
+	}
+	if ok, _ := mp.Intersects(NewGeoJsonPoint([]float64{0, 0})); ok {
+		t.Fatal("expected the multipolygon not to contain (0, 0)")
+	}
+}
@@ -0,0 +1,224 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// PreparedShape is a query shape whose s2 structures are built once to
+// filter many document shapes with FilterOnRelation: its bounding
+// rectangle, the ShapeIndex used for the distance relations and for
+// intersects and disjoint, the edges used for the DE-9IM relations and
+// for contains and within, and its query covering. These are only read
+// once built, so a PreparedShape is safe for concurrent use.
+type PreparedShape struct {
+	shape index.GeoJSON
+
+	// bound is the bounding rectangle of the shape, expanded by
+	// relateTolerance. Document shapes whose bounding rectangle does
+	// not intersect it are rejected without further checks.
+	bound s2.Rect
+
+	distance *distanceGeometry
+	relate   *relateGeometry
+
+	// envelopes and circles are whether the shape has envelopes and
+	// circles, which some relations check without the geometries.
+	envelopes, circles bool
+
+	inner, cross []uint64
+}
+
+// NewPreparedShape returns the prepared form of the query shape.
+func NewPreparedShape(shape index.GeoJSON) (*PreparedShape, error) {
+	distance, err := newDistanceGeometry(shape)
+	if err != nil {
+		return nil, err
+	}
+	relate, err := newRelateGeometry(shape)
+	if err != nil {
+		return nil, err
+	}
+	// build the index once, instead of on the first query
+	distance.index.Build()
+
+	p := &PreparedShape{
+		shape:     shape,
+		bound:     rectBound(shape).ExpandedByDistance(relateTolerance),
+		distance:  distance,
+		relate:    relate,
+		envelopes: hasShapeType(shape, EnvelopeType),
+		circles:   hasShapeType(shape, CircleType),
+	}
+	p.inner, p.cross = shape.QueryCells()
+	return p, nil
+}
+
+// Shape returns the query shape.
+func (p *PreparedShape) Shape() index.GeoJSON {
+	return p.shape
+}
+
+// QueryCells returns the cached cells covering the query shape.
+func (p *PreparedShape) QueryCells() (inner, cross []uint64) {
+	return p.inner, p.cross
+}
+
+// FilterOnRelation extracts the shape in the document and confirms
+// whether it satisfies the given relation with the query shape, like
+// FilterGeoShapesOnRelation. The boundaries of the shapes are closed
+// for intersects, disjoint, contains and within: a point on the edge of
+// a polygon intersects it and lies within it, where the result of
+// FilterGeoShapesOnRelation depends on the s2 vertex model.
+func (p *PreparedShape) FilterOnRelation(targetShapeBytes []byte,
+	relation string, reader **bytes.Reader, bufPool *s2.GeoBufferPool) (bool, error) {
+
+	shapeInDoc, err := ExtractShapesFromBytes(targetShapeBytes, reader, bufPool)
+	if err != nil {
+		return false, err
+	}
+
+	return p.filter(shapeInDoc, relation)
+}
+
+// filter applies the given relation between the query shape and the
+// shape in the document.
+func (p *PreparedShape) filter(shapeInDoc index.GeoJSON, relation string) (bool, error) {
+	meters, ok, err := parseDWithinRelation(relation)
+	if ok {
+		if err != nil {
+			return false, err
+		}
+		limit := radiusInMetersToS1Angle(meters)
+		bound := rectBound(shapeInDoc)
+		if limit < math.Pi && !bound.IsEmpty() && !p.bound.IsEmpty() &&
+			!p.bound.Intersects(bound.ExpandedByDistance(limit)) {
+			return false, nil
+		}
+		distance, err := newDistanceGeometry(shapeInDoc)
+		if err != nil {
+			return false, err
+		}
+		return distance.withinDistance(p.distance, limit), nil
+	}
+
+	predicate, isRelate := relatePredicates[relation]
+
+	// shapes with disjoint bounding rectangles are disjoint. Empty
+	// shapes are left to the relations, for which some are vacuously
+	// true.
+	bound := rectBound(shapeInDoc)
+	if !bound.IsEmpty() && !p.bound.IsEmpty() && !p.bound.Intersects(bound) {
+		switch {
+		case relation == "disjoint":
+			return true, nil
+		case relation == "intersects", relation == "contains",
+			relation == "within", isRelate:
+			return false, nil
+		}
+	}
+
+	switch {
+	case isRelate:
+		relate, err := newRelateGeometry(shapeInDoc)
+		if err != nil {
+			return false, err
+		}
+		return predicate(relateGeometries(relate, p.relate)), nil
+
+	case relation == "intersects", relation == "disjoint":
+		// envelopes intersect as rectangles, which the distance
+		// geometries approximate by the polygon of their corners
+		if p.envelopes || hasShapeType(shapeInDoc, EnvelopeType) {
+			break
+		}
+		distance, err := newDistanceGeometry(shapeInDoc)
+		if err != nil {
+			return false, err
+		}
+		intersects := distance.withinDistance(p.distance, 0)
+		return intersects == (relation == "intersects"), nil
+
+	case relation == "contains", relation == "within":
+		// the containment of circles and envelopes is checked on their
+		// cap and rectangle, which the relate geometries approximate by
+		// polygons. Otherwise a shape contains another when it covers
+		// it, including on its boundary.
+		if p.envelopes || p.circles || hasShapeType(shapeInDoc, EnvelopeType) ||
+			hasShapeType(shapeInDoc, CircleType) {
+			break
+		}
+		relate, err := newRelateGeometry(shapeInDoc)
+		if err != nil {
+			return false, err
+		}
+		m := relateGeometries(relate, p.relate)
+		if relation == "contains" {
+			return m.Covers(), nil
+		}
+		return m.CoveredBy(), nil
+	}
+
+	return filterShapes(p.shape, shapeInDoc, relation)
+}
+
+// hasShapeType returns whether the shape is of the given type or is a
+// collection with a member of that type.
+func hasShapeType(shape index.GeoJSON, typ string) bool {
+	if gc, ok := shape.(*GeometryCollection); ok {
+		for _, member := range gc.Members() {
+			if member != nil && hasShapeType(member, typ) {
+				return true
+			}
+		}
+		return false
+	}
+	return shape != nil && shape.Type() == typ
+}
+
+// rectBound returns a rectangle bounding the shape as it is seen by the
+// relations, the full rectangle for unknown shapes.
+func rectBound(shape index.GeoJSON) s2.Rect {
+	switch s := shape.(type) {
+	case *Envelope:
+		s.init()
+		// envelopes are checked against some shapes as the polygon of
+		// their corners, whose geodesic edges may leave the rectangle
+		return s.r.Union(s2PolygonFromS2Rectangle(s.r).RectBound())
+
+	case *GeometryCollection:
+		rv := s2.EmptyRect()
+		for _, member := range s.Members() {
+			if member != nil {
+				rv = rv.Union(rectBound(member))
+			}
+		}
+		return rv
+
+	case nil:
+		return s2.FullRect()
+	}
+
+	env, ok := shape.BoundingBox().(*Envelope)
+	if !ok || env == nil || env.r == nil {
+		return s2.FullRect()
+	}
+	return *env.r
+}
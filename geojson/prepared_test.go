@@ -0,0 +1,165 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestPreparedShapeMatchesFilter(t *testing.T) {
+	queries := []index.GeoJSON{
+		NewGeoJsonPolygon([][][]float64{testSquare(0, 10)}),
+		NewGeoJsonPoint([]float64{5, 5}),
+		NewGeoJsonLinestring([][]float64{{-5, 5}, {15, 5}}),
+		NewGeoCircle([]float64{5, 5}, "200km"),
+		NewGeoEnvelope([][]float64{{0, 10}, {10, 0}}),
+		&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{40, 40}),
+			NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})}},
+	}
+	docs := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{5, 5}),
+		NewGeoJsonPoint([]float64{0, 0}),
+		NewGeoJsonPoint([]float64{60, 60}),
+		NewGeoJsonMultiPoint([][]float64{{5, 5}, {60, 60}}),
+		NewGeoJsonLinestring([][]float64{{0, 0}, {0, 10}}),
+		NewGeoJsonLinestring([][]float64{{20, 20}, {30, 30}}),
+		NewGeoJsonMultilinestring([][][]float64{{{2, 2}, {8, 8}}}),
+		NewGeoJsonPolygon([][][]float64{testSquare(2, 8)}),
+		NewGeoJsonPolygon([][][]float64{testSquare(5, 15)}),
+		NewGeoJsonPolygon([][][]float64{testSquare(50, 60)}),
+		NewGeoJsonMultiPolygon([][][][]float64{{testSquare(-20, -10)}, {testSquare(2, 8)}}),
+		NewGeoCircle([]float64{-5, -5}, "100km"),
+		NewGeoEnvelope([][]float64{{-10, 20}, {20, -10}}),
+		NewGeoEnvelope([][]float64{{30, 40}, {40, 30}}),
+	}
+	relations := []string{"intersects", "contains", "within", "disjoint",
+		"touches", "crosses", "overlaps", "equals", "covers", "coveredby",
+		"dwithin 100km", "dwithin 3000km"}
+
+	for i, query := range queries {
+		prepared, err := NewPreparedShape(query)
+		if err != nil {
+			t.Fatalf("query %d: unexpected error: %v", i, err)
+		}
+		var reader *bytes.Reader
+		for j, doc := range docs {
+			data, err := doc.(s2Serializable).Marshal()
+			if err != nil {
+				t.Fatalf("doc %d: unexpected error: %v", j, err)
+			}
+			for _, relation := range relations {
+				want, err := FilterGeoShapesOnRelation(query, data, relation, &reader, nil)
+				if err != nil {
+					t.Fatalf("query %d, doc %d, %s: unexpected error: %v", i, j, relation, err)
+				}
+				got, err := prepared.FilterOnRelation(data, relation, &reader, nil)
+				if err != nil {
+					t.Fatalf("query %d, doc %d, %s: unexpected error: %v", i, j, relation, err)
+				}
+				if got != want {
+					t.Fatalf("query %d, doc %d, %s: expected %v, got %v", i, j, relation, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPreparedShapeBoundaries(t *testing.T) {
+	tests := []struct {
+		query, doc index.GeoJSON
+		want       []string
+	}{
+		// a point on an edge of the polygon
+		{NewGeoJsonPolygon([][][]float64{testSquare(0, 4)}),
+			NewGeoJsonPoint([]float64{2, 0}), []string{"intersects", "within"}},
+		{NewGeoJsonPoint([]float64{2, 0}),
+			NewGeoJsonPolygon([][][]float64{testSquare(0, 4)}), []string{"intersects", "contains"}},
+		// polygons sharing an edge
+		{NewGeoJsonPolygon([][][]float64{testSquare(0, 4)}),
+			NewGeoJsonPolygon([][][]float64{{{0, 4}, {4, 4}, {4, 8}, {0, 8}, {0, 4}}}),
+			[]string{"intersects"}},
+		// a polygon within another, sharing part of its boundary
+		{NewGeoJsonMultiPolygon([][][][]float64{{testSquare(0, 6)}, {testSquare(10, 12)}}),
+			NewGeoJsonPolygon([][][]float64{testSquare(0, 3)}), []string{"intersects", "within"}},
+	}
+
+	for i, test := range tests {
+		prepared, err := NewPreparedShape(test.query)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		want := make(map[string]bool)
+		for _, relation := range test.want {
+			want[relation] = true
+		}
+		want["disjoint"] = !want["intersects"]
+		for _, relation := range []string{"intersects", "disjoint", "contains", "within"} {
+			got, err := prepared.filter(test.doc, relation)
+			if err != nil {
+				t.Fatalf("case %d, %s: unexpected error: %v", i, relation, err)
+			}
+			if got != want[relation] {
+				t.Fatalf("case %d: expected %s to be %v, got %v", i, relation, want[relation], got)
+			}
+		}
+	}
+}
+
+func TestPreparedShape(t *testing.T) {
+	query := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	prepared, err := NewPreparedShape(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prepared.Shape() != query {
+		t.Fatalf("expected the query shape, got %v", prepared.Shape())
+	}
+	inner, cross := query.QueryCells()
+	gotInner, gotCross := prepared.QueryCells()
+	if !reflect.DeepEqual(gotInner, inner) || !reflect.DeepEqual(gotCross, cross) {
+		t.Fatal("expected the cached cells to match the query cells")
+	}
+
+	// shapes outside of the bounding rectangle are rejected before the
+	// relation is checked
+	far := NewGeoJsonPoint([]float64{100, 50})
+	for _, test := range []struct {
+		relation string
+		want     bool
+	}{{"intersects", false}, {"disjoint", true}, {"covers", false}} {
+		got, err := prepared.filter(far, test.relation)
+		if err != nil || got != test.want {
+			t.Fatalf("%s: expected %v, got %v %v", test.relation, test.want, got, err)
+		}
+	}
+
+	data, err := far.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var reader *bytes.Reader
+	if _, err := prepared.FilterOnRelation(data, "nearby", &reader, nil); err == nil {
+		t.Fatal("expected an error for an unknown relation")
+	}
+
+	if _, err := NewPreparedShape(nil); err == nil {
+		t.Fatal("expected an error for a nil shape")
+	}
+}
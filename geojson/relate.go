@@ -0,0 +1,569 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s1"
+	"github.com/blevesearch/geo/s2"
+)
+
+// The rows and columns of an IntersectionMatrix.
+const (
+	Interior = 0
+	Boundary = 1
+	Exterior = 2
+)
+
+// The dimensions held by an IntersectionMatrix. DimEmpty, written F,
+// means that the sets do not intersect.
+const (
+	DimEmpty   = -1
+	DimPoint   = 0
+	DimCurve   = 1
+	DimSurface = 2
+)
+
+// IntersectionMatrix is the DE-9IM matrix of two shapes a and b: the
+// entry [i][j] is the dimension of the intersection of the location i
+// (Interior, Boundary or Exterior) of a with the location j of b.
+type IntersectionMatrix [3][3]int
+
+// String returns the matrix in the usual row major form, such as
+// "212101212", with F for the empty intersections.
+func (m IntersectionMatrix) String() string {
+	var sb strings.Builder
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if m[i][j] == DimEmpty {
+				sb.WriteByte('F')
+			} else {
+				sb.WriteByte(byte('0' + m[i][j]))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Matches returns whether the matrix matches the given 9 character
+// pattern, where T matches any non empty intersection, F an empty one,
+// * anything and 0, 1 and 2 the intersections of that dimension.
+func (m IntersectionMatrix) Matches(pattern string) bool {
+	if len(pattern) != 9 {
+		return false
+	}
+	for k := 0; k < 9; k++ {
+		d := m[k/3][k%3]
+		switch pattern[k] {
+		case '*':
+		case 'T', 't':
+			if d == DimEmpty {
+				return false
+			}
+		case 'F', 'f':
+			if d != DimEmpty {
+				return false
+			}
+		case '0', '1', '2':
+			if d != int(pattern[k]-'0') {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// dimensions returns the dimensions of the two shapes, DimEmpty for an
+// empty shape.
+func (m IntersectionMatrix) dimensions() (int, int) {
+	dimA, dimB := DimEmpty, DimEmpty
+	for k := 0; k < 3; k++ {
+		for _, loc := range []int{Interior, Boundary} {
+			dimA = max(dimA, m[loc][k])
+			dimB = max(dimB, m[k][loc])
+		}
+	}
+	return dimA, dimB
+}
+
+// Intersects returns whether the shapes have at least one point in
+// common.
+func (m IntersectionMatrix) Intersects() bool {
+	return !m.Disjoint()
+}
+
+// Disjoint returns whether the shapes have no point in common.
+func (m IntersectionMatrix) Disjoint() bool {
+	return m.Matches("FF*FF****")
+}
+
+// Contains returns whether b lies in a and their interiors intersect.
+func (m IntersectionMatrix) Contains() bool {
+	return m.Matches("T*****FF*")
+}
+
+// Within returns whether a lies in b and their interiors intersect.
+func (m IntersectionMatrix) Within() bool {
+	return m.Matches("T*F**F***")
+}
+
+// Covers returns whether no point of b lies outside of a.
+func (m IntersectionMatrix) Covers() bool {
+	return m.Intersects() && m.Matches("******FF*")
+}
+
+// CoveredBy returns whether no point of a lies outside of b.
+func (m IntersectionMatrix) CoveredBy() bool {
+	return m.Intersects() && m.Matches("**F**F***")
+}
+
+// Equals returns whether the shapes are topologically equal.
+func (m IntersectionMatrix) Equals() bool {
+	return m.Matches("T*F**FFF*")
+}
+
+// Touches returns whether the shapes have points in common but their
+// interiors do not intersect.
+func (m IntersectionMatrix) Touches() bool {
+	return m[Interior][Interior] == DimEmpty && m.Intersects()
+}
+
+// Crosses returns whether the interiors of the shapes intersect in a
+// set of lower dimension than the higher dimension of the shapes and
+// each shape has points outside of the other.
+func (m IntersectionMatrix) Crosses() bool {
+	dimA, dimB := m.dimensions()
+	switch {
+	case dimA == DimCurve && dimB == DimCurve:
+		return m[Interior][Interior] == DimPoint
+	case dimA < dimB:
+		return m.Matches("T*T******")
+	case dimA > dimB:
+		return m.Matches("T*****T**")
+	}
+	return false
+}
+
+// Overlaps returns whether the shapes have the same dimension, their
+// interiors intersect in a set of that dimension and each shape has
+// points outside of the other.
+func (m IntersectionMatrix) Overlaps() bool {
+	dimA, dimB := m.dimensions()
+	if dimA != dimB || dimA == DimEmpty {
+		return false
+	}
+	return m[Interior][Interior] == dimA &&
+		m.Matches("T*T***T**")
+}
+
+// relatePredicates maps the relations accepted by
+// FilterGeoShapesOnRelation, besides intersects, contains, within and
+// disjoint, to their DE-9IM predicate.
+var relatePredicates = map[string]func(IntersectionMatrix) bool{
+	"touches":   IntersectionMatrix.Touches,
+	"crosses":   IntersectionMatrix.Crosses,
+	"overlaps":  IntersectionMatrix.Overlaps,
+	"equals":    IntersectionMatrix.Equals,
+	"covers":    IntersectionMatrix.Covers,
+	"coveredby": IntersectionMatrix.CoveredBy,
+}
+
+// relateTolerance is the distance under which points are considered to
+// be at the same location.
+const relateTolerance = s1.Angle(1e-12)
+
+// relateSampleDistance is the distance of the points sampled on each
+// side of the polygon edges to find the intersections of the areas.
+const relateSampleDistance = s1.Angle(1e-9)
+
+// relateCircleVertices is the number of vertices of the polygon used to
+// approximate a circle.
+const relateCircleVertices = 128
+
+// Relate returns the DE-9IM matrix of the shapes a and b.
+//
+// The computation works on the s2 representation of the shapes, whose
+// edges are geodesics. Circles are approximated by a regular polygon of
+// 128 vertices and envelopes by the polygon of their corners, as in the
+// other predicates of the package. Points closer than about 6
+// micrometers are considered to be the same. Geometrycollection members
+// are merged, a point being in the interior of the collection if it is
+// in the interior of any member.
+func Relate(a, b index.GeoJSON) (IntersectionMatrix, error) {
+	ga, err := newRelateGeometry(a)
+	if err != nil {
+		return IntersectionMatrix{}, err
+	}
+	gb, err := newRelateGeometry(b)
+	if err != nil {
+		return IntersectionMatrix{}, err
+	}
+	return relateGeometries(ga, gb), nil
+}
+
+// relateGeometries returns the DE-9IM matrix of the geometries.
+func relateGeometries(a, b *relateGeometry) IntersectionMatrix {
+	ga, gb := a.queries(), b.queries()
+	var m IntersectionMatrix
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = DimEmpty
+		}
+	}
+	if len(ga.polygons) == 0 && len(gb.polygons) == 0 {
+		// the exteriors of points and lines cover all of the sphere
+		m[Exterior][Exterior] = DimSurface
+	}
+
+	set := func(x s2.Point, dim int) {
+		locA, locB := ga.locate(x), gb.locate(x)
+		m[locA][locB] = max(m[locA][locB], dim)
+	}
+
+	for _, p := range ga.points {
+		set(p, DimPoint)
+	}
+	for _, p := range gb.points {
+		set(p, DimPoint)
+	}
+
+	for _, g := range []struct {
+		g, other *relateQueries
+	}{{ga, gb}, {gb, ga}} {
+		for _, e := range g.g.edges {
+			nodes := g.g.node(e, g.other)
+			for k, p := range nodes {
+				set(p, DimPoint)
+				if k == 0 {
+					continue
+				}
+				q := nodes[k-1]
+				mid := s2.Interpolate(0.5, q, p)
+				set(mid, DimCurve)
+				if e.polygon < 0 {
+					continue
+				}
+
+				// the areas on each side of the boundary
+				r := min(relateSampleDistance, q.Distance(p)/4)
+				for _, x := range []s2.Point{s2.PointToLeft(mid, p, r),
+					s2.PointToRight(mid, p, r)} {
+					locA, locB := ga.locate(x), gb.locate(x)
+					if locA != Boundary && locB != Boundary {
+						m[locA][locB] = DimSurface
+					}
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+// relateEdge is an edge of a line or polygon boundary.
+type relateEdge struct {
+	a, b s2.Point
+	// bound is the bounding rectangle of the edge expanded by
+	// relateTolerance.
+	bound s2.Rect
+	// polygon is the index of the polygon of the edge, -1 for the edges
+	// of lines.
+	polygon int
+	// aBoundary and bBoundary tell whether the vertices of a line edge
+	// are on the boundary of the line.
+	aBoundary, bBoundary bool
+}
+
+// relateGeometry is the point set of a shape, split in its points,
+// line edges and polygons.
+type relateGeometry struct {
+	points   []s2.Point
+	lines    []s2.Polyline
+	polygons []*s2.Polygon
+	// index holds the polygons, then the lines, then the points of the
+	// geometry, so that the id of a shape tells what it is.
+	index *s2.ShapeIndex
+	// edges are the edges of the polygons and lines of the index, those
+	// of the shape with id i starting at firstEdge[i].
+	edges     []relateEdge
+	firstEdge []int
+	// mixed is set when the shape holds both lines and polygons, in
+	// which case the location of the points of an edge may change along
+	// the edge.
+	mixed bool
+}
+
+func newRelateGeometry(shape index.GeoJSON) (*relateGeometry, error) {
+	g := &relateGeometry{index: s2.NewShapeIndex()}
+	err := g.add(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	// the boundary of the lines holds the end points found an odd
+	// number of times (the mod-2 rule)
+	ends := make(map[s2.Point]int)
+	for _, pl := range g.lines {
+		ends[pl[0]]++
+		ends[pl[len(pl)-1]]++
+	}
+	for k, pgn := range g.polygons {
+		g.index.Add(pgn)
+		g.firstEdge = append(g.firstEdge, len(g.edges))
+		for i := 0; i < pgn.NumEdges(); i++ {
+			e := pgn.Edge(i)
+			g.addEdge(relateEdge{a: e.V0, b: e.V1, polygon: k})
+		}
+	}
+	for k := range g.lines {
+		pl := g.lines[k]
+		g.index.Add(&g.lines[k])
+		g.firstEdge = append(g.firstEdge, len(g.edges))
+		for i := 0; i+1 < len(pl); i++ {
+			g.addEdge(relateEdge{a: pl[i], b: pl[i+1], polygon: -1,
+				aBoundary: i == 0 && ends[pl[0]]%2 == 1,
+				bBoundary: i+2 == len(pl) && ends[pl[len(pl)-1]]%2 == 1})
+		}
+	}
+	if len(g.points) > 0 {
+		points := s2.PointVector(g.points)
+		g.index.Add(&points)
+	}
+
+	// the index is built now, so that the geometry is only read from
+	// then on
+	g.index.Build()
+	g.mixed = len(g.lines) > 0 && len(g.polygons) > 0
+	return g, nil
+}
+
+// relateQueries is a geometry with the queries on its index. The
+// queries keep state between calls, so they are made for each relation
+// rather than kept with the geometry, which a PreparedShape shares
+// between goroutines.
+type relateQueries struct {
+	*relateGeometry
+	// edgeQuery finds the edges and points within relateTolerance of a
+	// target, and containsQuery the polygons containing a point.
+	edgeQuery     *s2.EdgeQuery
+	containsQuery *s2.ContainsPointQuery
+}
+
+func (g *relateGeometry) queries() *relateQueries {
+	opts := s2.NewClosestEdgeQueryOptions().IncludeInteriors(false).
+		DistanceLimit(s1.ChordAngleFromAngle(relateTolerance).Successor())
+	return &relateQueries{relateGeometry: g,
+		edgeQuery:     s2.NewClosestEdgeQuery(g.index, opts),
+		containsQuery: s2.NewContainsPointQuery(g.index, s2.VertexModelSemiOpen)}
+}
+
+func (g *relateGeometry) addEdge(e relateEdge) {
+	rb := s2.NewRectBounder()
+	rb.AddPoint(e.a)
+	rb.AddPoint(e.b)
+	e.bound = rb.RectBound().ExpandedByDistance(relateTolerance)
+	g.edges = append(g.edges, e)
+}
+
+// nearEdges returns the edges and points of the geometry found by the
+// edge query, the points as edges whose vertices are the point.
+func (g *relateGeometry) nearEdges(results []s2.EdgeQueryResult) []relateEdge {
+	var rv []relateEdge
+	for _, r := range results {
+		if id := int(r.ShapeID()); id < len(g.firstEdge) {
+			rv = append(rv, g.edges[g.firstEdge[id]+int(r.EdgeID())])
+		} else {
+			p := g.points[r.EdgeID()]
+			rv = append(rv, relateEdge{a: p, b: p, polygon: -1})
+		}
+	}
+	return rv
+}
+
+func (g *relateGeometry) addPolyline(pl s2.Polyline) {
+	switch len(pl) {
+	case 0:
+	case 1:
+		g.points = append(g.points, pl[0])
+	default:
+		g.lines = append(g.lines, pl)
+	}
+}
+
+// add adds the point set of the shape to the geometry.
+func (g *relateGeometry) add(shape index.GeoJSON) error {
+	switch s := shape.(type) {
+	case *Point:
+		s.init()
+		if s.s2point != nil {
+			g.points = append(g.points, *s.s2point)
+		}
+
+	case *MultiPoint:
+		s.init()
+		for _, p := range s.s2points {
+			g.points = append(g.points, *p)
+		}
+
+	case *LineString:
+		s.init()
+		if s.pl != nil {
+			g.addPolyline(*s.pl)
+		}
+
+	case *MultiLineString:
+		s.init()
+		for _, pl := range s.pls {
+			g.addPolyline(*pl)
+		}
+
+	case *Polygon:
+		s.init()
+		if s.s2pgn != nil && !s.s2pgn.IsEmpty() {
+			g.polygons = append(g.polygons, s.s2pgn)
+		}
+
+	case *MultiPolygon:
+		s.init()
+		for _, pgn := range s.s2pgns {
+			if !pgn.IsEmpty() {
+				g.polygons = append(g.polygons, pgn)
+			}
+		}
+
+	case *Circle:
+		s.init()
+		loop := s2.RegularLoop(s.s2cap.Center(), s.s2cap.Radius(),
+			relateCircleVertices)
+		g.polygons = append(g.polygons, s2.PolygonFromLoops([]*s2.Loop{loop}))
+
+	case *Envelope:
+		s.init()
+		g.polygons = append(g.polygons, s2PolygonFromS2Rectangle(s.r))
+
+	case *GeometryCollection:
+		for _, member := range s.Members() {
+			if member == nil {
+				continue
+			}
+			if err := g.add(member); err != nil {
+				return err
+			}
+		}
+
+	default:
+		if shape == nil {
+			return fmt.Errorf("nil shape")
+		}
+		return fmt.Errorf("unknown geojson type: %s", shape.Type())
+	}
+
+	return nil
+}
+
+// locate returns the location of the point in the geometry.
+func (g *relateQueries) locate(x s2.Point) int {
+	loc := Exterior
+	var onPolygon []bool
+	for _, e := range g.nearEdges(
+		g.edgeQuery.FindEdges(s2.NewMinDistanceToPointTarget(x))) {
+		if e.polygon >= 0 {
+			if onPolygon == nil {
+				onPolygon = make([]bool, len(g.polygons))
+			}
+			onPolygon[e.polygon] = true
+			loc = Boundary
+			continue
+		}
+		if (e.aBoundary && x.Distance(e.a) <= relateTolerance) ||
+			(e.bBoundary && x.Distance(e.b) <= relateTolerance) {
+			loc = Boundary
+			continue
+		}
+		// a point of a line, or a point of the geometry
+		return Interior
+	}
+
+	if len(g.polygons) == 0 {
+		return loc
+	}
+	// only the polygons of the index contain points
+	for _, shape := range g.containsQuery.ContainingShapes(x) {
+		if onPolygon == nil || !onPolygon[g.polygonID(shape.(*s2.Polygon))] {
+			return Interior
+		}
+	}
+
+	return loc
+}
+
+// polygonID returns the index of the polygon in the geometry.
+func (g *relateGeometry) polygonID(pgn *s2.Polygon) int {
+	for k, p := range g.polygons {
+		if p == pgn {
+			return k
+		}
+	}
+	return -1
+}
+
+// node returns the vertices of the edge followed by the points where
+// the edge meets the other geometry, ordered from the start of the
+// edge, so that every location of the two geometries is constant
+// between consecutive points.
+func (g *relateQueries) node(e relateEdge, other *relateQueries) []s2.Point {
+	nodes := []s2.Point{e.a, e.b}
+	addPoint := func(p s2.Point) {
+		if e.bound.ContainsPoint(p) &&
+			s2.DistanceFromSegment(p, e.a, e.b) <= relateTolerance {
+			nodes = append(nodes, p)
+		}
+	}
+	addEdges := func(edges []relateEdge) {
+		for _, f := range edges {
+			if f.a == e.a && f.b == e.b {
+				continue
+			}
+			if s2.CrossingSign(e.a, e.b, f.a, f.b) == s2.Cross {
+				nodes = append(nodes, s2.Intersection(e.a, e.b, f.a, f.b))
+			}
+			addPoint(f.a)
+			addPoint(f.b)
+		}
+	}
+
+	target := s2.NewMinDistanceToEdgeTarget(s2.Edge{V0: e.a, V1: e.b})
+	addEdges(other.nearEdges(other.edgeQuery.FindEdges(target)))
+	if g.mixed {
+		addEdges(g.nearEdges(g.edgeQuery.FindEdges(target)))
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return e.a.Distance(nodes[i]) < e.a.Distance(nodes[j])
+	})
+	rv := nodes[:1]
+	for _, p := range nodes[1:] {
+		if p.Distance(rv[len(rv)-1]) > relateTolerance {
+			rv = append(rv, p)
+		}
+	}
+	return rv
+}
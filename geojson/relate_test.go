@@ -0,0 +1,262 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestRelate(t *testing.T) {
+	square := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	tests := []struct {
+		a, b index.GeoJSON
+		want string
+	}{
+		// equal polygons
+		{square, NewGeoJsonPolygon([][][]float64{testSquare(0, 10)}), "2FFF1FFF2"},
+		// polygons sharing an edge
+		{square, NewGeoJsonPolygon([][][]float64{
+			{{10, 0}, {20, 0}, {20, 10}, {10, 10}, {10, 0}}}), "FF2F11212"},
+		// overlapping polygons
+		{square, NewGeoJsonPolygon([][][]float64{testSquare(5, 15)}), "212101212"},
+		// a polygon inside another
+		{square, NewGeoJsonPolygon([][][]float64{testSquare(2, 8)}), "212FF1FF2"},
+		// a line crossing a polygon
+		{NewGeoJsonLinestring([][]float64{{-5, 5}, {15, 5}}), square, "101FF0212"},
+		// a line along the boundary of a polygon
+		{NewGeoJsonLinestring([][]float64{{0, 0}, {0, 10}}), square, "F1FF0F212"},
+		// crossing lines
+		{NewGeoJsonLinestring([][]float64{{0, -5}, {0, 5}}),
+			NewGeoJsonLinestring([][]float64{{-5, 0}, {5, 0}}), "0F1FF0102"},
+		// overlapping lines
+		{NewGeoJsonLinestring([][]float64{{0, 0}, {10, 0}}),
+			NewGeoJsonLinestring([][]float64{{5, 0}, {15, 0}}), "1010F0102"},
+		// a closed line has no boundary
+		{NewGeoJsonLinestring([][]float64{{0, 0}, {10, 0}, {10, 10}, {0, 0}}),
+			NewGeoJsonPoint([]float64{0, 0}), "0F1FFFFF2"},
+		// points inside, on the boundary and outside of a polygon
+		{NewGeoJsonPoint([]float64{5, 5}), square, "0FFFFF212"},
+		{NewGeoJsonPoint([]float64{0, 0}), square, "F0FFFF212"},
+		{NewGeoJsonPoint([]float64{20, 20}), square, "FF0FFF212"},
+		// a point on the end of a line
+		{NewGeoJsonPoint([]float64{0, 0}),
+			NewGeoJsonLinestring([][]float64{{0, 0}, {10, 0}}), "F0FFFF102"},
+		{NewGeoJsonMultiPoint([][]float64{{5, 5}, {20, 20}}), square, "0F0FFF212"},
+		// an envelope and the polygon of its corners
+		{NewGeoEnvelope([][]float64{{0, 10}, {10, 0}}), square, "2FFF1FFF2"},
+		{NewGeoCircle([]float64{5, 5}, "10km"), NewGeoJsonPoint([]float64{5, 5}), "0F2FF1FF2"},
+		{&GeometryCollection{Typ: GeometryCollectionType, Shapes: []index.GeoJSON{
+			NewGeoJsonPoint([]float64{5, 5}),
+			NewGeoJsonPolygon([][][]float64{testSquare(20, 30)})}},
+			square, "0F2FF1212"},
+	}
+
+	for i, test := range tests {
+		m, err := Relate(test.a, test.b)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if m.String() != test.want {
+			t.Fatalf("case %d: expected %s, got %s", i, test.want, m)
+		}
+
+		// the matrix of the reversed relation is transposed, and the
+		// decoded shapes must give the same result
+		m, err = Relate(decodeShape(t, test.b), decodeShape(t, test.a))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		var want IntersectionMatrix
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				want[r][c] = m[c][r]
+			}
+		}
+		if want.String() != test.want {
+			t.Fatalf("case %d: expected the transposed %s, got %s", i, test.want, m)
+		}
+	}
+
+	m, err := Relate(&Point{Typ: PointType}, square)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "FFFFFF212"; m.String() != want {
+		t.Fatalf("expected %s for an empty point, got %s", want, m)
+	}
+}
+
+// testRing returns a ring of n vertices around the center.
+func testRing(lng, lat, radius float64, n int) [][]float64 {
+	ring := make([][]float64, 0, n+1)
+	for i := 0; i < n; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		ring = append(ring, []float64{lng + radius*math.Cos(angle),
+			lat + radius*math.Sin(angle)})
+	}
+	return append(ring, ring[0])
+}
+
+func TestRelateLargeShapes(t *testing.T) {
+	a := NewGeoJsonPolygon([][][]float64{testRing(0, 0, 10, 2000)})
+	tests := []struct {
+		b    index.GeoJSON
+		want string
+	}{
+		{NewGeoJsonPolygon([][][]float64{testRing(5, 0, 10, 2000)}), "212101212"},
+		{NewGeoJsonPolygon([][][]float64{testRing(0, 0, 5, 2000)}), "212FF1FF2"},
+		{NewGeoJsonPolygon([][][]float64{testRing(30, 0, 10, 2000)}), "FF2FF1212"},
+		{NewGeoJsonLinestring(testRing(10, 0, 5, 2000)), "1F20F11F2"},
+	}
+	for i, test := range tests {
+		m, err := Relate(a, test.b)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if m.String() != test.want {
+			t.Fatalf("case %d: expected %s, got %s", i, test.want, m)
+		}
+	}
+}
+
+func BenchmarkRelateLargePolygons(b *testing.B) {
+	pa := NewGeoJsonPolygon([][][]float64{testRing(0, 0, 10, 2000)})
+	pb := NewGeoJsonPolygon([][][]float64{testRing(5, 0, 10, 2000)})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Relate(pa, pb); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestIntersectionMatrixPredicates(t *testing.T) {
+	tests := []struct {
+		matrix string
+		want   []string
+	}{
+		{"2FFF1FFF2", []string{"intersects", "contains", "within", "covers",
+			"coveredby", "equals"}},
+		{"FF2F11212", []string{"intersects", "touches"}},
+		{"212101212", []string{"intersects", "overlaps"}},
+		{"212FF1FF2", []string{"intersects", "contains", "covers"}},
+		{"101FF0212", []string{"intersects", "crosses"}},
+		{"F1FF0F212", []string{"intersects", "touches", "coveredby"}},
+		{"0F1FF0102", []string{"intersects", "crosses"}},
+		{"1010F0102", []string{"intersects", "overlaps"}},
+		{"FF0FFF212", []string{"disjoint"}},
+		{"0F0FFF212", []string{"intersects", "crosses"}},
+	}
+
+	for i, test := range tests {
+		var m IntersectionMatrix
+		for k, c := range test.matrix {
+			m[k/3][k%3] = DimEmpty
+			if c != 'F' {
+				m[k/3][k%3] = int(c - '0')
+			}
+		}
+		if m.String() != test.matrix {
+			t.Fatalf("case %d: expected %s, got %s", i, test.matrix, m)
+		}
+
+		got := map[string]bool{
+			"intersects": m.Intersects(),
+			"disjoint":   m.Disjoint(),
+			"contains":   m.Contains(),
+			"within":     m.Within(),
+		}
+		for relation, predicate := range relatePredicates {
+			got[relation] = predicate(m)
+		}
+		want := make(map[string]bool)
+		for _, relation := range test.want {
+			want[relation] = true
+		}
+		for relation, ok := range got {
+			if ok != want[relation] {
+				t.Fatalf("case %d: expected %s to be %v for %s", i, relation,
+					want[relation], test.matrix)
+			}
+		}
+	}
+}
+
+func TestIntersectionMatrixMatches(t *testing.T) {
+	m := IntersectionMatrix{{2, 1, 2}, {1, 0, 1}, {2, 1, 2}}
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"212101212", true},
+		{"TTTTTTTTT", true},
+		{"*********", true},
+		{"T*F******", false},
+		{"2*2***1**", false},
+		{"21210121", false},
+		{"X********", false},
+	}
+
+	for i, test := range tests {
+		if got := m.Matches(test.pattern); got != test.want {
+			t.Fatalf("case %d: expected %v for %s, got %v", i, test.want, test.pattern, got)
+		}
+	}
+}
+
+func TestFilterGeoShapesOnRelationDE9IM(t *testing.T) {
+	square := NewGeoJsonPolygon([][][]float64{testSquare(0, 10)})
+	tests := []struct {
+		query, doc index.GeoJSON
+		relation   string
+		want       bool
+	}{
+		{square, NewGeoJsonLinestring([][]float64{{0, 0}, {0, 10}}), "touches", true},
+		{square, NewGeoJsonLinestring([][]float64{{0, 0}, {0, 10}}), "coveredby", true},
+		{NewGeoJsonPoint([]float64{0, 0}), square, "covers", true},
+		{square, NewGeoJsonLinestring([][]float64{{-5, 5}, {15, 5}}), "crosses", true},
+		{square, NewGeoJsonPolygon([][][]float64{testSquare(5, 15)}), "overlaps", true},
+		{square, NewGeoEnvelope([][]float64{{0, 10}, {10, 0}}), "equals", true},
+		{square, NewGeoJsonPolygon([][][]float64{testSquare(2, 8)}), "equals", false},
+	}
+
+	for i, test := range tests {
+		data, err := test.doc.(s2Serializable).Marshal()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		var reader *bytes.Reader
+		got, err := FilterGeoShapesOnRelation(test.query, data, test.relation, &reader, nil)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %s to be %v, got %v", i, test.relation, test.want, got)
+		}
+	}
+
+	data, err := square.(s2Serializable).Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var reader *bytes.Reader
+	if _, err := FilterGeoShapesOnRelation(square, data, "nearby", &reader, nil); err == nil {
+		t.Fatal("expected an error for an unknown relation")
+	}
+}
@@ -0,0 +1,291 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s1"
+	"github.com/blevesearch/geo/s2"
+)
+
+// Simplify returns a copy of the shape whose linestrings and polygons
+// keep as few of their vertices as possible while every point of them
+// stays within the given distance in meters of the original ones, and
+// the original ones within that distance of them. Rings which collapse
+// within the distance are dropped, and polygons whose rings would cross
+// each other once simplified are rebuilt so that the result is always a
+// valid polygon; a polygon which can't be simplified that way is kept as
+// it is, as is a polygon or multipolygon which would collapse entirely,
+// so that it still has cells to be found by. Points, circles and
+// envelopes are returned as they are, and the members of a
+// geometrycollection are simplified in turn.
+func Simplify(shape index.GeoJSON, meters float64) (index.GeoJSON, error) {
+	return simplifyShape(shape, meters, false)
+}
+
+// simplifyShape simplifies the shape as Simplify does. keepOriginal
+// keeps the original coordinates of the simplified shape, rather than
+// those of its simplified s2 representation, which are still the ones
+// encoded by Marshal when they have extra ordinates.
+func simplifyShape(shape index.GeoJSON, meters float64,
+	keepOriginal bool) (index.GeoJSON, error) {
+	if math.IsNaN(meters) || meters < 0 {
+		return nil, fmt.Errorf("invalid simplification tolerance: %v", meters)
+	}
+	tolerance := radiusInMetersToS1Angle(meters)
+
+	switch s := shape.(type) {
+	case *LineString:
+		s.init()
+		rv := &LineString{Typ: s.Typ, Vertices: s.Vertices,
+			pl: simplifyPolyline(s.pl, tolerance)}
+		if lookup, ok := simplifiedLookup(s, keepOriginal); ok && rv.pl != nil {
+			positions := extendPositions(positionsFromS2Points(*rv.pl), lookup)
+			if keepOriginal {
+				rv.simplified = [][][][]float64{{positions}}
+			} else {
+				rv.Vertices = positions
+			}
+		}
+		return rv, nil
+
+	case *MultiLineString:
+		s.init()
+		rv := &MultiLineString{Typ: s.Typ, Vertices: s.Vertices,
+			pls: make([]*s2.Polyline, len(s.pls))}
+		for i, pl := range s.pls {
+			rv.pls[i] = simplifyPolyline(pl, tolerance)
+		}
+		if lookup, ok := simplifiedLookup(s, keepOriginal); ok {
+			lines := make([][][]float64, 0, len(rv.pls))
+			for _, pl := range rv.pls {
+				if pl != nil {
+					lines = append(lines,
+						extendPositions(positionsFromS2Points(*pl), lookup))
+				}
+			}
+			if keepOriginal {
+				rv.simplified = [][][][]float64{lines}
+			} else {
+				rv.Vertices = lines
+			}
+		}
+		return rv, nil
+
+	case *Polygon:
+		s.init()
+		rv := &Polygon{Typ: s.Typ, Vertices: s.Vertices,
+			s2pgn: simplifyPolygon(s.s2pgn, tolerance)}
+		if rv.s2pgn != nil && rv.s2pgn.IsEmpty() {
+			rv.s2pgn = s.s2pgn
+		}
+		if rv.s2pgn == s.s2pgn {
+			return rv, nil
+		}
+		if lookup, ok := simplifiedLookup(s, keepOriginal); ok {
+			// a polygon split into several shells by the simplification
+			// keeps the rings of all of them, as its s2 representation
+			var rings [][][]float64
+			polygons, _ := ringsFromS2Polygon(rv.s2pgn)
+			for _, polygon := range polygons {
+				for _, ring := range polygon {
+					rings = append(rings, extendPositions(ring, lookup))
+				}
+			}
+			if keepOriginal {
+				rv.simplified = [][][][]float64{rings}
+			} else {
+				rv.Vertices = rings
+			}
+		}
+		return rv, nil
+
+	case *MultiPolygon:
+		s.init()
+		rv := &MultiPolygon{Typ: s.Typ, Vertices: s.Vertices,
+			s2pgns: make([]*s2.Polygon, len(s.s2pgns))}
+		simplified, collapsed := false, true
+		for i, pgn := range s.s2pgns {
+			rv.s2pgns[i] = simplifyPolygon(pgn, tolerance)
+			simplified = simplified || rv.s2pgns[i] != pgn
+			collapsed = collapsed && (pgn == nil || rv.s2pgns[i].IsEmpty())
+		}
+		if collapsed {
+			copy(rv.s2pgns, s.s2pgns)
+			simplified = false
+		}
+		if !simplified {
+			return rv, nil
+		}
+		if lookup, ok := simplifiedLookup(s, keepOriginal); ok {
+			var polygons [][][][]float64
+			for _, pgn := range rv.s2pgns {
+				rings, _ := ringsFromS2Polygon(pgn)
+				for _, polygon := range rings {
+					for i, ring := range polygon {
+						polygon[i] = extendPositions(ring, lookup)
+					}
+					polygons = append(polygons, polygon)
+				}
+			}
+			if keepOriginal {
+				rv.simplified = polygons
+			} else {
+				rv.Vertices = polygons
+			}
+		}
+		return rv, nil
+
+	case *GeometryCollection:
+		rv := &GeometryCollection{Typ: s.Typ,
+			Shapes: make([]index.GeoJSON, len(s.Shapes))}
+		for i, member := range s.Shapes {
+			var err error
+			rv.Shapes[i], err = simplifyShape(member, meters, keepOriginal)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return rv, nil
+	}
+
+	return shape, nil
+}
+
+// simplifyPolyline returns the polyline with only the vertices that
+// SubsampleVertices keeps within the tolerance.
+func simplifyPolyline(pl *s2.Polyline, tolerance s1.Angle) *s2.Polyline {
+	if pl == nil {
+		return nil
+	}
+	keep := pl.SubsampleVertices(tolerance)
+	rv := make(s2.Polyline, len(keep))
+	for i, k := range keep {
+		rv[i] = (*pl)[k]
+	}
+	return &rv
+}
+
+// simplifyPolygon returns the polygon simplified within the tolerance,
+// following the approach of S2Polygon::InitToSimplified: every loop is
+// subsampled within half the tolerance, then the subsampled edges are
+// snapped with a Builder whose snap radius is a quarter of it, which
+// splits the edges that cross, merges the vertices that come too close
+// and drops the loops that collapse. The edges move by at most
+// 0.5 + 1.1*0.25 times the tolerance overall.
+//
+// The original polygon is returned when the result isn't valid, or when
+// its area differs from the original one by more than the edges moving
+// within the tolerance can account for, as when a loop is inverted.
+func simplifyPolygon(pgn *s2.Polygon, tolerance s1.Angle) *s2.Polygon {
+	if pgn == nil || pgn.IsEmpty() || pgn.IsFull() || tolerance <= 0 {
+		return pgn
+	}
+
+	b := s2.NewBuilder(s2.BuilderOptions{
+		SnapFunction:       s2.NewIdentitySnapper(tolerance / 4),
+		SplitCrossingEdges: true,
+		Idempotent:         true,
+	})
+	layerOpts := s2.DefaultPolygonLayerOptions()
+	layerOpts.Validate = true
+	layer := s2.NewPolygonLayer(layerOpts)
+	b.StartLayer(layer)
+	b.AddIsFullPolygonPredicate(s2.IsFullPolygon(false))
+
+	var perimeter float64
+	for _, loop := range pgn.Loops() {
+		// the chain goes around the loop with the interior of the
+		// polygon on its left, back to its first vertex
+		n := loop.NumVertices()
+		chain := make(s2.Polyline, 0, n+1)
+		for i := 0; i < n; i++ {
+			chain = append(chain, loop.OrientedVertex(i))
+		}
+		chain = append(chain, chain[0])
+		perimeter += chain.Length().Radians()
+
+		keep := chain.SubsampleVertices(tolerance / 2)
+		if len(keep) < 4 {
+			continue
+		}
+		for i := 1; i < len(keep); i++ {
+			b.AddEdge(chain[keep[i-1]], chain[keep[i]])
+		}
+	}
+
+	if err := b.Build(); err != nil {
+		return pgn
+	}
+	rv := layer.Polygon()
+	t := tolerance.Radians()
+	maxAreaChange := 2*t*perimeter + math.Pi*t*t*float64(pgn.NumLoops())
+	if math.Abs(rv.Area()-pgn.Area()) > maxAreaChange {
+		return pgn
+	}
+	return rv
+}
+
+// simplifiedLookup returns the extra ordinates of the positions of the
+// shape by extraOrdinatesByPosition, and whether the positions of its
+// simplified s2 representation are needed: as its coordinates, or to be
+// encoded in place of the original ones it keeps when they have extra
+// ordinates.
+func simplifiedLookup(shape positionedShape,
+	keepOriginal bool) (map[[2]float64][]float64, bool) {
+	lookup := extraOrdinatesByPosition(shape.positions())
+	return lookup, !keepOriginal || lookup != nil
+}
+
+// extraOrdinatesByPosition maps the positions, by positionKey, to their
+// extra ordinates. It is nil when none of them has any.
+func extraOrdinatesByPosition(coords [][][][]float64) map[[2]float64][]float64 {
+	if !hasExtraOrdinates(coords) {
+		return nil
+	}
+	lookup := make(map[[2]float64][]float64)
+	for _, polygon := range coords {
+		for _, ring := range polygon {
+			for _, pos := range ring {
+				if len(pos) < 3 {
+					continue
+				}
+				key := positionKey(pos)
+				if _, ok := lookup[key]; !ok {
+					lookup[key] = pos[2:]
+				}
+			}
+		}
+	}
+	return lookup
+}
+
+// extendPositions appends the extra ordinates of the original positions
+// to the positions output from the s2 points kept from them.
+func extendPositions(positions [][]float64,
+	lookup map[[2]float64][]float64) [][]float64 {
+	if lookup == nil {
+		return positions
+	}
+	for i, pos := range positions {
+		if extra := lookup[[2]float64{pos[0], pos[1]}]; len(extra) > 0 {
+			positions[i] = append(pos[:2:2], extra...)
+		}
+	}
+	return positions
+}
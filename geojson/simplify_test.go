@@ -0,0 +1,310 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/geo/s2"
+)
+
+// noisyRing returns a closed ring of n positions around the center,
+// whose radius in degrees alternately grows and shrinks by jitter.
+func noisyRing(lng, lat, radius, jitter float64, n int, clockwise bool) [][]float64 {
+	ring := make([][]float64, 0, n+1)
+	for i := 0; i < n; i++ {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		if clockwise {
+			a = -a
+		}
+		r := radius + jitter*float64(i%2*2-1)
+		ring = append(ring, []float64{lng + r*math.Cos(a), lat + r*math.Sin(a)})
+	}
+	return append(ring, ring[0])
+}
+
+// maxBoundaryDistance returns the greatest distance in meters from the
+// vertices and edge midpoints of the polygon a to the boundary of b.
+func maxBoundaryDistance(a, b *s2.Polygon) float64 {
+	idx := s2.NewShapeIndex()
+	idx.Add(b)
+	query := s2.NewClosestEdgeQuery(idx,
+		s2.NewClosestEdgeQueryOptions().IncludeInteriors(false))
+	var rv float64
+	for _, loop := range a.Loops() {
+		for i := 0; i < loop.NumVertices(); i++ {
+			v0, v1 := loop.Vertex(i), loop.Vertex(i+1)
+			mid := s2.Point{Vector: v0.Add(v1.Vector).Normalize()}
+			for _, p := range []s2.Point{v0, mid} {
+				d := query.Distance(s2.NewMinDistanceToPointTarget(p))
+				rv = math.Max(rv, d.Angle().Radians()*earthRadiusInMeter)
+			}
+		}
+	}
+	return rv
+}
+
+func TestSimplifyPolygon(t *testing.T) {
+	shell := noisyRing(10, 45, 1, 0.0002, 20000, false)
+	hole := noisyRing(10, 45, 0.5, 0.0002, 10000, true)
+	original := NewGeoJsonPolygon([][][]float64{shell, hole}).(*Polygon)
+
+	for _, meters := range []float64{100, 500, 5000} {
+		shape, err := Simplify(original, meters)
+		if err != nil {
+			t.Fatalf("%v m: unexpected error: %v", meters, err)
+		}
+		simplified := shape.(*Polygon)
+		pgn := simplified.s2pgn
+		if err := pgn.Validate(); err != nil {
+			t.Fatalf("%v m: expected a valid polygon, got %v", meters, err)
+		}
+		if pgn.NumLoops() != 2 {
+			t.Fatalf("%v m: expected the shell and the hole, got %d loops",
+				meters, pgn.NumLoops())
+		}
+		if pgn.NumEdges() > original.s2pgn.NumEdges()/10 {
+			t.Fatalf("%v m: expected far fewer than %d edges, got %d", meters,
+				original.s2pgn.NumEdges(), pgn.NumEdges())
+		}
+		if d := maxBoundaryDistance(pgn, original.s2pgn); d > meters {
+			t.Fatalf("%v m: simplified boundary is %v m away", meters, d)
+		}
+		if d := maxBoundaryDistance(original.s2pgn, pgn); d > meters {
+			t.Fatalf("%v m: original boundary is %v m away", meters, d)
+		}
+
+		// the coordinates are those of the simplified polygon, and are
+		// built into the same polygon again
+		if len(simplified.Vertices) != 2 ||
+			len(simplified.Vertices[0]) != pgn.Loop(0).NumVertices()+1 {
+			t.Fatalf("%v m: expected the simplified coordinates", meters)
+		}
+		decoded := decodeShape(t, simplified)
+		ok, err := decoded.Intersects(NewGeoJsonPoint([]float64{10, 45}))
+		if err != nil || ok {
+			t.Fatalf("%v m: expected the hole to remain, got %v %v", meters, ok, err)
+		}
+		ok, err = decoded.Intersects(NewGeoJsonPoint([]float64{10.75, 45}))
+		if err != nil || !ok {
+			t.Fatalf("%v m: expected the shell to remain, got %v %v", meters, ok, err)
+		}
+	}
+}
+
+func TestSimplifyCollapsedRings(t *testing.T) {
+	// the small island and the small hole collapse within the tolerance
+	island := [][]float64{{20, 0}, {20.001, 0}, {20.001, 0.001}, {20, 0.001}, {20, 0}}
+	hole := [][]float64{{0.5, 0.5}, {0.5, 0.501}, {0.501, 0.501}, {0.501, 0.5}, {0.5, 0.5}}
+	shape := NewGeoJsonMultiPolygon([][][][]float64{
+		{testSquare(0, 1), hole}, {island}})
+
+	simplified, err := Simplify(shape, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp := simplified.(*MultiPolygon)
+	if mp.s2pgns[0].NumLoops() != 1 || !mp.s2pgns[1].IsEmpty() {
+		t.Fatalf("expected the island and the hole to be dropped, got %d and %d loops",
+			mp.s2pgns[0].NumLoops(), mp.s2pgns[1].NumLoops())
+	}
+	if len(mp.Vertices) != 1 || len(mp.Vertices[0]) != 1 {
+		t.Fatalf("expected the coordinates of the square only, got %v", mp.Vertices)
+	}
+}
+
+func TestSimplifyLineString(t *testing.T) {
+	// a zigzag of 2 meters along the equator, with altitudes
+	var positions [][]float64
+	for i := 0; i <= 1000; i++ {
+		lat := float64(i%2) * 2 / oneDegree
+		positions = append(positions, []float64{float64(i) / 100, lat, float64(i)})
+	}
+	line := NewGeoJsonLinestring(positions).(*LineString)
+
+	shape, err := Simplify(line, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simplified := shape.(*LineString)
+	if len(*simplified.pl) != 2 {
+		t.Fatalf("expected a straight line, got %d vertices", len(*simplified.pl))
+	}
+	want := [][]float64{{0, 0, 0}, {10, 0, 1000}}
+	if !reflect.DeepEqual(simplified.Vertices, want) {
+		t.Fatalf("expected %v, got %v", want, simplified.Vertices)
+	}
+
+	// within the tolerance of the zigzag, nothing is dropped
+	shape, err = Simplify(line, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(*shape.(*LineString).pl); n != len(positions) {
+		t.Fatalf("expected %d vertices, got %d", len(positions), n)
+	}
+}
+
+func TestSimplifyOptions(t *testing.T) {
+	shell := noisyRing(0, 0, 1, 0.0002, 5000, false)
+	input, err := ToGeoJSON(NewGeoJsonPolygon([][][]float64{shell}), GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simplification is off by default
+	shape, err := ParseGeoJSONShapeWithOptions(input, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := shape.(*Polygon).s2pgn.NumEdges(); n != 5000 {
+		t.Fatalf("expected 5000 edges, got %d", n)
+	}
+
+	// the original coordinates are kept for display if asked to
+	shape, err = ParseGeoJSONShapeWithOptions(input,
+		ParseOptions{SimplifyTolerance: 100, KeepOriginal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := shape.(*Polygon).s2pgn.NumEdges(); n >= 500 {
+		t.Fatalf("expected fewer than 500 edges, got %d", n)
+	}
+	if got := shape.(*Polygon).Coordinates(); len(got[0]) != len(shell) {
+		t.Fatalf("expected the %d original positions, got %d", len(shell), len(got[0]))
+	}
+	if value, err := shape.Value(); err != nil || len(value) < len(input)*9/10 {
+		t.Fatalf("expected the original coordinates in the value, got %d bytes %v",
+			len(value), err)
+	}
+
+	// while the encoding of their altitudes is that of the simplified ones
+	var ring [][]float64
+	for i, pos := range shell {
+		ring = append(ring, []float64{pos[0], pos[1], float64(i % (len(shell) - 1))})
+	}
+	withAltitudes, err := ToGeoJSON(NewGeoJsonPolygon([][][]float64{ring}), GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape, err = ParseGeoJSONShapeWithOptions(withAltitudes,
+		ParseOptions{SimplifyTolerance: 100, KeepOriginal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := shape.(*Polygon).Coordinates(); len(got[0]) != len(ring) {
+		t.Fatalf("expected the %d original positions, got %d", len(ring), len(got[0]))
+	}
+	encoded, err := shape.(*Polygon).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded) > len(ring)*20 {
+		t.Fatalf("expected far less than %d bytes, got %d", len(ring)*20, len(encoded))
+	}
+	decoded := decodeShape(t, shape).(*Polygon)
+	if n := len(decoded.Vertices[0]); n != decoded.s2pgn.Loop(0).NumVertices()+1 {
+		t.Fatalf("expected the simplified positions, got %d", n)
+	}
+	for _, pos := range decoded.Vertices[0] {
+		if len(pos) != 3 {
+			t.Fatalf("expected the altitudes of the positions, got %v", pos)
+		}
+	}
+
+	// members of geometrycollections are simplified too
+	collection := []byte(`{"type":"GeometryCollection","geometries":[` +
+		string(input) + `,{"type":"Point","coordinates":[1,1]}]}`)
+	shape, err = ParseGeoJSONShapeWithOptions(collection,
+		ParseOptions{SimplifyTolerance: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := shape.(*GeometryCollection).Shapes
+	if n := members[0].(*Polygon).s2pgn.NumEdges(); n >= 500 {
+		t.Fatalf("expected fewer than 500 edges, got %d", n)
+	}
+
+	// as are the shapes built from coordinates
+	_, plain, err := NewGeoJsonShape([][][][]float64{{shell}}, PolygonType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape, value, err := NewGeoJsonShapeWithOptions([][][][]float64{{shell}},
+		PolygonType, ParseOptions{SimplifyTolerance: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) >= len(plain)/10 {
+		t.Fatalf("expected a much smaller encoding than %d bytes, got %d",
+			len(plain), len(value))
+	}
+	if _, err := Area(decodeShape(t, shape)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, meters := range []float64{-1, math.NaN()} {
+		_, err := ParseGeoJSONShapeWithOptions(input,
+			ParseOptions{SimplifyTolerance: meters})
+		if err == nil {
+			t.Fatalf("expected an error for a tolerance of %v", meters)
+		}
+	}
+}
+
+func TestSimplifyUnchanged(t *testing.T) {
+	shapes := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{1, 2}),
+		NewGeoCircle([]float64{1, 2}, "10km"),
+		NewGeoEnvelope([][]float64{{0, 1}, {1, 0}}),
+	}
+	for i, shape := range shapes {
+		simplified, err := Simplify(shape, 1000)
+		if err != nil || simplified != shape {
+			t.Fatalf("case %d: expected the shape as it is, got %v %v", i, simplified, err)
+		}
+	}
+}
+
+func TestSimplifyCollapsedShape(t *testing.T) {
+	// a tolerance larger than the shapes would leave nothing of them
+	square := testSquare(0, 1)
+	shapes := []index.GeoJSON{
+		NewGeoJsonPolygon([][][]float64{square}),
+		NewGeoJsonMultiPolygon([][][][]float64{{square}, {testSquare(2, 3)}}),
+	}
+	for i, shape := range shapes {
+		simplified, err := Simplify(shape, 3e6)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(simplified.(positionedShape).positions(),
+			shape.(positionedShape).positions()) {
+			t.Fatalf("case %d: expected the original coordinates, got %v",
+				i, simplified.(positionedShape).positions())
+		}
+		inner, cross := decodeShape(t, simplified).IndexCells()
+		if len(inner)+len(cross) == 0 {
+			t.Fatalf("case %d: expected the cells of the original shape", i)
+		}
+		ok, err := simplified.Intersects(NewGeoJsonPoint([]float64{0.5, 0.5}))
+		if err != nil || !ok {
+			t.Fatalf("case %d: expected the original shape, got %v %v", i, ok, err)
+		}
+	}
+}
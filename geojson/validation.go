@@ -0,0 +1,296 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/blevesearch/geo/s2"
+)
+
+// The kinds of problems reported by strict validation. Every error
+// returned by the Validate methods wraps one of these, so callers
+// can test for them with errors.Is and retrieve the position of the
+// problem with errors.As and *ValidationError.
+var (
+	ErrInvalidPosition    = errors.New("position must have at least two coordinates")
+	ErrInvalidCoordinate  = errors.New("coordinate is NaN or infinite")
+	ErrLatitudeOutOfRange = errors.New("latitude out of range [-90, 90]")
+	ErrTooFewPositions    = errors.New("too few positions")
+	ErrUnclosedRing       = errors.New("ring is not closed")
+	ErrSelfIntersection   = errors.New("self-intersection")
+)
+
+// ValidationError describes a problem found by strict validation
+// and where in the coordinates of the shape it was found. Indexes
+// that do not apply to the problem are -1.
+type ValidationError struct {
+	// Err is one of the Err* values above.
+	Err error
+
+	// Member is the index of the polygon in a multipolygon, of the
+	// linestring in a multilinestring or of the point in a multipoint.
+	Member int
+
+	// Ring is the index of the ring within its polygon.
+	Ring int
+
+	// Vertex is the index of the offending position within its ring
+	// or linestring. For a self-intersection it is the index of the
+	// first vertex of one of the crossing edges.
+	Vertex int
+
+	// OtherRing and OtherVertex identify the other edge involved in
+	// a self-intersection.
+	OtherRing   int
+	OtherVertex int
+}
+
+func newValidationError(err error) *ValidationError {
+	return &ValidationError{Err: err, Member: -1, Ring: -1, Vertex: -1,
+		OtherRing: -1, OtherVertex: -1}
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	if e.Member >= 0 {
+		parts = append(parts, fmt.Sprintf("member %d", e.Member))
+	}
+	if e.Ring >= 0 {
+		parts = append(parts, fmt.Sprintf("ring %d", e.Ring))
+	}
+	if e.Vertex >= 0 {
+		parts = append(parts, fmt.Sprintf("vertex %d", e.Vertex))
+	}
+	msg := e.Err.Error()
+	if e.OtherVertex >= 0 {
+		msg += fmt.Sprintf(" with ring %d vertex %d", e.OtherRing, e.OtherVertex)
+	}
+	if len(parts) == 0 {
+		return msg
+	}
+	return strings.Join(parts, " ") + ": " + msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// withMember sets the member index on a validation error.
+func withMember(err error, member int) error {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		verr.Member = member
+	}
+	return err
+}
+
+// validatePosition checks a single [lon, lat] position.
+func validatePosition(pos []float64, vertex int) error {
+	var err error
+	switch {
+	case len(pos) < 2:
+		err = ErrInvalidPosition
+	case math.IsNaN(pos[0]) || math.IsInf(pos[0], 0) ||
+		math.IsNaN(pos[1]) || math.IsInf(pos[1], 0):
+		err = ErrInvalidCoordinate
+	case pos[1] < -90 || pos[1] > 90:
+		err = ErrLatitudeOutOfRange
+	default:
+		return nil
+	}
+	verr := newValidationError(err)
+	verr.Vertex = vertex
+	return verr
+}
+
+// validateLine checks the positions of a linestring, which must be
+// empty or have at least two of them.
+func validateLine(positions [][]float64) error {
+	for i, pos := range positions {
+		if err := validatePosition(pos, i); err != nil {
+			return err
+		}
+	}
+	if len(positions) == 1 {
+		return newValidationError(ErrTooFewPositions)
+	}
+	return nil
+}
+
+// validateRing checks that a polygon ring has valid positions, at
+// least four of them, and that it is closed.
+func validateRing(ring [][]float64, ringIdx int) error {
+	for i, pos := range ring {
+		if err := validatePosition(pos, i); err != nil {
+			err.(*ValidationError).Ring = ringIdx
+			return err
+		}
+	}
+	if len(ring) < 4 {
+		verr := newValidationError(ErrTooFewPositions)
+		verr.Ring = ringIdx
+		return verr
+	}
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		verr := newValidationError(ErrUnclosedRing)
+		verr.Ring = ringIdx
+		verr.Vertex = len(ring) - 1
+		return verr
+	}
+	return nil
+}
+
+// validatePolygonRings checks every ring of a polygon and then looks
+// for edges that cross, repeated vertices within a ring and rings
+// that share an edge or cross each other at a vertex.
+func validatePolygonRings(rings [][][]float64) error {
+	for i, ring := range rings {
+		if err := validateRing(ring, i); err != nil {
+			return err
+		}
+	}
+	return findRingSelfIntersection(rings)
+}
+
+// findRingSelfIntersection reports the first crossing between the
+// edges of the given (already validated) rings.
+func findRingSelfIntersection(rings [][][]float64) error {
+	// vertexIDs maps the loop vertices back to the positions in the
+	// rings, since edges may be split, and repeated consecutive positions
+	// and the closing position are dropped.
+	loops := make([][]s2.Point, 0, len(rings))
+	vertexIDs := make([][]int, 0, len(rings))
+	for _, ring := range rings {
+		points, ids := dedupS2Points(s2PointsFromPositions(ring))
+		if len(points) > 1 && points[0] == points[len(points)-1] {
+			points = points[:len(points)-1]
+			ids = ids[:len(ids)-1]
+		}
+		loops = append(loops, points)
+		vertexIDs = append(vertexIDs, ids)
+	}
+
+	shape := s2.LaxPolygonFromPoints(loops)
+	idx := s2.NewShapeIndex()
+	idx.Add(shape)
+
+	var rv error
+	s2.VisitCrossingEdgePairs(idx, s2.CrossingTypeNonAdjacent,
+		func(a, b s2.ShapeEdge, isInterior bool) bool {
+			ap := shape.ChainPosition(int(a.ID.EdgeID))
+			bp := shape.ChainPosition(int(b.ID.EdgeID))
+			// a vertex repeated within a ring, or two rings sharing an
+			// edge or crossing each other at a vertex, make it invalid too
+			if !isInterior && s2.LoopsAtSharedVertex(shape, a, b) == s2.SharedVertexNone {
+				return true
+			}
+			verr := newValidationError(ErrSelfIntersection)
+			verr.Ring = ap.ChainID
+			verr.Vertex = vertexIDs[ap.ChainID][ap.Offset]
+			verr.OtherRing = bp.ChainID
+			verr.OtherVertex = vertexIDs[bp.ChainID][bp.Offset]
+			rv = verr
+			return false
+		})
+	return rv
+}
+
+// Validate checks that the point has a valid position, unless it is
+// empty.
+func (p *Point) Validate() error {
+	if len(p.Vertices) == 0 {
+		return nil
+	}
+	return validatePosition(p.Vertices, -1)
+}
+
+// Validate checks that every point of the multipoint has a valid
+// position.
+func (mp *MultiPoint) Validate() error {
+	for i, pos := range mp.Vertices {
+		if err := validatePosition(pos, -1); err != nil {
+			return withMember(err, i)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the linestring is empty or has at least two
+// positions, and that all of them are valid.
+func (ls *LineString) Validate() error {
+	return validateLine(ls.Vertices)
+}
+
+// Validate checks every linestring of the multilinestring.
+func (mls *MultiLineString) Validate() error {
+	for i, line := range mls.Vertices {
+		if err := validateLine(line); err != nil {
+			return withMember(err, i)
+		}
+	}
+	return nil
+}
+
+// Validate checks that every ring of the polygon is closed, has at
+// least four valid positions, and that the rings do not intersect
+// themselves or each other.
+func (pg *Polygon) Validate() error {
+	return validatePolygonRings(pg.Vertices)
+}
+
+// Validate checks every polygon of the multipolygon.
+func (mp *MultiPolygon) Validate() error {
+	for i, rings := range mp.Vertices {
+		if err := validatePolygonRings(rings); err != nil {
+			return withMember(err, i)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the circle has a valid center.
+func (c *Circle) Validate() error {
+	return validatePosition(c.Vertices, -1)
+}
+
+// Validate checks that the envelope has two valid corner positions.
+func (e *Envelope) Validate() error {
+	for i, pos := range e.Vertices {
+		if err := validatePosition(pos, i); err != nil {
+			return err
+		}
+	}
+	if len(e.Vertices) < 2 {
+		return newValidationError(ErrTooFewPositions)
+	}
+	return nil
+}
+
+// Validate checks every member shape of the geometry collection.
+func (gc *GeometryCollection) Validate() error {
+	for i, shape := range gc.Shapes {
+		if v, ok := shape.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("geometry %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseGeoJSONShapeStrict(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantErr    error
+		wantMember int
+		wantRing   int
+		wantVertex int
+	}{
+		// valid shapes
+		{`{"type": "point", "coordinates": [1, 1]}`, nil, -1, -1, -1},
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+			[[2, 2], [2, 8], [8, 8], [8, 2], [2, 2]]]}`, nil, -1, -1, -1},
+		// repeated consecutive positions are not a self-intersection
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 0], [10, 10], [0, 10], [0, 0]]]}`,
+			nil, -1, -1, -1},
+		{`{"type": "envelope", "coordinates": [[0, 1], [1, 0]]}`, nil, -1, -1, -1},
+
+		{`{"type": "point", "coordinates": [1]}`, ErrInvalidPosition, -1, -1, -1},
+		{`{"type": "point", "coordinates": [1, 91]}`, ErrLatitudeOutOfRange, -1, -1, -1},
+		{`{"type": "multipoint", "coordinates": [[1, 1], [1, -90.5]]}`, ErrLatitudeOutOfRange, 1, -1, -1},
+		{`{"type": "linestring", "coordinates": [[0, 0]]}`, ErrTooFewPositions, -1, -1, -1},
+		{`{"type": "multilinestring", "coordinates": [[[0, 0], [1, 1]], [[0, 0], [1, 100]]]}`,
+			ErrLatitudeOutOfRange, 1, -1, 1},
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10]]]}`,
+			ErrUnclosedRing, -1, 0, 3},
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [0, 0]]]}`,
+			ErrTooFewPositions, -1, 0, -1},
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+			[[2, 2], [2, 8], [8, 95], [8, 2], [2, 2]]]}`, ErrLatitudeOutOfRange, -1, 1, 2},
+		// a bowtie
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 10], [10, 0], [0, 10], [0, 0]]]}`,
+			ErrSelfIntersection, -1, 0, 0},
+		// a hole crossing its shell
+		{`{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+			[[5, 5], [5, 15], [8, 15], [8, 5], [5, 5]]]}`, ErrSelfIntersection, -1, 0, 2},
+		{`{"type": "multipolygon", "coordinates": [[[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]],
+			[[[20, 20], [30, 30], [30, 20], [20, 30], [20, 20]]]]}`, ErrSelfIntersection, 1, 0, 0},
+		{`{"type": "envelope", "coordinates": [[0, 1]]}`, ErrTooFewPositions, -1, -1, -1},
+		{`{"type": "circle", "coordinates": [0, -91], "radius": "10km"}`,
+			ErrLatitudeOutOfRange, -1, -1, -1},
+		{`{"type": "geometrycollection", "geometries": [{"type": "point", "coordinates": [1, 1]},
+			{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10]]]}]}`,
+			ErrUnclosedRing, -1, 0, 3},
+	}
+
+	for i, test := range tests {
+		shape, err := ParseGeoJSONShapeWithOptions([]byte(test.input), ParseOptions{Strict: true})
+		if test.wantErr == nil {
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if shape == nil {
+				t.Fatalf("case %d: expected a shape", i)
+			}
+			continue
+		}
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("case %d: expected error %v, got %v", i, test.wantErr, err)
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("case %d: expected a *ValidationError, got %T", i, err)
+		}
+		if verr.Member != test.wantMember || verr.Ring != test.wantRing ||
+			verr.Vertex != test.wantVertex {
+			t.Fatalf("case %d: expected member %d ring %d vertex %d, got %d %d %d (%v)",
+				i, test.wantMember, test.wantRing, test.wantVertex,
+				verr.Member, verr.Ring, verr.Vertex, err)
+		}
+	}
+}
+
+func TestParseGeoJSONShapeLenient(t *testing.T) {
+	// without strict validation, an unclosed ring is still accepted
+	input := `{"type": "polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10]]]}`
+	if _, err := ParseGeoJSONShape([]byte(input)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateNaNCoordinates(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		shape interface{ Validate() error }
+		ring  int
+	}{
+		{&Point{Vertices: []float64{nan, 0}}, -1},
+		{&LineString{Vertices: [][]float64{{0, 0}, {1, math.Inf(1)}}}, -1},
+		{&Polygon{Vertices: [][][]float64{{{0, 0}, {1, 0}, {1, nan}, {0, 0}}}}, 0},
+	}
+
+	for i, test := range tests {
+		err := test.shape.Validate()
+		if !errors.Is(err, ErrInvalidCoordinate) {
+			t.Fatalf("case %d: expected %v, got %v", i, ErrInvalidCoordinate, err)
+		}
+		var verr *ValidationError
+		if errors.As(err, &verr) && verr.Ring != test.ring {
+			t.Fatalf("case %d: expected ring %d, got %d", i, test.ring, verr.Ring)
+		}
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	pg := &Polygon{Vertices: [][][]float64{{{0, 0}, {10, 10}, {10, 0}, {0, 10}, {0, 0}}}}
+	err := pg.Validate()
+	want := "ring 0 vertex 0: self-intersection with ring 0 vertex 2"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,519 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// The OGC WKB geometry type codes.
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// The EWKB flags of the geometry type.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// The WKB byte order markers.
+const (
+	wkbBigEndian    = 0
+	wkbLittleEndian = 1
+)
+
+// ParseWKBShape decodes the OGC Well-Known Binary representation of
+// a shape, in either byte order, into the same shapes as
+// ParseGeoJSONShape.
+//
+// Both the ISO (1000, 2000 and 3000 type offsets) and the EWKB (type
+// flags) Z, M and ZM variants are accepted: Z ordinates are kept in the
+// coordinates of the shape and M ordinates are dropped. An EWKB SRID is
+// accepted if it is 4326. A point with NaN coordinates is empty, and
+// is dropped from the geometry collections as it cannot be encoded.
+func ParseWKBShape(data []byte) (index.GeoJSON, error) {
+	return ParseWKBShapeWithOptions(data, ParseOptions{})
+}
+
+// ParseWKBShapeWithOptions decodes the OGC Well-Known Binary
+// representation of a shape using the given options.
+func ParseWKBShapeWithOptions(data []byte, opts ParseOptions) (
+	index.GeoJSON, error) {
+	d := &wkbDecoder{data: data, opts: opts}
+	rv, err := d.geometry(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf("%d unexpected trailing bytes", len(d.data)-d.pos)
+	}
+	return rv, nil
+}
+
+// wkbDecoder decodes a WKB geometry.
+type wkbDecoder struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	opts  ParseOptions
+}
+
+func (d *wkbDecoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid WKB at offset %d: %s", d.pos,
+		fmt.Sprintf(format, args...))
+}
+
+func (d *wkbDecoder) readUint32() (uint32, error) {
+	if len(d.data)-d.pos < 4 {
+		return 0, d.errorf("unexpected end of data")
+	}
+	v := d.order.Uint32(d.data[d.pos:])
+	d.pos += 4
+	return v, nil
+}
+
+// count reads the number of elements that follow, each of them at
+// least minSize bytes long.
+func (d *wkbDecoder) count(minSize int) (int, error) {
+	n, err := d.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n)*uint64(minSize) > uint64(len(d.data)-d.pos) {
+		return 0, d.errorf("%d elements do not fit in the data", n)
+	}
+	return int(n), nil
+}
+
+// position reads a position with the given number of ordinates.
+func (d *wkbDecoder) position(dims int, hasZ bool) ([]float64, error) {
+	if len(d.data)-d.pos < 8*dims {
+		return nil, d.errorf("unexpected end of data")
+	}
+	pos := make([]float64, dims)
+	for i := range pos {
+		pos[i] = math.Float64frombits(d.order.Uint64(d.data[d.pos:]))
+		d.pos += 8
+	}
+	if hasZ {
+		return pos[:3], nil
+	}
+	return pos[:2], nil
+}
+
+func (d *wkbDecoder) positions(dims int, hasZ bool) ([][]float64, error) {
+	n, err := d.count(8 * dims)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([][]float64, 0, n)
+	for i := 0; i < n; i++ {
+		pos, err := d.position(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, pos)
+	}
+	return rv, nil
+}
+
+func (d *wkbDecoder) rings(dims int, hasZ bool) ([][][]float64, error) {
+	n, err := d.count(4)
+	if err != nil {
+		return nil, err
+	}
+	rv := make([][][]float64, 0, n)
+	for i := 0; i < n; i++ {
+		ring, err := d.positions(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, ring)
+	}
+	return rv, nil
+}
+
+// header reads the byte order, type, dimensions and optional SRID of a
+// geometry.
+func (d *wkbDecoder) header() (typ uint32, hasZ, hasM bool, err error) {
+	if d.pos >= len(d.data) {
+		return 0, false, false, d.errorf("unexpected end of data")
+	}
+	switch d.data[d.pos] {
+	case wkbBigEndian:
+		d.order = binary.BigEndian
+	case wkbLittleEndian:
+		d.order = binary.LittleEndian
+	default:
+		return 0, false, false, d.errorf("invalid byte order %d", d.data[d.pos])
+	}
+	d.pos++
+
+	typ, err = d.readUint32()
+	if err != nil {
+		return 0, false, false, err
+	}
+	hasZ = typ&ewkbZ != 0
+	hasM = typ&ewkbM != 0
+	if typ&ewkbSRID != 0 {
+		srid, err := d.readUint32()
+		if err != nil {
+			return 0, false, false, err
+		}
+		if srid != 4326 {
+			return 0, false, false, d.errorf("unsupported SRID %d", srid)
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+
+	switch typ / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	return typ % 1000, hasZ, hasM, nil
+}
+
+// geometry decodes a geometry, which must be of the given type unless
+// it is 0.
+func (d *wkbDecoder) geometry(want uint32) (index.GeoJSON, error) {
+	typ, hasZ, hasM, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	if want != 0 && typ != want {
+		return nil, d.errorf("expected geometry type %d, got %d", want, typ)
+	}
+	dims := 2
+	if hasZ {
+		dims++
+	}
+	if hasM {
+		dims++
+	}
+
+	switch typ {
+	case wkbPoint:
+		pos, err := d.position(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		rv := &Point{Typ: PointType}
+		if !math.IsNaN(pos[0]) || !math.IsNaN(pos[1]) {
+			rv.Vertices = pos
+		}
+		return d.opts.build(rv, rv.init)
+
+	case wkbLineString:
+		positions, err := d.positions(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		rv := &LineString{Typ: LineStringType, Vertices: positions}
+		return d.opts.build(rv, rv.init)
+
+	case wkbPolygon:
+		rings, err := d.rings(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		rv := &Polygon{Typ: PolygonType, Vertices: rings}
+		return d.opts.build(rv, rv.init)
+
+	case wkbMultiPoint:
+		n, err := d.count(5)
+		if err != nil {
+			return nil, err
+		}
+		rv := &MultiPoint{Typ: MultiPointType, Vertices: make([][]float64, 0, n)}
+		for i := 0; i < n; i++ {
+			p, err := d.geometry(wkbPoint)
+			if err != nil {
+				return nil, err
+			}
+			if v := p.(*Point).Vertices; v != nil {
+				rv.Vertices = append(rv.Vertices, v)
+			}
+		}
+		return d.opts.build(rv, rv.init)
+
+	case wkbMultiLineString:
+		n, err := d.count(5)
+		if err != nil {
+			return nil, err
+		}
+		rv := &MultiLineString{Typ: MultiLineStringType,
+			Vertices: make([][][]float64, 0, n)}
+		for i := 0; i < n; i++ {
+			ls, err := d.geometry(wkbLineString)
+			if err != nil {
+				return nil, err
+			}
+			rv.Vertices = append(rv.Vertices, ls.(*LineString).Vertices)
+		}
+		return d.opts.build(rv, rv.init)
+
+	case wkbMultiPolygon:
+		n, err := d.count(5)
+		if err != nil {
+			return nil, err
+		}
+		rv := &MultiPolygon{Typ: MultiPolygonType,
+			Vertices: make([][][][]float64, 0, n)}
+		for i := 0; i < n; i++ {
+			pg, err := d.geometry(wkbPolygon)
+			if err != nil {
+				return nil, err
+			}
+			rv.Vertices = append(rv.Vertices, pg.(*Polygon).Vertices)
+		}
+		return d.opts.build(rv, rv.init)
+
+	case wkbGeometryCollection:
+		n, err := d.count(5)
+		if err != nil {
+			return nil, err
+		}
+		rv := &GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: make([]index.GeoJSON, 0, n)}
+		for i := 0; i < n; i++ {
+			shape, err := d.geometry(0)
+			if err != nil {
+				return nil, err
+			}
+			if !isEmptyPoint(shape) {
+				rv.Shapes = append(rv.Shapes, shape)
+			}
+		}
+		return rv, nil
+	}
+
+	return nil, d.errorf("unknown geometry type %d", typ)
+}
+
+// WKBOptions controls the output of ToWKB.
+type WKBOptions struct {
+	// ByteOrder is the byte order of the output, binary.LittleEndian
+	// by default.
+	ByteOrder binary.ByteOrder
+
+	// SRID, if not zero, is written in the EWKB format.
+	SRID uint32
+}
+
+// ToWKB returns the OGC Well-Known Binary representation of the given
+// shape. Like ToGeoJSON it works from the shape's s2 representation.
+// Envelopes are written as polygons, with extra vertices along their
+// parallels when they are wide; circles have no WKB representation.
+// Geometries whose positions all have altitudes are written with the
+// EWKB Z flag.
+func ToWKB(shape index.GeoJSON, opts WKBOptions) ([]byte, error) {
+	obj, err := geoJSONObject(shape)
+	if err != nil {
+		return nil, err
+	}
+	addExtraOrdinates(obj, shape)
+	e := &wkbEncoder{order: opts.ByteOrder, srid: opts.SRID}
+	if e.order == nil {
+		e.order = binary.LittleEndian
+	}
+	err = e.geometry(obj)
+	if err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// wkbEncoder encodes WKB geometries.
+type wkbEncoder struct {
+	buf   []byte
+	order binary.ByteOrder
+	// srid is written in the header of the next geometry only.
+	srid uint32
+	// dims is the number of ordinates of the positions of the current
+	// geometry, 2 or 3.
+	dims int
+}
+
+func (e *wkbEncoder) uint32(v uint32) {
+	var b [4]byte
+	e.order.PutUint32(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *wkbEncoder) header(typ uint32) {
+	if e.order == binary.BigEndian {
+		e.buf = append(e.buf, wkbBigEndian)
+	} else {
+		e.buf = append(e.buf, wkbLittleEndian)
+	}
+	if e.dims == 3 {
+		typ |= ewkbZ
+	}
+	if e.srid != 0 {
+		e.uint32(typ | ewkbSRID)
+		e.uint32(e.srid)
+		e.srid = 0
+		return
+	}
+	e.uint32(typ)
+}
+
+func (e *wkbEncoder) position(pos []float64) {
+	var b [8]byte
+	for _, v := range pos[:e.dims] {
+		e.order.PutUint64(b[:], math.Float64bits(v))
+		e.buf = append(e.buf, b[:]...)
+	}
+}
+
+func (e *wkbEncoder) positions(positions [][]float64) {
+	e.uint32(uint32(len(positions)))
+	for _, pos := range positions {
+		e.position(pos)
+	}
+}
+
+func (e *wkbEncoder) rings(rings [][][]float64) {
+	e.uint32(uint32(len(rings)))
+	for _, ring := range rings {
+		e.positions(ring)
+	}
+}
+
+func (e *wkbEncoder) geometry(obj interface{}) error {
+	switch g := obj.(type) {
+	case *geoJSONGeometry:
+		e.dims = wktDimensions(g.Coordinates)
+		switch g.Typ {
+		case "Point":
+			e.header(wkbPoint)
+			pos := g.Coordinates.([]float64)
+			if len(pos) == 0 {
+				pos = []float64{math.NaN(), math.NaN()}
+			}
+			e.position(pos)
+
+		case "MultiPoint":
+			points := g.Coordinates.([][]float64)
+			e.header(wkbMultiPoint)
+			e.uint32(uint32(len(points)))
+			for _, pos := range points {
+				e.header(wkbPoint)
+				e.position(pos)
+			}
+
+		case "LineString":
+			e.header(wkbLineString)
+			e.positions(g.Coordinates.([][]float64))
+
+		case "MultiLineString":
+			lines := g.Coordinates.([][][]float64)
+			e.header(wkbMultiLineString)
+			e.uint32(uint32(len(lines)))
+			for _, line := range lines {
+				e.header(wkbLineString)
+				e.positions(line)
+			}
+
+		case "Polygon":
+			e.header(wkbPolygon)
+			e.rings(g.Coordinates.([][][]float64))
+
+		case "MultiPolygon":
+			polygons := g.Coordinates.([][][][]float64)
+			e.header(wkbMultiPolygon)
+			e.uint32(uint32(len(polygons)))
+			for _, rings := range polygons {
+				e.header(wkbPolygon)
+				e.rings(rings)
+			}
+
+		case "Envelope":
+			corners := g.Coordinates.([][]float64)
+			west, north := corners[0][0], corners[0][1]
+			east, south := corners[1][0], corners[1][1]
+			e.dims = 2
+			e.header(wkbPolygon)
+			e.rings([][][]float64{envelopeRing(west, east, south, north)})
+
+		default:
+			return fmt.Errorf("no WKB representation for shape type: %s", g.Typ)
+		}
+		return nil
+
+	case *geoJSONGeometryCollection:
+		e.dims = 2
+		e.header(wkbGeometryCollection)
+		e.uint32(uint32(len(g.Geometries)))
+		for _, member := range g.Geometries {
+			if err := e.geometry(member); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported shape: %T", obj)
+}
+
+// envelopeRing returns the ring of the polygon covering an envelope.
+// WKB edges are geodesics that go the short way round, so the edges
+// along the parallels are split into steps of at most 90 degrees of
+// longitude; otherwise an envelope wider than 180 degrees would come
+// back as its complement.
+func envelopeRing(west, east, south, north float64) [][]float64 {
+	span := east - west
+	if span < 0 {
+		span += 360
+	}
+	n := int(math.Ceil(span / 90))
+	if n < 1 {
+		n = 1
+	}
+	lng := func(k int) float64 {
+		if k == n {
+			return east
+		}
+		v := west + span*float64(k)/float64(n)
+		if v > 180 {
+			v -= 360
+		}
+		return v
+	}
+	ring := make([][]float64, 0, 2*n+3)
+	for k := 0; k <= n; k++ {
+		ring = append(ring, []float64{lng(k), south})
+	}
+	for k := n; k >= 0; k-- {
+		ring = append(ring, []float64{lng(k), north})
+	}
+	return append(ring, []float64{west, south})
+}
@@ -0,0 +1,299 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestParseWKBShape(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantType     string
+		wantVertices interface{}
+	}{
+		// POINT (1 2), little and big endian
+		{"0101000000000000000000f03f0000000000000040", PointType, []float64{1, 2}},
+		{"00000000013ff00000000000004000000000000000", PointType, []float64{1, 2}},
+		// POINT Z (1 2 3), ISO and EWKB
+		{"01e9030000000000000000f03f00000000000000400000000000000840",
+			PointType, []float64{1, 2, 3}},
+		{"0101000080000000000000f03f00000000000000400000000000000840",
+			PointType, []float64{1, 2, 3}},
+		// POINT M (1 2 3)
+		{"01d1070000000000000000f03f00000000000000400000000000000840",
+			PointType, []float64{1, 2}},
+		// SRID=4326;POINT (1 2)
+		{"0101000020e6100000000000000000f03f0000000000000040", PointType, []float64{1, 2}},
+		// POINT EMPTY
+		{"0101000000000000000000f87f000000000000f87f", PointType, []float64(nil)},
+		// LINESTRING (1 2, 3 4)
+		{"010200000002000000000000000000f03f000000000000004000000000000008400000000000001040",
+			LineStringType, [][]float64{{1, 2}, {3, 4}}},
+		// POLYGON ((0 0, 1 0, 1 1, 0 0))
+		{"01030000000100000004000000" +
+			"00000000000000000000000000000000" +
+			"000000000000f03f0000000000000000" +
+			"000000000000f03f000000000000f03f" +
+			"00000000000000000000000000000000",
+			PolygonType, [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+		// MULTIPOINT ((1 2), (3 4)), with a big endian member
+		{"010400000002000000" +
+			"0101000000000000000000f03f0000000000000040" +
+			"000000000140080000000000004010000000000000",
+			MultiPointType, [][]float64{{1, 2}, {3, 4}}},
+	}
+
+	for i, test := range tests {
+		data, err := hex.DecodeString(test.input)
+		if err != nil {
+			t.Fatalf("case %d: bad test input: %v", i, err)
+		}
+		shape, err := ParseWKBShape(data)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if shape.Type() != test.wantType {
+			t.Fatalf("case %d: expected type %s, got %s", i, test.wantType, shape.Type())
+		}
+		vertices := reflect.ValueOf(shape).Elem().FieldByName("Vertices").Interface()
+		if !reflect.DeepEqual(vertices, test.wantVertices) {
+			t.Fatalf("case %d: expected vertices %v, got %v", i, test.wantVertices, vertices)
+		}
+	}
+}
+
+func TestParseWKBGeometryCollection(t *testing.T) {
+	// GEOMETRYCOLLECTION (POINT EMPTY, POINT (1 2)), whose empty point is
+	// dropped so that the collection can be encoded
+	data, err := hex.DecodeString("010700000002000000" +
+		"0101000000000000000000f87f000000000000f87f" +
+		"0101000000000000000000f03f0000000000000040")
+	if err != nil {
+		t.Fatalf("bad test input: %v", err)
+	}
+	shape, err := ParseWKBShape(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gc, ok := shape.(*GeometryCollection)
+	if !ok || len(gc.Shapes) != 1 || gc.Shapes[0].Type() != PointType {
+		t.Fatalf("expected a collection of one point, got %v", shape)
+	}
+	if _, err := gc.Marshal(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseWKBShapeErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"02",
+		"0101000000000000000000f03f",
+		"0101000000000000000000f03f000000000000004000",
+		"0108000000",
+		// SRID=3857;POINT (1 2)
+		"0101000020110f0000000000000000f03f0000000000000040",
+		// LINESTRING with a count larger than the data
+		"0102000000ffffffff",
+		// MULTIPOINT holding a linestring
+		"010400000001000000010200000000000000",
+	}
+
+	for i, test := range tests {
+		data, err := hex.DecodeString(test)
+		if err != nil {
+			t.Fatalf("case %d: bad test input: %v", i, err)
+		}
+		if _, err := ParseWKBShape(data); err == nil {
+			t.Fatalf("case %d: expected an error for %s", i, test)
+		}
+	}
+}
+
+func TestParseWKBShapeStrict(t *testing.T) {
+	shape, err := ParseWKTShape("POLYGON ((0 0, 10 10, 10 0, 0 10, 0 0))")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := ToWKB(shape, WKBOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = ParseWKBShapeWithOptions(data, ParseOptions{Strict: true})
+	if !errors.Is(err, ErrSelfIntersection) {
+		t.Fatalf("expected %v, got %v", ErrSelfIntersection, err)
+	}
+}
+
+func TestToWKB(t *testing.T) {
+	got, err := ToWKB(NewGeoJsonPoint([]float64{1, 2}), WKBOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "0101000000000000000000f03f0000000000000040"; hex.EncodeToString(got) != want {
+		t.Fatalf("expected %s, got %x", want, got)
+	}
+
+	got, err = ToWKB(NewGeoJsonPoint([]float64{1, 2}),
+		WKBOptions{ByteOrder: binary.BigEndian, SRID: 4326})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "0020000001000010e63ff00000000000004000000000000000"; hex.EncodeToString(got) != want {
+		t.Fatalf("expected %s, got %x", want, got)
+	}
+
+	got, err = ToWKB(NewGeoJsonPoint([]float64{1, 2, 3}), WKBOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "0101000080000000000000f03f00000000000000400000000000000840"; hex.EncodeToString(got) != want {
+		t.Fatalf("expected %s, got %x", want, got)
+	}
+
+	if _, err := ToWKB(NewGeoCircle([]float64{1, 2}, "1km"), WKBOptions{}); err == nil {
+		t.Fatal("expected an error encoding a circle")
+	}
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+	tests := []index.GeoJSON{
+		NewGeoJsonPoint([]float64{30, 10}),
+		&Point{Typ: PointType},
+		NewGeoJsonMultiPoint([][]float64{{10, 40}, {40, 30}}),
+		NewGeoJsonLinestring([][]float64{{30, 10}, {10, 30}}),
+		NewGeoJsonMultilinestring([][][]float64{{{10, 10}, {20, 20}}, {{40, 40}, {30, 30}}}),
+		NewGeoJsonPolygon([][][]float64{testSquare(0, 10),
+			{{2, 2}, {2, 8}, {8, 8}, {8, 2}, {2, 2}}}),
+		NewGeoJsonMultiPolygon([][][][]float64{{testSquare(0, 10)}, {testSquare(40, 50)}}),
+		&GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{NewGeoJsonPoint([]float64{1, 2}),
+				NewGeoJsonLinestring([][]float64{{0, 0}, {3, 3}})}},
+		NewGeoJsonPoint([]float64{1, 2, 30}),
+		NewGeoJsonMultiPoint([][]float64{{10, 40, 1}, {40, 30, 2}}),
+		NewGeoJsonLinestring([][]float64{{30, 10, 5}, {10, 30, 6}}),
+		NewGeoJsonPolygon([][][]float64{{{0, 0, 1}, {10, 0, 2}, {10, 10, 3}, {0, 10, 4}, {0, 0, 1}}}),
+		&GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{NewGeoJsonPoint([]float64{1, 2, 3}),
+				NewGeoJsonLinestring([][]float64{{0, 0}, {3, 3}})}},
+	}
+
+	for i, test := range tests {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			data, err := ToWKB(test, WKBOptions{ByteOrder: order, SRID: 4326})
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			shape, err := ParseWKBShape(data)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if shape.Type() != test.Type() {
+				t.Fatalf("case %d: expected type %s, got %s", i, test.Type(), shape.Type())
+			}
+			want, err := ToWKT(test)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			got, err := ToWKT(shape)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if got != want {
+				t.Fatalf("case %d: expected %s, got %s", i, want, got)
+			}
+		}
+	}
+}
+
+func TestWKBEnvelopeRoundTrip(t *testing.T) {
+	tests := []struct {
+		corners [][]float64
+		inside  []float64
+		outside []float64
+	}{
+		{[][]float64{{-170, 10}, {170, -10}}, []float64{0, 0}, []float64{180, 0}},
+		{[][]float64{{170, 10}, {-170, -10}}, []float64{180, 0}, []float64{0, 0}},
+		{[][]float64{{-180, 80}, {180, -80}}, []float64{90, 0}, []float64{0, 85}},
+		{[][]float64{{100, 40}, {90, 20}}, []float64{-80, 30}, []float64{95, 30}},
+	}
+
+	for i, test := range tests {
+		env := NewGeoEnvelope(test.corners)
+		data, err := ToWKB(env, WKBOptions{})
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		shape, err := ParseWKBShape(data)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		want, err := Area(env)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		got, err := Area(shape)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		// the parallels of the envelope become geodesics, which bulge
+		// towards the poles; the complement would be several times larger
+		if math.Abs(got-want) > 0.25*want {
+			t.Fatalf("case %d: expected an area of %g, got %g", i, want, got)
+		}
+		if ok, err := shape.Intersects(NewGeoJsonPoint(test.inside)); err != nil || !ok {
+			t.Fatalf("case %d: expected the polygon to contain %v, got %v %v",
+				i, test.inside, ok, err)
+		}
+		if ok, err := shape.Intersects(NewGeoJsonPoint(test.outside)); err != nil || ok {
+			t.Fatalf("case %d: expected the polygon not to contain %v, got %v %v",
+				i, test.outside, ok, err)
+		}
+	}
+}
+
+func TestWKBDecodedShapes(t *testing.T) {
+	// the shapes returned by ExtractShapesFromBytes only have their s2
+	// representation
+	env := decodeShape(t, NewGeoEnvelope([][]float64{{0, 20}, {20, 0}}))
+	data, err := ToWKB(env, WKBOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	shape, err := ParseWKBShape(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][][]float64{{{0, 0}, {20, 0}, {20, 20}, {0, 20}, {0, 0}}}
+	if pg, ok := shape.(*Polygon); !ok || !reflect.DeepEqual(pg.Vertices, want) {
+		t.Fatalf("expected the polygon %v, got %v", want, shape)
+	}
+
+	inner, cross := shape.IndexCells()
+	if len(inner)+len(cross) == 0 {
+		t.Fatal("expected the decoded polygon to be indexable")
+	}
+	if ok, err := shape.Intersects(NewGeoJsonPoint([]float64{10, 10})); err != nil || !ok {
+		t.Fatalf("expected the polygon to intersect its center, got %v %v", ok, err)
+	}
+}
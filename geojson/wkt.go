@@ -0,0 +1,675 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// ParseWKTShape parses the Well-Known Text representation of a shape,
+// from POINT to GEOMETRYCOLLECTION including the EMPTY variants, into
+// the same shapes as ParseGeoJSONShape.
+//
+// The Z, M and ZM variants are accepted: Z ordinates are kept in the
+// coordinates of the shape and M ordinates are dropped. An EWKT SRID
+// prefix is accepted if it is 4326. Circles and envelopes are read from
+// the Spatial4j extensions BUFFER(POINT(x y), d), with d in degrees, and
+// ENVELOPE(minX, maxX, maxY, minY). POINT EMPTY is dropped from the
+// geometry collections, as an empty point cannot be encoded.
+func ParseWKTShape(input string) (index.GeoJSON, error) {
+	return ParseWKTShapeWithOptions(input, ParseOptions{})
+}
+
+// ParseWKTShapeWithOptions parses the Well-Known Text representation of
+// a shape using the given options.
+func ParseWKTShapeWithOptions(input string, opts ParseOptions) (
+	index.GeoJSON, error) {
+	p := &wktParser{input: input, opts: opts}
+
+	if err := p.srid(); err != nil {
+		return nil, err
+	}
+	rv, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	return rv, nil
+}
+
+// wktParser is a recursive descent parser for WKT.
+type wktParser struct {
+	input string
+	pos   int
+	opts  ParseOptions
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid WKT at offset %d: %s", p.pos,
+		fmt.Sprintf(format, args...))
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next non space character, or 0 at the end of the
+// input.
+func (p *wktParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// peekWord returns the next word in upper case without consuming it.
+func (p *wktParser) peekWord() (string, int) {
+	p.skipSpace()
+	end := p.pos
+	for end < len(p.input) {
+		c := p.input[end]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			break
+		}
+		end++
+	}
+	return strings.ToUpper(p.input[p.pos:end]), end
+}
+
+// word consumes and returns the next word in upper case.
+func (p *wktParser) word() string {
+	w, end := p.peekWord()
+	p.pos = end
+	return w
+}
+
+// empty consumes the EMPTY keyword if it comes next.
+func (p *wktParser) empty() bool {
+	w, end := p.peekWord()
+	if w == "EMPTY" {
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpace()
+	end := p.pos
+	for end < len(p.input) {
+		c := p.input[end]
+		if (c < '0' || c > '9') && c != '.' && c != '-' && c != '+' &&
+			c != 'e' && c != 'E' {
+			break
+		}
+		end++
+	}
+	v, err := strconv.ParseFloat(p.input[p.pos:end], 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", p.input[p.pos:end])
+	}
+	p.pos = end
+	return v, nil
+}
+
+// srid consumes an optional EWKT SRID prefix.
+func (p *wktParser) srid() error {
+	p.skipSpace()
+	if !strings.HasPrefix(strings.ToUpper(p.input[p.pos:]), "SRID=") {
+		return nil
+	}
+	p.pos += len("SRID=")
+	end := strings.IndexByte(p.input[p.pos:], ';')
+	if end < 0 {
+		return p.errorf("expected %q", ';')
+	}
+	srid, err := strconv.Atoi(strings.TrimSpace(p.input[p.pos : p.pos+end]))
+	if err != nil {
+		return p.errorf("invalid SRID %q", p.input[p.pos:p.pos+end])
+	}
+	if srid != 4326 {
+		return p.errorf("unsupported SRID %d", srid)
+	}
+	p.pos += end + 1
+	return nil
+}
+
+// dimension consumes the optional Z, M or ZM tag of a geometry and
+// reports whether its positions have an M ordinate.
+func (p *wktParser) dimension() bool {
+	w, end := p.peekWord()
+	switch w {
+	case "Z":
+		p.pos = end
+	case "M", "ZM":
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+// position parses the ordinates of a single position.
+func (p *wktParser) position(hasM bool) ([]float64, error) {
+	var pos []float64
+	for {
+		c := p.peek()
+		if c == ',' || c == ')' || c == 0 {
+			break
+		}
+		v, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		pos = append(pos, v)
+	}
+	if len(pos) < 2 || len(pos) > 4 {
+		return nil, p.errorf("invalid position with %d ordinates", len(pos))
+	}
+	if len(pos) == 4 || (hasM && len(pos) == 3) {
+		pos = pos[:len(pos)-1]
+	}
+	return pos, nil
+}
+
+// positions parses a parenthesized list of positions, or EMPTY.
+func (p *wktParser) positions(hasM bool) ([][]float64, error) {
+	rv := [][]float64{}
+	if p.empty() {
+		return rv, nil
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	for {
+		pos, err := p.position(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, pos)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return rv, p.expect(')')
+}
+
+// rings parses a parenthesized list of position lists, or EMPTY.
+func (p *wktParser) rings(hasM bool) ([][][]float64, error) {
+	rv := [][][]float64{}
+	if p.empty() {
+		return rv, nil
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	for {
+		ring, err := p.positions(hasM)
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) > 0 {
+			rv = append(rv, ring)
+		}
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return rv, p.expect(')')
+}
+
+// point parses the parenthesized position of a point, or EMPTY, for
+// which it returns nil.
+func (p *wktParser) point(hasM bool) ([]float64, error) {
+	if p.empty() {
+		return nil, nil
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	pos, err := p.position(hasM)
+	if err != nil {
+		return nil, err
+	}
+	return pos, p.expect(')')
+}
+
+// multiPoints parses the points of a multipoint, which may or may not
+// be parenthesized individually.
+func (p *wktParser) multiPoints(hasM bool) ([][]float64, error) {
+	rv := [][]float64{}
+	if p.empty() {
+		return rv, nil
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.empty():
+		case p.peek() == '(':
+			p.pos++
+			pos, err := p.position(hasM)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(')'); err != nil {
+				return nil, err
+			}
+			rv = append(rv, pos)
+		default:
+			pos, err := p.position(hasM)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, pos)
+		}
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return rv, p.expect(')')
+}
+
+func (p *wktParser) geometry() (index.GeoJSON, error) {
+	typ := p.word()
+	if typ == "" {
+		return nil, p.errorf("expected a geometry type")
+	}
+	hasM := p.dimension()
+
+	switch typ {
+	case "POINT":
+		pos, err := p.point(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv := &Point{Typ: PointType, Vertices: pos}
+		return p.opts.build(rv, rv.init)
+
+	case "MULTIPOINT":
+		points, err := p.multiPoints(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv := &MultiPoint{Typ: MultiPointType, Vertices: points}
+		return p.opts.build(rv, rv.init)
+
+	case "LINESTRING":
+		positions, err := p.positions(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv := &LineString{Typ: LineStringType, Vertices: positions}
+		return p.opts.build(rv, rv.init)
+
+	case "MULTILINESTRING":
+		lines, err := p.rings(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv := &MultiLineString{Typ: MultiLineStringType, Vertices: lines}
+		return p.opts.build(rv, rv.init)
+
+	case "POLYGON":
+		rings, err := p.rings(hasM)
+		if err != nil {
+			return nil, err
+		}
+		rv := &Polygon{Typ: PolygonType, Vertices: rings}
+		return p.opts.build(rv, rv.init)
+
+	case "MULTIPOLYGON":
+		polygons := [][][][]float64{}
+		if !p.empty() {
+			if err := p.expect('('); err != nil {
+				return nil, err
+			}
+			for {
+				rings, err := p.rings(hasM)
+				if err != nil {
+					return nil, err
+				}
+				if len(rings) > 0 {
+					polygons = append(polygons, rings)
+				}
+				if p.peek() != ',' {
+					break
+				}
+				p.pos++
+			}
+			if err := p.expect(')'); err != nil {
+				return nil, err
+			}
+		}
+		rv := &MultiPolygon{Typ: MultiPolygonType, Vertices: polygons}
+		return p.opts.build(rv, rv.init)
+
+	case "GEOMETRYCOLLECTION":
+		rv := &GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{}}
+		if p.empty() {
+			return rv, nil
+		}
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		for {
+			shape, err := p.geometry()
+			if err != nil {
+				return nil, err
+			}
+			if !isEmptyPoint(shape) {
+				rv.Shapes = append(rv.Shapes, shape)
+			}
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		return rv, p.expect(')')
+
+	case "ENVELOPE":
+		var bounds [4]float64
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		for i := range bounds {
+			if i > 0 {
+				if err := p.expect(','); err != nil {
+					return nil, err
+				}
+			}
+			v, err := p.number()
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = v
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		rv := &Envelope{Typ: EnvelopeType, Vertices: [][]float64{
+			{bounds[0], bounds[2]}, {bounds[1], bounds[3]}}}
+		return p.opts.build(rv, rv.init)
+
+	case "BUFFER":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		// the center is built into the circle rather than into a point,
+		// so that it is unprojected from the CRS of the options only once
+		if p.word() != "POINT" {
+			return nil, p.errorf("expected a POINT to buffer")
+		}
+		center, err := p.point(p.dimension())
+		if err != nil {
+			return nil, err
+		}
+		if len(center) < 2 {
+			return nil, p.errorf("expected a non empty POINT to buffer")
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		degrees, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		meters := degrees * math.Pi / 180 * earthRadiusInMeter
+		rv := &Circle{Typ: CircleType, Vertices: center,
+			Radius:         strconv.FormatFloat(meters, 'f', -1, 64) + "m",
+			radiusInMeters: meters}
+		return p.opts.build(rv, rv.init)
+	}
+
+	return nil, p.errorf("unknown geometry type: %s", typ)
+}
+
+// ToWKT returns the Well-Known Text representation of the given shape.
+// Like ToGeoJSON it works from the shape's s2 representation. Circles
+// and envelopes are written with the BUFFER and ENVELOPE extensions
+// read by ParseWKTShape. Geometries whose positions all have altitudes
+// are written with the Z tag.
+func ToWKT(shape index.GeoJSON) (string, error) {
+	obj, err := geoJSONObject(shape)
+	if err != nil {
+		return "", err
+	}
+	addExtraOrdinates(obj, shape)
+	var b strings.Builder
+	err = writeWKT(&b, obj)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeWKT(b *strings.Builder, obj interface{}) error {
+	switch g := obj.(type) {
+	case *geoJSONGeometry:
+		dims := wktDimensions(g.Coordinates)
+		tag := " "
+		if dims == 3 {
+			tag = " Z "
+		}
+		switch g.Typ {
+		case "Point":
+			pos := g.Coordinates.([]float64)
+			b.WriteString("POINT" + tag)
+			if len(pos) == 0 {
+				b.WriteString("EMPTY")
+				return nil
+			}
+			writeWKTPositions(b, [][]float64{pos}, dims)
+
+		case "MultiPoint":
+			b.WriteString("MULTIPOINT" + tag)
+			points := g.Coordinates.([][]float64)
+			if len(points) == 0 {
+				b.WriteString("EMPTY")
+				return nil
+			}
+			b.WriteByte('(')
+			for i, pos := range points {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				writeWKTPositions(b, [][]float64{pos}, dims)
+			}
+			b.WriteByte(')')
+
+		case "LineString":
+			b.WriteString("LINESTRING" + tag)
+			writeWKTPositions(b, g.Coordinates.([][]float64), dims)
+
+		case "MultiLineString":
+			b.WriteString("MULTILINESTRING" + tag)
+			writeWKTRings(b, g.Coordinates.([][][]float64), dims)
+
+		case "Polygon":
+			b.WriteString("POLYGON" + tag)
+			writeWKTRings(b, g.Coordinates.([][][]float64), dims)
+
+		case "MultiPolygon":
+			b.WriteString("MULTIPOLYGON" + tag)
+			polygons := g.Coordinates.([][][][]float64)
+			if len(polygons) == 0 {
+				b.WriteString("EMPTY")
+				return nil
+			}
+			b.WriteByte('(')
+			for i, rings := range polygons {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				writeWKTRings(b, rings, dims)
+			}
+			b.WriteByte(')')
+
+		case "Circle":
+			meters, err := ParseDistance(g.Radius)
+			if err != nil {
+				return err
+			}
+			b.WriteString("BUFFER (POINT" + tag)
+			writeWKTPositions(b, [][]float64{g.Coordinates.([]float64)}, dims)
+			b.WriteString(", ")
+			b.WriteString(formatWKTNumber(meters / earthRadiusInMeter * 180 / math.Pi))
+			b.WriteByte(')')
+
+		case "Envelope":
+			corners := g.Coordinates.([][]float64)
+			fmt.Fprintf(b, "ENVELOPE (%s, %s, %s, %s)",
+				formatWKTNumber(corners[0][0]), formatWKTNumber(corners[1][0]),
+				formatWKTNumber(corners[0][1]), formatWKTNumber(corners[1][1]))
+
+		default:
+			return fmt.Errorf("unsupported shape type: %s", g.Typ)
+		}
+		return nil
+
+	case *geoJSONGeometryCollection:
+		b.WriteString("GEOMETRYCOLLECTION ")
+		if len(g.Geometries) == 0 {
+			b.WriteString("EMPTY")
+			return nil
+		}
+		b.WriteByte('(')
+		for i, member := range g.Geometries {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := writeWKT(b, member); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return nil
+	}
+
+	return fmt.Errorf("unsupported shape: %T", obj)
+}
+
+// isEmptyPoint returns whether the shape is an empty point, e.g. POINT
+// EMPTY, which is dropped from the collections as it cannot be encoded.
+func isEmptyPoint(shape index.GeoJSON) bool {
+	p, ok := shape.(*Point)
+	return ok && len(p.Vertices) < 2
+}
+
+// wktDimensions returns 3 when every position of the coordinates has
+// an altitude, and 2 otherwise. WKT has no room for the ordinates that
+// come after the altitude.
+func wktDimensions(coords interface{}) int {
+	var positions [][]float64
+	switch c := coords.(type) {
+	case []float64:
+		positions = [][]float64{c}
+	case [][]float64:
+		positions = c
+	case [][][]float64:
+		for _, ring := range c {
+			positions = append(positions, ring...)
+		}
+	case [][][][]float64:
+		for _, polygon := range c {
+			for _, ring := range polygon {
+				positions = append(positions, ring...)
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return 2
+	}
+	for _, pos := range positions {
+		if len(pos) < 3 {
+			return 2
+		}
+	}
+	return 3
+}
+
+// writeWKTPositions writes a parenthesized list of positions with the
+// given number of ordinates, or EMPTY.
+func writeWKTPositions(b *strings.Builder, positions [][]float64, dims int) {
+	if len(positions) == 0 {
+		b.WriteString("EMPTY")
+		return
+	}
+	b.WriteByte('(')
+	for i, pos := range positions {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		for j, v := range pos[:min(dims, len(pos))] {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(formatWKTNumber(v))
+		}
+	}
+	b.WriteByte(')')
+}
+
+// writeWKTRings writes a parenthesized list of position lists, or EMPTY.
+func writeWKTRings(b *strings.Builder, rings [][][]float64, dims int) {
+	if len(rings) == 0 {
+		b.WriteString("EMPTY")
+		return
+	}
+	b.WriteByte('(')
+	for i, ring := range rings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeWKTPositions(b, ring, dims)
+	}
+	b.WriteByte(')')
+}
+
+func formatWKTNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
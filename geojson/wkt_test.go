@@ -0,0 +1,288 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geojson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestParseWKTShape(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantType     string
+		wantVertices interface{}
+	}{
+		{"POINT (30 10)", PointType, []float64{30, 10}},
+		{"point(30 10)", PointType, []float64{30, 10}},
+		{"POINT Z (30 10 5)", PointType, []float64{30, 10, 5}},
+		{"POINT M (30 10 5)", PointType, []float64{30, 10}},
+		{"POINT ZM (30 10 5 1)", PointType, []float64{30, 10, 5}},
+		{"SRID=4326;POINT (30 10)", PointType, []float64{30, 10}},
+		{"POINT EMPTY", PointType, []float64(nil)},
+		{"MULTIPOINT ((10 40), (40 30))", MultiPointType, [][]float64{{10, 40}, {40, 30}}},
+		{"MULTIPOINT (10 40, 40 30)", MultiPointType, [][]float64{{10, 40}, {40, 30}}},
+		{"MULTIPOINT ((10 40), EMPTY)", MultiPointType, [][]float64{{10, 40}}},
+		{"MULTIPOINT EMPTY", MultiPointType, [][]float64{}},
+		{"LINESTRING (30 10, 10 30, 40 40)", LineStringType,
+			[][]float64{{30, 10}, {10, 30}, {40, 40}}},
+		{"LINESTRING EMPTY", LineStringType, [][]float64{}},
+		{"MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))", MultiLineStringType,
+			[][][]float64{{{10, 10}, {20, 20}}, {{40, 40}, {30, 30}}}},
+		{"MULTILINESTRING EMPTY", MultiLineStringType, [][][]float64{}},
+		{"POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))",
+			PolygonType, [][][]float64{
+				{{35, 10}, {45, 45}, {15, 40}, {10, 20}, {35, 10}},
+				{{20, 30}, {35, 35}, {30, 20}, {20, 30}}}},
+		{"POLYGON EMPTY", PolygonType, [][][]float64{}},
+		{"MULTIPOLYGON (((30 20, 45 40, 10 40, 30 20)), ((15 5, 40 10, 10 20, 5 10, 15 5)))",
+			MultiPolygonType, [][][][]float64{
+				{{{30, 20}, {45, 40}, {10, 40}, {30, 20}}},
+				{{{15, 5}, {40, 10}, {10, 20}, {5, 10}, {15, 5}}}}},
+		{"MULTIPOLYGON EMPTY", MultiPolygonType, [][][][]float64{}},
+		{"ENVELOPE (10, 20, 40, 30)", EnvelopeType, [][]float64{{10, 40}, {20, 30}}},
+		{"BUFFER (POINT (10 20), 1)", CircleType, []float64{10, 20}},
+	}
+
+	for i, test := range tests {
+		shape, err := ParseWKTShape(test.input)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if shape.Type() != test.wantType {
+			t.Fatalf("case %d: expected type %s, got %s", i, test.wantType, shape.Type())
+		}
+		vertices := reflect.ValueOf(shape).Elem().FieldByName("Vertices").Interface()
+		if !reflect.DeepEqual(vertices, test.wantVertices) {
+			t.Fatalf("case %d: expected vertices %v, got %v", i, test.wantVertices, vertices)
+		}
+	}
+}
+
+func TestParseWKTGeometryCollection(t *testing.T) {
+	shape, err := ParseWKTShape(`GEOMETRYCOLLECTION (POINT (40 10),
+		LINESTRING (10 10, 20 20, 10 40),
+		POLYGON ((40 40, 20 45, 45 30, 40 40)))`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gc, ok := shape.(*GeometryCollection)
+	if !ok {
+		t.Fatalf("expected a geometrycollection, got %T", shape)
+	}
+	var types []string
+	for _, s := range gc.Shapes {
+		types = append(types, s.Type())
+	}
+	want := []string{PointType, LineStringType, PolygonType}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("expected members %v, got %v", want, types)
+	}
+
+	if ok, err := gc.Intersects(NewGeoJsonPoint([]float64{40, 10})); err != nil || !ok {
+		t.Fatalf("expected the collection to intersect its point, got %v %v", ok, err)
+	}
+
+	// empty points are dropped, so that the collection can be encoded
+	shape, err = ParseWKTShape("GEOMETRYCOLLECTION (POINT EMPTY, POINT (1 2), " +
+		"GEOMETRYCOLLECTION (POINT EMPTY))")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := ToWKT(shape); err != nil ||
+		got != "GEOMETRYCOLLECTION (POINT (1 2), GEOMETRYCOLLECTION EMPTY)" {
+		t.Fatalf("expected the empty points to be dropped, got %s %v", got, err)
+	}
+	if _, err := shape.(*GeometryCollection).Marshal(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	shape, err = ParseWKTShape("GEOMETRYCOLLECTION EMPTY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(shape.(*GeometryCollection).Shapes); n != 0 {
+		t.Fatalf("expected an empty collection, got %d members", n)
+	}
+}
+
+func TestParseWKTShapeErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"HEXAGON (1 2)",
+		"POINT (1)",
+		"POINT (1 2 3 4 5)",
+		"POINT (1 2",
+		"POINT (1 2) x",
+		"POINT (a b)",
+		"LINESTRING (1 2, 3 4",
+		"SRID=3857;POINT (1 2)",
+		"BUFFER (LINESTRING (1 2, 3 4), 1)",
+		"BUFFER (POINT EMPTY, 1)",
+		"ENVELOPE (1, 2, 3)",
+	}
+
+	for i, test := range tests {
+		if _, err := ParseWKTShape(test); err == nil {
+			t.Fatalf("case %d: expected an error for %q", i, test)
+		}
+	}
+}
+
+func TestParseWKTShapeStrict(t *testing.T) {
+	input := "POLYGON ((0 0, 10 10, 10 0, 0 10, 0 0))"
+	if _, err := ParseWKTShape(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := ParseWKTShapeWithOptions(input, ParseOptions{Strict: true})
+	if !errors.Is(err, ErrSelfIntersection) {
+		t.Fatalf("expected %v, got %v", ErrSelfIntersection, err)
+	}
+
+	for _, input := range []string{"POINT EMPTY", "LINESTRING EMPTY", "POLYGON EMPTY"} {
+		if _, err := ParseWKTShapeWithOptions(input, ParseOptions{Strict: true}); err != nil {
+			t.Fatalf("%s: unexpected error: %v", input, err)
+		}
+	}
+}
+
+func TestToWKT(t *testing.T) {
+	tests := []struct {
+		shape index.GeoJSON
+		want  string
+	}{
+		{NewGeoJsonPoint([]float64{30, 10}), "POINT (30 10)"},
+		{&Point{Typ: PointType}, "POINT EMPTY"},
+		{NewGeoJsonMultiPoint([][]float64{{10, 40}, {40, 30}}), "MULTIPOINT ((10 40), (40 30))"},
+		{NewGeoJsonMultiPoint([][]float64{}), "MULTIPOINT EMPTY"},
+		{NewGeoJsonLinestring([][]float64{{30, 10}, {10, 30}}), "LINESTRING (30 10, 10 30)"},
+		{NewGeoJsonMultilinestring([][][]float64{{{10, 10}, {20, 20}}, {{40, 40}, {30, 30}}}),
+			"MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))"},
+		{NewGeoJsonPolygon([][][]float64{testSquare(0, 10)}),
+			"POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0))"},
+		{NewGeoJsonPolygon([][][]float64{}), "POLYGON EMPTY"},
+		{NewGeoJsonMultiPolygon([][][][]float64{{testSquare(0, 10)}, {testSquare(40, 50)}}),
+			"MULTIPOLYGON (((0 0, 10 0, 10 10, 0 10, 0 0)), ((40 40, 50 40, 50 50, 40 50, 40 40)))"},
+		{NewGeoEnvelope([][]float64{{10, 40}, {20, 30}}), "ENVELOPE (10, 20, 40, 30)"},
+		{&GeometryCollection{Typ: GeometryCollectionType,
+			Shapes: []index.GeoJSON{NewGeoJsonPoint([]float64{1, 2}),
+				NewGeoJsonLinestring([][]float64{{0, 0}, {3, 3}})}},
+			"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (0 0, 3 3))"},
+		{&GeometryCollection{Typ: GeometryCollectionType}, "GEOMETRYCOLLECTION EMPTY"},
+	}
+
+	for i, test := range tests {
+		got, err := ToWKT(test.shape)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("case %d: expected %s, got %s", i, test.want, got)
+		}
+
+		// the output must parse back into the same kind of shape
+		shape, err := ParseWKTShape(got)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error parsing %s: %v", i, got, err)
+		}
+		if shape.Type() != test.shape.Type() {
+			t.Fatalf("case %d: expected type %s, got %s", i, test.shape.Type(), shape.Type())
+		}
+	}
+}
+
+func TestToWKTCircle(t *testing.T) {
+	c := NewGeoCircle([]float64{10, 20}, "100km")
+	got, err := ToWKT(decodeShape(t, c))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	shape, err := ParseWKTShape(got)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %s: %v", got, err)
+	}
+	circle, ok := shape.(*Circle)
+	if !ok {
+		t.Fatalf("expected a circle, got %T", shape)
+	}
+	if d := circle.radiusInMeters - 100000; d < -1 || d > 1 {
+		t.Fatalf("expected a radius of 100km, got %vm", circle.radiusInMeters)
+	}
+	if !reflect.DeepEqual(circle.Vertices, []float64{10, 20}) {
+		t.Fatalf("expected the center [10 20], got %v", circle.Vertices)
+	}
+}
+
+func TestEmptyPoint(t *testing.T) {
+	shape, err := ParseWKTShape("POINT EMPTY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pg := NewGeoJsonPolygon([][][]float64{testSquare(-10, 10)})
+	for _, test := range []struct {
+		a, b index.GeoJSON
+	}{{shape, pg}, {pg, shape}} {
+		if ok, err := test.a.Intersects(test.b); err != nil || ok {
+			t.Fatalf("expected no intersection, got %v %v", ok, err)
+		}
+		if ok, err := test.a.Contains(test.b); err != nil || ok {
+			t.Fatalf("expected no containment, got %v %v", ok, err)
+		}
+	}
+	if inner, cross := shape.IndexCells(); len(inner)+len(cross) != 0 {
+		t.Fatalf("expected no cells, got %v %v", inner, cross)
+	}
+	if _, err := shape.(*Point).Marshal(); err == nil {
+		t.Fatal("expected an error encoding an empty point")
+	}
+}
+
+func TestToWKTAltitudes(t *testing.T) {
+	tests := []string{
+		"POINT Z (1 2 3)",
+		"LINESTRING Z (30 10 1, 10 30 2)",
+		"POLYGON Z ((0 0 5, 10 0 6, 10 10 7, 0 10 8, 0 0 5))",
+		"BUFFER (POINT Z (10 20 30), 1)",
+		"GEOMETRYCOLLECTION (POINT Z (1 2 3), LINESTRING (0 0, 3 3))",
+	}
+
+	for i, test := range tests {
+		shape, err := ParseWKTShape(test)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		got, err := ToWKT(shape)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got != test {
+			t.Fatalf("case %d: expected %s, got %s", i, test, got)
+		}
+	}
+
+	// the ordinates after the altitude are not written
+	shape := NewGeoJsonPoint([]float64{1, 2, 3, 4})
+	if got, err := ToWKT(shape); err != nil || got != "POINT Z (1 2 3)" {
+		t.Fatalf("expected POINT Z (1 2 3), got %s %v", got, err)
+	}
+	// nor are the altitudes of only some of the positions
+	shape = NewGeoJsonLinestring([][]float64{{30, 10, 1}, {10, 30}})
+	if got, err := ToWKT(shape); err != nil || got != "LINESTRING (30 10, 10 30)" {
+		t.Fatalf("expected LINESTRING (30 10, 10 30), got %s %v", got, err)
+	}
+}
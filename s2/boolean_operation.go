@@ -0,0 +1,430 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/geo/s1"
+)
+
+// BooleanOperationType is the type of boolean operation to compute.
+type BooleanOperationType int
+
+// These are the BooleanOperationType values.
+const (
+	BooleanOperationUnion BooleanOperationType = iota
+	BooleanOperationIntersection
+	BooleanOperationDifference
+	BooleanOperationSymmetricDifference
+)
+
+func (op BooleanOperationType) String() string {
+	switch op {
+	case BooleanOperationUnion:
+		return "Union"
+	case BooleanOperationIntersection:
+		return "Intersection"
+	case BooleanOperationDifference:
+		return "Difference"
+	case BooleanOperationSymmetricDifference:
+		return "SymmetricDifference"
+	}
+	return fmt.Sprintf("BooleanOperationType(%d)", int(op))
+}
+
+// contains reports whether a point contained by A (inA) and/or by B (inB)
+// is contained by the result of the operation.
+func (op BooleanOperationType) contains(inA, inB bool) bool {
+	switch op {
+	case BooleanOperationUnion:
+		return inA || inB
+	case BooleanOperationIntersection:
+		return inA && inB
+	case BooleanOperationDifference:
+		return inA && !inB
+	}
+	return inA != inB
+}
+
+// subEdgeRelation describes how a piece of the boundary of one geometry is
+// positioned with respect to another polygon.
+type subEdgeRelation int
+
+const (
+	// subEdgeOutside indicates that the edge is outside the other polygon.
+	subEdgeOutside subEdgeRelation = iota
+	// subEdgeInside indicates that the edge is inside the other polygon.
+	subEdgeInside
+	// subEdgeShared indicates that the other polygon has the same edge in
+	// the same direction.
+	subEdgeShared
+	// subEdgeSharedReversed indicates that the other polygon has the same
+	// edge in the opposite direction.
+	subEdgeSharedReversed
+)
+
+// booleanOperationKeep reports whether a sub-edge of one operand with the
+// given relation to the other operand belongs to the boundary of the
+// result, and if so whether it must be reversed. The first operand keeps
+// one copy of any shared edges and the second operand discards them.
+func booleanOperationKeep(op BooleanOperationType, first bool, rel subEdgeRelation) (keep, reverse bool) {
+	switch rel {
+	case subEdgeShared:
+		// The interiors of both operands are on the same side of the edge.
+		return first && (op == BooleanOperationUnion || op == BooleanOperationIntersection), false
+	case subEdgeSharedReversed:
+		// The operands are on opposite sides of the edge, so the edge only
+		// survives when one side is removed from the other.
+		return first && op == BooleanOperationDifference, false
+	case subEdgeInside:
+		switch op {
+		case BooleanOperationIntersection:
+			return true, false
+		case BooleanOperationDifference:
+			return !first, !first
+		case BooleanOperationSymmetricDifference:
+			return true, true
+		}
+		return false, false
+	}
+	// subEdgeOutside
+	switch op {
+	case BooleanOperationUnion, BooleanOperationSymmetricDifference:
+		return true, false
+	case BooleanOperationDifference:
+		return first, false
+	}
+	return false, false
+}
+
+// isExactlyCollinear reports whether c lies exactly on the great circle
+// through a and b, without using symbolic perturbations.
+func isExactlyCollinear(a, b, c Point) bool {
+	if triageSign(a, b, c) != Indeterminate {
+		return false
+	}
+	return exactSign(a, b, c, false) == Indeterminate
+}
+
+// pointInEdgeInterior reports whether the point v, which must lie exactly on
+// the great circle through a0 and a1, is in the interior of the edge.
+func pointInEdgeInterior(v, a0, a1 Point) bool {
+	if v == a0 || v == a1 {
+		return false
+	}
+	n := a0.Cross(a1.Vector)
+	return a0.Cross(v.Vector).Dot(n) > 0 && v.Cross(a1.Vector).Dot(n) > 0
+}
+
+// edgeSplitPoints appends the points where the edge "a" must be split so
+// that it does not cross or overlap the edge "b" except at its endpoints.
+// This includes the crossing point of the two edges and any vertex of one
+// edge that lies exactly in the interior of the other.
+//
+// Crossing points are always computed with the edge of the first operand
+// passed first so that both operands are split at exactly the same point.
+func edgeSplitPoints(splits []Point, a, b Edge, aFirst bool) []Point {
+	first, second := a, b
+	if !aFirst {
+		first, second = b, a
+	}
+	if CrossingSign(first.V0, first.V1, second.V0, second.V1) == Cross &&
+		!isExactlyCollinear(a.V0, a.V1, b.V0) && !isExactlyCollinear(a.V0, a.V1, b.V1) &&
+		!isExactlyCollinear(b.V0, b.V1, a.V0) && !isExactlyCollinear(b.V0, b.V1, a.V1) {
+		return append(splits, Intersection(first.V0, first.V1, second.V0, second.V1))
+	}
+
+	// Otherwise the edges can only meet at a vertex of one edge that lies
+	// exactly on the other edge (including when the edges overlap along the
+	// same great circle). In that case the vertex itself is the split point,
+	// rather than a slightly different computed intersection point.
+	for _, v := range [2]Point{b.V0, b.V1} {
+		if isExactlyCollinear(a.V0, a.V1, v) && pointInEdgeInterior(v, a.V0, a.V1) {
+			splits = append(splits, v)
+		}
+	}
+	return splits
+}
+
+// splitShapeEdges returns the edges of shape "a" split at every point where
+// they cross or touch the edges of shape "b" (which is indexed by bIndex).
+// Degenerate edges are discarded. aFirst reports whether "a" is the first
+// operand of the operation.
+func splitShapeEdges(a, b Shape, bIndex *ShapeIndex, aFirst bool) []Edge {
+	var query *CrossingEdgeQuery
+	if b.NumEdges() > 0 {
+		query = NewCrossingEdgeQuery(bIndex)
+	}
+	var result []Edge
+	var splits []Point
+	for e := 0; e < a.NumEdges(); e++ {
+		edge := a.Edge(e)
+		if edge.V0 == edge.V1 {
+			continue
+		}
+		splits = splits[:0]
+		if query != nil {
+			for _, id := range query.candidates(edge.V0, edge.V1, b) {
+				splits = edgeSplitPoints(splits, edge, b.Edge(id), aFirst)
+			}
+		}
+		sort.Slice(splits, func(i, j int) bool {
+			return ChordAngleBetweenPoints(edge.V0, splits[i]) < ChordAngleBetweenPoints(edge.V0, splits[j])
+		})
+		prev := edge.V0
+		for _, p := range splits {
+			if p == prev || p == edge.V1 {
+				continue
+			}
+			result = append(result, Edge{prev, p})
+			prev = p
+		}
+		result = append(result, Edge{prev, edge.V1})
+	}
+	return result
+}
+
+// classifySubEdge returns the relation of the given edge, which does not
+// cross the boundary of polygon p, to p. otherEdges is the set of sub-edges
+// of the boundary of p.
+func classifySubEdge(e Edge, p *Polygon, otherEdges map[Edge]bool) subEdgeRelation {
+	if otherEdges[e] {
+		return subEdgeShared
+	}
+	if otherEdges[Edge{e.V1, e.V0}] {
+		return subEdgeSharedReversed
+	}
+	if p.ContainsPoint(Point{e.V0.Add(e.V1.Vector).Normalize()}) {
+		return subEdgeInside
+	}
+	return subEdgeOutside
+}
+
+// edgeSet returns the given edges as a set.
+func edgeSet(edges []Edge) map[Edge]bool {
+	set := make(map[Edge]bool, len(edges))
+	for _, e := range edges {
+		set[e] = true
+	}
+	return set
+}
+
+// addBooleanOperationEdges adds the sub-edges of one polygon operand that
+// belong to the boundary of the result to the builder.
+func addBooleanOperationEdges(builder *Builder, op BooleanOperationType, first bool,
+	edges []Edge, other *Polygon, otherEdges map[Edge]bool) {
+	for _, e := range edges {
+		keep, reverse := booleanOperationKeep(op, first, classifySubEdge(e, other, otherEdges))
+		if !keep {
+			continue
+		}
+		if reverse {
+			builder.AddEdge(e.V1, e.V0)
+		} else {
+			builder.AddEdge(e.V0, e.V1)
+		}
+	}
+}
+
+// InitToOperation sets this polygon to the result of the given boolean
+// operation on polygons a and b. The output vertices are snapped using the
+// given snap function, which determines the maximum distance that any
+// vertex may move. Returns an error if the result could not be assembled.
+//
+// The boundaries of a and b are split wherever they cross, and each piece
+// is kept or discarded according to whether it lies inside the other
+// polygon. Edges shared by both polygons are kept at most once.
+func (p *Polygon) InitToOperation(op BooleanOperationType, snapper Snapper, a, b *Polygon) error {
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = snapper
+	builder := NewBuilder(opts)
+	layer := NewPolygonLayer(DefaultPolygonLayerOptions())
+	builder.StartLayer(layer)
+
+	// If the result has no edges it is either empty or full, which we
+	// determine by testing an arbitrary point.
+	builder.AddIsFullPolygonPredicate(func(g *Graph) (bool, error) {
+		origin := OriginPoint()
+		return op.contains(a.ContainsPoint(origin), b.ContainsPoint(origin)), nil
+	})
+
+	aEdges := splitShapeEdges(a, b, b.index, true)
+	bEdges := splitShapeEdges(b, a, a.index, false)
+	addBooleanOperationEdges(builder, op, true, aEdges, b, edgeSet(bEdges))
+	addBooleanOperationEdges(builder, op, false, bEdges, a, edgeSet(aEdges))
+
+	if err := builder.Build(); err != nil {
+		return fmt.Errorf("%v operation failed: %v", op, err)
+	}
+	*p = *layer.Polygon()
+	p.initLoopProperties()
+	return nil
+}
+
+// InitToIntersection sets this polygon to the intersection of a and b.
+// Vertices closer together than intersectionMergeRadius are merged.
+func (p *Polygon) InitToIntersection(a, b *Polygon) error {
+	return p.InitToApproxIntersection(a, b, intersectionMergeRadius)
+}
+
+// InitToApproxIntersection sets this polygon to the intersection of a and b,
+// snapping vertices that are within snapRadius of each other together.
+func (p *Polygon) InitToApproxIntersection(a, b *Polygon, snapRadius s1.Angle) error {
+	return p.InitToOperation(BooleanOperationIntersection, NewIdentitySnapper(snapRadius), a, b)
+}
+
+// InitToUnion sets this polygon to the union of a and b.
+// Vertices closer together than intersectionMergeRadius are merged.
+func (p *Polygon) InitToUnion(a, b *Polygon) error {
+	return p.InitToApproxUnion(a, b, intersectionMergeRadius)
+}
+
+// InitToApproxUnion sets this polygon to the union of a and b, snapping
+// vertices that are within snapRadius of each other together.
+func (p *Polygon) InitToApproxUnion(a, b *Polygon, snapRadius s1.Angle) error {
+	return p.InitToOperation(BooleanOperationUnion, NewIdentitySnapper(snapRadius), a, b)
+}
+
+// InitToDifference sets this polygon to the difference a - b.
+// Vertices closer together than intersectionMergeRadius are merged.
+func (p *Polygon) InitToDifference(a, b *Polygon) error {
+	return p.InitToApproxDifference(a, b, intersectionMergeRadius)
+}
+
+// InitToApproxDifference sets this polygon to the difference a - b,
+// snapping vertices that are within snapRadius of each other together.
+func (p *Polygon) InitToApproxDifference(a, b *Polygon, snapRadius s1.Angle) error {
+	return p.InitToOperation(BooleanOperationDifference, NewIdentitySnapper(snapRadius), a, b)
+}
+
+// InitToSymmetricDifference sets this polygon to the symmetric difference
+// of a and b, i.e. the region contained by exactly one of them.
+// Vertices closer together than intersectionMergeRadius are merged.
+func (p *Polygon) InitToSymmetricDifference(a, b *Polygon) error {
+	return p.InitToApproxSymmetricDifference(a, b, intersectionMergeRadius)
+}
+
+// InitToApproxSymmetricDifference sets this polygon to the symmetric
+// difference of a and b, snapping vertices that are within snapRadius of
+// each other together.
+func (p *Polygon) InitToApproxSymmetricDifference(a, b *Polygon, snapRadius s1.Angle) error {
+	return p.InitToOperation(BooleanOperationSymmetricDifference, NewIdentitySnapper(snapRadius), a, b)
+}
+
+// UnionOfPolygons returns the union of the given polygons. Vertices closer
+// together than intersectionMergeRadius are merged.
+func UnionOfPolygons(polygons []*Polygon) (*Polygon, error) {
+	return ApproxUnionOfPolygons(polygons, intersectionMergeRadius)
+}
+
+// ApproxUnionOfPolygons returns the union of the given polygons, snapping
+// vertices that are within snapRadius of each other together.
+func ApproxUnionOfPolygons(polygons []*Polygon, snapRadius s1.Angle) (*Polygon, error) {
+	// Repeatedly union the two smallest polygons and add the result back
+	// into the queue, which keeps the size of intermediate results small.
+	queue := append([]*Polygon(nil), polygons...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].numVertices < queue[j].numVertices
+	})
+	for len(queue) > 1 {
+		a, b := queue[0], queue[1]
+		queue = queue[2:]
+		union := &Polygon{}
+		if err := union.InitToApproxUnion(a, b, snapRadius); err != nil {
+			return nil, err
+		}
+		i := sort.Search(len(queue), func(i int) bool {
+			return queue[i].numVertices > union.numVertices
+		})
+		queue = append(queue, nil)
+		copy(queue[i+1:], queue[i:])
+		queue[i] = union
+	}
+	if len(queue) == 0 {
+		return PolygonFromLoops(nil), nil
+	}
+	return queue[0], nil
+}
+
+// OperationWithPolyline returns the result of the given boolean operation
+// between the polyline "in" and this polygon, snapped using the given snap
+// function. Only BooleanOperationIntersection (the parts of the polyline
+// inside the polygon) and BooleanOperationDifference (the parts outside the
+// polygon) are supported.
+//
+// A polyline edge that coincides with an edge of the polygon boundary is
+// considered inside the polygon if the polygon interior is on its left.
+func (p *Polygon) OperationWithPolyline(op BooleanOperationType, snapper Snapper, in *Polyline) ([]*Polyline, error) {
+	if op != BooleanOperationIntersection && op != BooleanOperationDifference {
+		return nil, fmt.Errorf("%v operation is not supported for polylines", op)
+	}
+
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = snapper
+	builder := NewBuilder(opts)
+	layerOpts := DefaultPolylineVectorLayerOptions()
+	layerOpts.PolylineType = PolylineTypeWalk
+	layer := NewPolylineVectorLayer(layerOpts)
+	builder.StartLayer(layer)
+
+	index := NewShapeIndex()
+	index.Add(in)
+	polygonEdges := edgeSet(splitShapeEdges(p, in, index, true))
+	for _, e := range splitShapeEdges(in, p, p.index, false) {
+		rel := classifySubEdge(e, p, polygonEdges)
+		inside := rel == subEdgeInside || rel == subEdgeShared
+		if inside == (op == BooleanOperationIntersection) {
+			builder.AddEdge(e.V0, e.V1)
+		}
+	}
+	if err := builder.Build(); err != nil {
+		return nil, fmt.Errorf("polyline %v operation failed: %v", op, err)
+	}
+	return layer.Polylines(), nil
+}
+
+// IntersectWithPolyline returns the parts of the given polyline that are
+// contained by this polygon. Vertices closer together than
+// intersectionMergeRadius are merged.
+func (p *Polygon) IntersectWithPolyline(in *Polyline) ([]*Polyline, error) {
+	return p.ApproxIntersectWithPolyline(in, intersectionMergeRadius)
+}
+
+// ApproxIntersectWithPolyline is like IntersectWithPolyline, except that
+// vertices within snapRadius of each other are snapped together.
+func (p *Polygon) ApproxIntersectWithPolyline(in *Polyline, snapRadius s1.Angle) ([]*Polyline, error) {
+	return p.OperationWithPolyline(BooleanOperationIntersection, NewIdentitySnapper(snapRadius), in)
+}
+
+// SubtractFromPolyline returns the parts of the given polyline that are not
+// contained by this polygon. Vertices closer together than
+// intersectionMergeRadius are merged.
+func (p *Polygon) SubtractFromPolyline(in *Polyline) ([]*Polyline, error) {
+	return p.ApproxSubtractFromPolyline(in, intersectionMergeRadius)
+}
+
+// ApproxSubtractFromPolyline is like SubtractFromPolyline, except that
+// vertices within snapRadius of each other are snapped together.
+func (p *Polygon) ApproxSubtractFromPolyline(in *Polyline, snapRadius s1.Angle) ([]*Polyline, error) {
+	return p.OperationWithPolyline(BooleanOperationDifference, NewIdentitySnapper(snapRadius), in)
+}
+
+// TODO(rsned): Differences from C++
+// Operations between arbitrary ShapeIndexes (S2BooleanOperation).
+// PolygonModel and PolylineModel options; polygons are always semi-open.
+// Polyline-polyline and point operations.
+// IsEmpty / Intersects / Contains predicates that avoid building the result.
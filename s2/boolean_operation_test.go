@@ -0,0 +1,308 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"math"
+	"testing"
+)
+
+// polygonOperation returns the result of the given operation on a and b.
+func polygonOperation(t *testing.T, op BooleanOperationType, a, b *Polygon) *Polygon {
+	t.Helper()
+	var result Polygon
+	var err error
+	switch op {
+	case BooleanOperationUnion:
+		err = result.InitToUnion(a, b)
+	case BooleanOperationIntersection:
+		err = result.InitToIntersection(a, b)
+	case BooleanOperationDifference:
+		err = result.InitToDifference(a, b)
+	case BooleanOperationSymmetricDifference:
+		err = result.InitToSymmetricDifference(a, b)
+	}
+	if err != nil {
+		t.Fatalf("%v returned error: %v", op, err)
+	}
+	if err := result.Validate(); err != nil {
+		t.Errorf("%v result is not valid: %v", op, err)
+	}
+	return &result
+}
+
+func TestBooleanOperationPolygons(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		// Points that must be inside (contained) or outside the result of
+		// each operation.
+		probes []string
+	}{
+		{
+			name:   "overlapping squares",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "5:5, 5:15, 15:15, 15:5",
+			probes: []string{"2:2", "7:7", "12:12", "20:20", "2:12", "12:2"},
+		},
+		{
+			name:   "adjacent squares",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "0:10, 0:20, 10:20, 10:10",
+			probes: []string{"5:5", "5:15", "5:25", "-5:10"},
+		},
+		{
+			name:   "disjoint squares",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "20:20, 20:30, 30:30, 30:20",
+			probes: []string{"5:5", "25:25", "15:15"},
+		},
+		{
+			name:   "identical squares",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "0:0, 0:10, 10:10, 10:0",
+			probes: []string{"5:5", "15:15"},
+		},
+		{
+			name:   "nested squares",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "2:2, 2:8, 8:8, 8:2",
+			probes: []string{"1:1", "5:5", "15:15"},
+		},
+		{
+			name:   "square inside hole",
+			a:      "0:0, 0:10, 10:10, 10:0; 2:2, 2:8, 8:8, 8:2",
+			b:      "4:4, 4:6, 6:6, 6:4",
+			probes: []string{"1:1", "3:3", "5:5", "15:15"},
+		},
+		{
+			name:   "squares with collinear overlapping edges",
+			a:      "0:0, 0:10, 10:10, 10:0",
+			b:      "0:5, 0:15, -10:15, -10:5",
+			probes: []string{"5:2", "5:7", "-5:7", "-5:12", "5:12"},
+		},
+	}
+
+	ops := []BooleanOperationType{
+		BooleanOperationUnion,
+		BooleanOperationIntersection,
+		BooleanOperationDifference,
+		BooleanOperationSymmetricDifference,
+	}
+	for _, test := range tests {
+		a := makePolygon(test.a, true)
+		b := makePolygon(test.b, true)
+		results := make(map[BooleanOperationType]*Polygon)
+		for _, op := range ops {
+			results[op] = polygonOperation(t, op, a, b)
+			for _, s := range test.probes {
+				x := parsePoint(s)
+				want := op.contains(a.ContainsPoint(x), b.ContainsPoint(x))
+				if got := results[op].ContainsPoint(x); got != want {
+					t.Errorf("%s: %v.ContainsPoint(%s) = %t, want %t", test.name, op, s, got, want)
+				}
+			}
+		}
+
+		// Check the areas for consistency.
+		union := results[BooleanOperationUnion].Area()
+		intersection := results[BooleanOperationIntersection].Area()
+		if got, want := union+intersection, a.Area()+b.Area(); !float64Near(got, want, 1e-12) {
+			t.Errorf("%s: union + intersection area = %v, want %v", test.name, got, want)
+		}
+		if got, want := results[BooleanOperationDifference].Area(), a.Area()-intersection; !float64Near(got, want, 1e-12) {
+			t.Errorf("%s: difference area = %v, want %v", test.name, got, want)
+		}
+		if got, want := results[BooleanOperationSymmetricDifference].Area(), union-intersection; !float64Near(got, want, 1e-12) {
+			t.Errorf("%s: symmetric difference area = %v, want %v", test.name, got, want)
+		}
+	}
+}
+
+func TestBooleanOperationAdjacentUnionMergesLoops(t *testing.T) {
+	a := makePolygon("0:0, 0:10, 10:10, 10:0", true)
+	b := makePolygon("0:10, 0:20, 10:20, 10:10", true)
+	got := polygonOperation(t, BooleanOperationUnion, a, b)
+	if got.NumLoops() != 1 {
+		t.Fatalf("union has %d loops, want 1", got.NumLoops())
+	}
+	if n := got.Loop(0).NumVertices(); n != 6 {
+		t.Errorf("union has %d vertices, want 6", n)
+	}
+	if got := polygonOperation(t, BooleanOperationIntersection, a, b); !got.IsEmpty() {
+		t.Errorf("intersection of adjacent polygons = %v, want empty", got.Loops())
+	}
+}
+
+func TestBooleanOperationEmptyAndFull(t *testing.T) {
+	a := makePolygon("0:0, 0:10, 10:10, 10:0", true)
+	empty := PolygonFromLoops(nil)
+	full := FullPolygon()
+	complement := makePolygon("0:0, 0:10, 10:10, 10:0", true)
+	complement.Invert()
+
+	tests := []struct {
+		name     string
+		op       BooleanOperationType
+		a, b     *Polygon
+		wantArea float64
+	}{
+		{"a union complement", BooleanOperationUnion, a, complement, 4 * math.Pi},
+		{"a intersect complement", BooleanOperationIntersection, a, complement, 0},
+		{"a minus complement", BooleanOperationDifference, a, complement, a.Area()},
+		{"a symdiff complement", BooleanOperationSymmetricDifference, a, complement, 4 * math.Pi},
+		{"a union empty", BooleanOperationUnion, a, empty, a.Area()},
+		{"a intersect empty", BooleanOperationIntersection, a, empty, 0},
+		{"a union full", BooleanOperationUnion, a, full, 4 * math.Pi},
+		{"a intersect full", BooleanOperationIntersection, a, full, a.Area()},
+		{"full minus a", BooleanOperationDifference, full, a, 4*math.Pi - a.Area()},
+		{"empty minus a", BooleanOperationDifference, empty, a, 0},
+		{"full intersect full", BooleanOperationIntersection, full, full, 4 * math.Pi},
+		{"full minus full", BooleanOperationDifference, full, full, 0},
+	}
+	for _, test := range tests {
+		got := polygonOperation(t, test.op, test.a, test.b)
+		if !float64Near(got.Area(), test.wantArea, 1e-12) {
+			t.Errorf("%s: area = %v, want %v", test.name, got.Area(), test.wantArea)
+		}
+	}
+}
+
+func TestBooleanOperationSnapFunction(t *testing.T) {
+	a := makePolygon("0:0, 0:10, 10:10, 10:0", true)
+	b := makePolygon("5:5, 5:15, 15:15, 15:5", true)
+	var got Polygon
+	if err := got.InitToOperation(BooleanOperationIntersection, CellIDSnapperForLevel(10), a, b); err != nil {
+		t.Fatalf("InitToOperation returned error: %v", err)
+	}
+	if got.NumLoops() != 1 {
+		t.Fatalf("NumLoops() = %d, want 1", got.NumLoops())
+	}
+	for i, v := range got.Loop(0).Vertices() {
+		if cellIDFromPoint(v).Parent(10).Point() != v {
+			t.Errorf("vertex %d = %v is not a level 10 cell center", i, v)
+		}
+	}
+}
+
+func TestBooleanOperationUnionOfPolygons(t *testing.T) {
+	var polygons []*Polygon
+	var area float64
+	for _, s := range []string{
+		"0:0, 0:10, 10:10, 10:0",
+		"0:10, 0:20, 10:20, 10:10",
+		"10:0, 10:10, 20:10, 20:0",
+		"10:10, 10:20, 20:20, 20:10",
+	} {
+		p := makePolygon(s, true)
+		area += p.Area()
+		polygons = append(polygons, p)
+	}
+	got, err := UnionOfPolygons(polygons)
+	if err != nil {
+		t.Fatalf("UnionOfPolygons returned error: %v", err)
+	}
+	if got.NumLoops() != 1 {
+		t.Errorf("NumLoops() = %d, want 1", got.NumLoops())
+	}
+	if !float64Near(got.Area(), area, 1e-12) {
+		t.Errorf("Area() = %v, want %v", got.Area(), area)
+	}
+	if got, err := UnionOfPolygons(nil); err != nil || !got.IsEmpty() {
+		t.Errorf("UnionOfPolygons(nil) = %v, %v, want empty polygon", got, err)
+	}
+}
+
+func TestBooleanOperationPolyline(t *testing.T) {
+	polygon := makePolygon("0:0, 0:10, 10:10, 10:0", true)
+	tests := []struct {
+		polyline         string
+		wantIntersection int
+		wantDifference   int
+	}{
+		// Crosses the polygon.
+		{"5:-5, 5:15", 1, 2},
+		// Starts inside the polygon.
+		{"5:5, 5:15", 1, 1},
+		// Entirely inside.
+		{"2:2, 8:8", 1, 0},
+		// Entirely outside.
+		{"20:20, 30:30", 0, 1},
+		// Enters and leaves twice.
+		{"5:-5, 5:15, 6:15, 6:-5", 2, 3},
+		// Follows the boundary with the interior on its left.
+		{"0:0, 0:10", 1, 0},
+		// Follows the boundary with the interior on its right.
+		{"0:10, 0:0", 0, 1},
+	}
+	for _, test := range tests {
+		in := makePolyline(test.polyline)
+		inside, err := polygon.IntersectWithPolyline(in)
+		if err != nil {
+			t.Fatalf("IntersectWithPolyline(%s) returned error: %v", test.polyline, err)
+		}
+		if len(inside) != test.wantIntersection {
+			t.Errorf("IntersectWithPolyline(%s) returned %d polylines, want %d", test.polyline, len(inside), test.wantIntersection)
+		}
+		outside, err := polygon.SubtractFromPolyline(in)
+		if err != nil {
+			t.Fatalf("SubtractFromPolyline(%s) returned error: %v", test.polyline, err)
+		}
+		if len(outside) != test.wantDifference {
+			t.Errorf("SubtractFromPolyline(%s) returned %d polylines, want %d", test.polyline, len(outside), test.wantDifference)
+		}
+
+		// The pieces must add up to the original polyline.
+		var length float64
+		for _, p := range append(inside, outside...) {
+			length += p.Length().Radians()
+			for i := 1; i < len(*p); i++ {
+				mid := Point{(*p)[i-1].Add((*p)[i].Vector).Normalize()}
+				if got, want := polygon.ContainsPoint(mid), containsPolyline(inside, p); got != want && !polygonBoundaryContains(polygon, mid) {
+					t.Errorf("polyline %v edge %d midpoint contained = %t, want %t", *p, i-1, got, want)
+				}
+			}
+		}
+		if want := in.Length().Radians(); !float64Near(length, want, 1e-14) {
+			t.Errorf("total length of pieces of %s = %v, want %v", test.polyline, length, want)
+		}
+	}
+
+	if _, err := polygon.OperationWithPolyline(BooleanOperationUnion, NewIdentitySnapper(0), makePolyline("0:0, 1:1")); err == nil {
+		t.Errorf("OperationWithPolyline(Union) = nil error, want error")
+	}
+}
+
+// containsPolyline reports whether the given polyline is one of the given
+// polylines.
+func containsPolyline(polylines []*Polyline, p *Polyline) bool {
+	for _, q := range polylines {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonBoundaryContains reports whether x is on the boundary of p.
+func polygonBoundaryContains(p *Polygon, x Point) bool {
+	for e := 0; e < p.NumEdges(); e++ {
+		edge := p.Edge(e)
+		if DistanceFromSegment(x, edge.V0, edge.V1) < 1e-14 {
+			return true
+		}
+	}
+	return false
+}
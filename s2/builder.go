@@ -14,6 +14,15 @@
 
 package s2
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/blevesearch/geo/s1"
+)
+
 const (
 	// maxEdgeDeviationRatio is set so that MaxEdgeDeviation will be large enough
 	// compared to snapRadius such that edge splitting is rare.
@@ -33,3 +42,1105 @@ const (
 	// when MaxEdgeDeviation is exceeded.
 	maxEdgeDeviationRatio = 1.1
 )
+
+// BuilderOptions holds the parameters that control how a Builder snaps
+// and assembles its input geometry.
+type BuilderOptions struct {
+	// SnapFunction determines the locations of the output vertices and
+	// the maximum distance that vertices may move (see Snapper). The
+	// default is an IdentitySnapper with a snap radius of zero, which
+	// preserves all input vertices exactly.
+	SnapFunction Snapper
+
+	// SplitCrossingEdges specifies that if any input edges cross, then
+	// a new vertex is added at each crossing point so that the output
+	// edges only meet at vertices. (The crossing points are then snapped
+	// like any other vertex.)
+	//
+	// Note that this requires that IntersectionTolerance be at least
+	// intersectionError; smaller values are rounded up automatically.
+	SplitCrossingEdges bool
+
+	// IntersectionTolerance specifies the maximum allowable distance between
+	// a vertex added by SplitCrossingEdges and the edges that cross there.
+	// It is also useful for callers that compute their own intersection
+	// points and add them as input vertices. The effective value is the
+	// maximum of this value and intersectionError when SplitCrossingEdges
+	// is true.
+	IntersectionTolerance s1.Angle
+
+	// Idempotent specifies that snapping is only performed if the input
+	// geometry does not already satisfy the output guarantees (i.e. vertices
+	// are already snapped and separated as required). If false, all
+	// vertices are snapped using the SnapFunction even if this moves them.
+	Idempotent bool
+}
+
+// DefaultBuilderOptions returns the default set of options for a Builder.
+func DefaultBuilderOptions() BuilderOptions {
+	return BuilderOptions{
+		SnapFunction: NewIdentitySnapper(0),
+		Idempotent:   true,
+	}
+}
+
+// intersectionTolerance returns the effective intersection tolerance.
+func (o BuilderOptions) intersectionTolerance() s1.Angle {
+	if !o.SplitCrossingEdges {
+		return o.IntersectionTolerance
+	}
+	return maxAngle(o.IntersectionTolerance, intersectionError)
+}
+
+// EdgeSnapRadius returns the maximum distance from a vertex to an edge such
+// that the edge is snapped to that vertex. This is the snap radius of the
+// snap function plus the effective intersection tolerance.
+func (o BuilderOptions) EdgeSnapRadius() s1.Angle {
+	return o.SnapFunction.SnapRadius() + o.intersectionTolerance()
+}
+
+// MaxEdgeDeviation returns the maximum distance that any point along an
+// edge can move when snapped. It is slightly larger than EdgeSnapRadius
+// because when a geodesic edge is snapped, the edge center moves further
+// than its endpoints. Builder splits edges as necessary to stay within this
+// bound.
+func (o BuilderOptions) MaxEdgeDeviation() s1.Angle {
+	return maxEdgeDeviationRatio * o.EdgeSnapRadius()
+}
+
+// IsFullPolygonPredicate reports whether a polygon layer whose graph
+// contains no edges should be interpreted as the full polygon rather than
+// the empty one. It is called only for layers that need to make this
+// decision (such as PolygonLayer).
+type IsFullPolygonPredicate func(g *Graph) (bool, error)
+
+// IsFullPolygon returns an IsFullPolygonPredicate that always returns the
+// given value.
+func IsFullPolygon(isFull bool) IsFullPolygonPredicate {
+	return func(g *Graph) (bool, error) { return isFull, nil }
+}
+
+// Layer represents an output layer of a Builder. Each layer receives the
+// snapped edges that were added to it, processed according to its
+// GraphOptions, and assembles them into some output geometry.
+type Layer interface {
+	// GraphOptions returns the options for the Graph passed to Build.
+	GraphOptions() GraphOptions
+
+	// Build assembles the given graph into the layer's output geometry.
+	Build(g *Graph) error
+}
+
+// builderInputEdge is a pair of input vertex ids representing an input edge.
+type builderInputEdge struct {
+	first, second int32
+}
+
+// Builder is a tool for assembling polygonal geometry from edges. Here are
+// some of the things it is designed for:
+//
+//  1. Building polygons, polylines, and polygon meshes from unsorted
+//     collections of edges.
+//
+//  2. Snapping geometry to discrete representations (such as CellID centers
+//     or E7 lat/lng coordinates) while preserving the input topology and with
+//     guaranteed error bounds.
+//
+//  3. Simplifying geometry (e.g. for indexing, display, or storage).
+//
+//  4. Importing geometry from other formats, including repairing geometry
+//     that has errors.
+//
+//  5. As a tool for implementing more complex operations such as polygon
+//     intersections and unions.
+//
+// The implementation is based on the framework of "snap rounding". Unlike
+// most snap rounding implementations, Builder defines edges as geodesics on
+// the sphere (straight lines) and uses the topology of the sphere (i.e.,
+// there are no "seams" at the poles or 180th meridian). The algorithm is
+// designed to be 100% robust for arbitrary input geometry. It offers the
+// following properties:
+//
+//   - Guaranteed bounds on how far input vertices and edges can move during
+//     the snapping process (i.e., at most the given snap radius).
+//
+//   - Guaranteed minimum separation between edges and vertices other than
+//     their endpoints (similar to the goals of Iterated Snap Rounding). In
+//     other words, edges that do not intersect in the output are guaranteed
+//     to have a minimum separation between them.
+//
+//   - Idempotency (similar to the goals of Stable Snap Rounding), i.e. if the
+//     input already meets the output criteria then it will not be modified.
+//
+//   - Preservation of the input topology (up to the creation of
+//     degeneracies). This means that there exists a continuous deformation
+//     from the input to the output such that no vertex crosses an edge. In
+//     other words, self-intersections won't be created, loops won't change
+//     orientation, etc.
+//
+//   - The ability to snap to arbitrary discrete point sets (such as CellID
+//     centers, E7 lat/lng points on the sphere, or simply a subset of the
+//     input vertices), rather than being limited to an integer grid.
+//
+// Here are some of its other features:
+//
+//   - It can handle both directed and undirected edges. Undirected edges can
+//     be useful for importing data from other formats, e.g. where loops have
+//     unspecified orientations.
+//
+//   - It can eliminate self-intersections by finding all edge pairs that
+//     cross and adding a new vertex at each intersection point.
+//
+//   - It can split the output into multiple layers (e.g., when building a
+//     polygon mesh where each polygon is stored separately), and the output
+//     of each layer can be processed independently using GraphOptions.
+//
+// Typical usage:
+//
+//	b := NewBuilder(DefaultBuilderOptions())
+//	layer := NewPolygonLayer(DefaultPolygonLayerOptions())
+//	b.StartLayer(layer)
+//	b.AddPolygon(input)
+//	if err := b.Build(); err != nil {
+//		// handle the error
+//	}
+//	output := layer.Polygon()
+type Builder struct {
+	opts BuilderOptions
+
+	// siteSnapRadiusCA is the maximum distance (inclusive) that a vertex can
+	// move when snapped, equal to ChordAngleFromAngle(SnapRadius).
+	siteSnapRadiusCA s1.ChordAngle
+
+	// edgeSnapRadiusCA is the maximum distance (inclusive) that an edge can
+	// move when snapping to a snap site. It can be slightly larger than the
+	// site snap radius when edges are being split at crossings.
+	edgeSnapRadiusCA s1.ChordAngle
+
+	// snappingRequested reports whether non-zero snapping was requested.
+	snappingRequested bool
+
+	maxEdgeDeviation             s1.Angle
+	edgeSiteQueryRadiusCA        s1.ChordAngle
+	minEdgeLengthToSplitCA       s1.ChordAngle
+	minSiteSeparation            s1.Angle
+	minSiteSeparationCA          s1.ChordAngle
+	minEdgeSiteSeparationCA      s1.ChordAngle
+	minEdgeSiteSeparationCALimit s1.ChordAngle
+	maxAdjacentSiteSeparationCA  s1.ChordAngle
+
+	// edgeSnapRadiusSin2 is sin^2 of the edge snap radius, increased by the
+	// maximum error in computing the perpendicular distance between a vertex
+	// and an edge.
+	edgeSnapRadiusSin2 float64
+
+	// snappingNeeded is set to true if the input geometry needs to be
+	// modified in order to satisfy the output guarantees.
+	snappingNeeded bool
+
+	// The input vertices and edges of all layers.
+	inputVertices []Point
+	inputEdges    []builderInputEdge
+
+	layers                       []Layer
+	layerOptions                 []GraphOptions
+	layerBegins                  []int
+	layerIsFullPolygonPredicates []IsFullPolygonPredicate
+
+	// sites is the set of snapped vertex locations ("sites").
+	sites []Point
+
+	// numForcedSites is the number of sites specified using ForceVertex.
+	// These sites are always at the beginning of the sites slice.
+	numForcedSites int
+
+	// edgeSites maps each input edge to the set of sites that are nearby,
+	// sorted by increasing distance from the edge's first vertex. This
+	// includes both the sites the edge snaps to and "sites to avoid".
+	edgeSites [][]int32
+}
+
+// NewBuilder returns a new Builder with the given options.
+func NewBuilder(opts BuilderOptions) *Builder {
+	if opts.SnapFunction == nil {
+		opts.SnapFunction = NewIdentitySnapper(0)
+	}
+	b := &Builder{opts: opts}
+
+	snapRadius := opts.SnapFunction.SnapRadius()
+
+	// Convert the snap radius to a ChordAngle. This is the "true snap
+	// radius" used when evaluating exact predicates.
+	b.siteSnapRadiusCA = s1.ChordAngleFromAngle(snapRadius)
+
+	// When SplitCrossingEdges is true, we need to use a larger snap radius
+	// for edges than for vertices to ensure that both edges are snapped to the
+	// edge intersection location. This is because the computed intersection
+	// point is not exact; it may be up to intersectionError away from its true
+	// position. The computed intersection point might then be snapped to some
+	// other vertex up to snapRadius away. So to ensure that both edges are
+	// snapped to a common vertex, we need to increase the snap radius for edges
+	// to at least the sum of these two values (calculated conservatively).
+	edgeSnapRadius := opts.EdgeSnapRadius()
+	b.edgeSnapRadiusCA = roundUpChordAngle(edgeSnapRadius)
+	b.snappingRequested = edgeSnapRadius > 0
+
+	// Compute the maximum distance that a vertex can be separated from an
+	// edge while still affecting how that edge is snapped.
+	b.maxEdgeDeviation = opts.MaxEdgeDeviation()
+	b.edgeSiteQueryRadiusCA = s1.ChordAngleFromAngle(b.maxEdgeDeviation +
+		opts.SnapFunction.MinEdgeVertexSeparation())
+
+	// Compute the maximum edge length such that even if both endpoints move by
+	// the maximum distance allowed (i.e., edgeSnapRadius), the center of the
+	// edge will still move by less than maxEdgeDeviation. This saves us a
+	// lot of work since then we don't need to check the actual deviation.
+	if !b.snappingRequested {
+		b.minEdgeLengthToSplitCA = s1.InfChordAngle()
+	} else {
+		// This value varies between 30 and 50 degrees depending on the snap radius.
+		b.minEdgeLengthToSplitCA = s1.ChordAngleFromAngle(s1.Angle(2 *
+			math.Acos(math.Sin(edgeSnapRadius.Radians())/math.Sin(b.maxEdgeDeviation.Radians()))))
+	}
+
+	// To implement idempotency, we check whether the input geometry could
+	// possibly be the output of a previous Builder invocation. This involves
+	// testing whether any site/vertex or edge/vertex pair is too close together.
+	// This is done using exact predicates, which require converting the minimum
+	// separation values to a ChordAngle.
+	b.minSiteSeparation = opts.SnapFunction.MinVertexSeparation()
+	b.minSiteSeparationCA = s1.ChordAngleFromAngle(b.minSiteSeparation)
+	b.minEdgeSiteSeparationCA = s1.ChordAngleFromAngle(opts.SnapFunction.MinEdgeVertexSeparation())
+
+	// This is an upper bound on the distance computed by ClosestEdgeQuery
+	// where the true distance might be less than minEdgeSiteSeparationCA.
+	b.minEdgeSiteSeparationCALimit = b.minEdgeSiteSeparationCA.Expanded(
+		minUpdateDistanceMaxError(b.minEdgeSiteSeparationCA))
+
+	// Compute the maximum possible distance between two sites whose Voronoi
+	// regions touch. (The maximum radius of each Voronoi region is
+	// edgeSnapRadius.) Then increase this bound to account for errors.
+	maxAdjacent := roundUpChordAngle(2 * edgeSnapRadius)
+	b.maxAdjacentSiteSeparationCA = maxAdjacent.Expanded(maxAdjacent.MaxPointError())
+
+	// Finally, we also precompute sin^2(edgeSnapRadius), which is simply the
+	// squared distance between a vertex and an edge measured perpendicular to
+	// the plane containing the edge, and increase this value by the maximum
+	// error in the calculation to compare this distance against the bound.
+	d := math.Sin(edgeSnapRadius.Radians())
+	b.edgeSnapRadiusSin2 = d * d
+	b.edgeSnapRadiusSin2 += ((9.5*d+2.5+2*sqrt3)*d + 9*dblEpsilon) * dblEpsilon
+
+	return b
+}
+
+// roundUpChordAngle returns the ChordAngle corresponding to the given angle,
+// increased by the maximum error in the conversion.
+func roundUpChordAngle(a s1.Angle) s1.ChordAngle {
+	ca := s1.ChordAngleFromAngle(a)
+	return ca.Expanded(ca.MaxAngleError())
+}
+
+// Options returns the options this Builder was created with.
+func (b *Builder) Options() BuilderOptions {
+	return b.opts
+}
+
+// StartLayer starts a new output layer. This method must be called before
+// adding any edges to the Builder. All edges added after this call (and
+// before the next call to StartLayer) are assembled by the given layer.
+func (b *Builder) StartLayer(layer Layer) {
+	b.layerOptions = append(b.layerOptions, layer.GraphOptions())
+	b.layerBegins = append(b.layerBegins, len(b.inputEdges))
+	b.layerIsFullPolygonPredicates = append(b.layerIsFullPolygonPredicates, IsFullPolygon(false))
+	b.layers = append(b.layers, layer)
+}
+
+// AddEdge adds the given edge to the current layer. Degenerate edges
+// (v0 == v1) are discarded immediately if the current layer discards them.
+func (b *Builder) AddEdge(v0, v1 Point) {
+	if len(b.layers) == 0 {
+		panic("s2: Builder.StartLayer must be called before adding any edges")
+	}
+	if v0 == v1 && b.layerOptions[len(b.layerOptions)-1].DegenerateEdges == DegenerateEdgesDiscard {
+		return
+	}
+	j0 := b.addVertex(v0)
+	j1 := b.addVertex(v1)
+	b.inputEdges = append(b.inputEdges, builderInputEdge{j0, j1})
+}
+
+// addVertex adds the given vertex to the input vertices and returns its id.
+// Consecutive duplicate vertices (following the pattern AB, BC, CD) share
+// the same id.
+func (b *Builder) addVertex(v Point) int32 {
+	if len(b.inputVertices) == 0 || v != b.inputVertices[len(b.inputVertices)-1] {
+		b.inputVertices = append(b.inputVertices, v)
+	}
+	return int32(len(b.inputVertices) - 1)
+}
+
+// AddPoint adds the given point as a degenerate edge to the current layer.
+// It is only useful for layers that keep degenerate edges.
+func (b *Builder) AddPoint(v Point) {
+	b.AddEdge(v, v)
+}
+
+// AddPolyline adds the edges of the given polyline to the current layer.
+// A polyline with a single vertex is added as a degenerate edge.
+func (b *Builder) AddPolyline(polyline *Polyline) {
+	p := *polyline
+	if len(p) == 1 {
+		b.AddEdge(p[0], p[0])
+		return
+	}
+	for i := 1; i < len(p); i++ {
+		b.AddEdge(p[i-1], p[i])
+	}
+}
+
+// AddLoop adds the edges of the given loop to the current layer. The loop
+// is added such that its interior is on the left (i.e. holes are reversed).
+// Empty and full loops are ignored since they have no boundary.
+func (b *Builder) AddLoop(loop *Loop) {
+	if loop.isEmptyOrFull() {
+		return
+	}
+
+	// For loops that represent holes, we add the edge from vertex n-1 to vertex
+	// n-2 first. This is because these edges will be assembled into a
+	// clockwise loop, which will eventually be normalized by the polygon
+	// calling Loop.Invert. Invert reverses the order of the vertices, so to
+	// end up with the original vertex order (0, 1, ..., n-1) we need to build
+	// a clockwise loop with vertex order (n-1, n-2, ..., 0). This is done by
+	// adding the edge (n-1, n-2) first, and then ensuring that Build assembles
+	// loops starting from edges in the order they were added.
+	n := loop.NumVertices()
+	for i := 0; i < n; i++ {
+		b.AddEdge(loop.OrientedVertex(i), loop.OrientedVertex(i+1))
+	}
+}
+
+// AddPolygon adds the edges of all the loops of the given polygon to the
+// current layer.
+func (b *Builder) AddPolygon(polygon *Polygon) {
+	for _, l := range polygon.Loops() {
+		b.AddLoop(l)
+	}
+}
+
+// AddShape adds all the edges of the given shape to the current layer.
+func (b *Builder) AddShape(shape Shape) {
+	for e := 0; e < shape.NumEdges(); e++ {
+		edge := shape.Edge(e)
+		b.AddEdge(edge.V0, edge.V1)
+	}
+}
+
+// AddIsFullPolygonPredicate sets the predicate used by the current layer to
+// decide whether a polygon with no edges is empty or full. The default
+// predicate reports that such polygons are empty.
+func (b *Builder) AddIsFullPolygonPredicate(predicate IsFullPolygonPredicate) {
+	b.layerIsFullPolygonPredicates[len(b.layerIsFullPolygonPredicates)-1] = predicate
+}
+
+// ForceVertex forces a vertex to be located at the given position. This can
+// be used to prevent certain input vertices from moving. However if you are
+// trying to preserve input edges, be aware that this option does not
+// prevent edges from being split by new vertices.
+//
+// Forced vertices are never snapped; if this is desired then you need to
+// call the snap function yourself before calling ForceVertex.
+//
+// Forced vertices are only used when snapping is requested (i.e. the edge
+// snap radius is positive).
+func (b *Builder) ForceVertex(vertex Point) {
+	b.sites = append(b.sites, vertex)
+}
+
+// Reset clears all input data and layers so that the Builder can be reused.
+// The options are preserved.
+func (b *Builder) Reset() {
+	b.inputVertices = nil
+	b.inputEdges = nil
+	b.layers = nil
+	b.layerOptions = nil
+	b.layerBegins = nil
+	b.layerIsFullPolygonPredicates = nil
+	b.sites = nil
+	b.numForcedSites = 0
+	b.edgeSites = nil
+	b.snappingNeeded = false
+}
+
+// Build performs the requested edge splitting, snapping, simplification,
+// etc, and then assembles the resulting edges into the output layers.
+//
+// Returns an error if any problem occurs (such as the input edges not
+// forming polygons when a PolygonLayer is used). All layers are built
+// even if an error occurs; the first error encountered is returned.
+//
+// Build resets the Builder so that it can be reused.
+func (b *Builder) Build() error {
+	defer b.Reset()
+
+	if snapRadius := b.opts.SnapFunction.SnapRadius(); snapRadius > maxSnapRadius {
+		return fmt.Errorf("snap radius is too large (%v > %v)", snapRadius, maxSnapRadius)
+	}
+
+	// Mark the end of the last layer.
+	b.layerBegins = append(b.layerBegins, len(b.inputEdges))
+
+	if b.snappingRequested && !b.opts.Idempotent {
+		b.snappingNeeded = true
+	}
+	if err := b.chooseSites(); err != nil {
+		return err
+	}
+	return b.buildLayers()
+}
+
+// chooseSites selects the set of output vertices ("sites") and computes
+// the sites that are near each input edge.
+func (b *Builder) chooseSites() error {
+	if len(b.inputVertices) == 0 {
+		return nil
+	}
+
+	inputEdgeIndex := NewShapeIndex()
+	inputEdgeIndex.Add(&builderInputEdgeShape{b})
+	if b.opts.SplitCrossingEdges {
+		b.addEdgeCrossings(inputEdgeIndex)
+	}
+	if b.snappingRequested {
+		siteIndex := newBuilderPointIndex(b.minSiteSeparationCA)
+		b.addForcedSites(siteIndex)
+		if err := b.chooseInitialSites(siteIndex); err != nil {
+			return err
+		}
+		b.collectSiteEdges()
+	}
+	if b.snappingNeeded {
+		return b.addExtraSites(inputEdgeIndex)
+	}
+	b.chooseAllVerticesAsSites()
+	return nil
+}
+
+// chooseAllVerticesAsSites uses the input vertices as the sites. This is
+// used when no snapping is needed.
+func (b *Builder) chooseAllVerticesAsSites() {
+	// Sort the input vertices, discard duplicates, and update the input edges
+	// to refer to the pruned vertex list. (We sort in the same order used by
+	// chooseInitialSites to avoid inconsistencies in tests.)
+	sorted := b.sortInputVertices()
+	vmap := make([]int32, len(b.inputVertices))
+	b.sites = make([]Point, 0, len(b.inputVertices))
+	for in := 0; in < len(sorted); {
+		site := b.inputVertices[sorted[in]]
+		vmap[sorted[in]] = int32(len(b.sites))
+		for in++; in < len(sorted) && b.inputVertices[sorted[in]] == site; in++ {
+			vmap[sorted[in]] = int32(len(b.sites))
+		}
+		b.sites = append(b.sites, site)
+	}
+	b.inputVertices = b.sites
+	for i, e := range b.inputEdges {
+		b.inputEdges[i] = builderInputEdge{vmap[e.first], vmap[e.second]}
+	}
+}
+
+// sortInputVertices returns the input vertex ids sorted by CellID, breaking
+// ties by comparing the points themselves.
+func (b *Builder) sortInputVertices() []int32 {
+	keys := make([]CellID, len(b.inputVertices))
+	ids := make([]int32, len(b.inputVertices))
+	for i, v := range b.inputVertices {
+		keys[i] = cellIDFromPoint(v)
+		ids[i] = int32(i)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, c := ids[i], ids[j]
+		if keys[a] != keys[c] {
+			return keys[a] < keys[c]
+		}
+		return b.inputVertices[a].Cmp(b.inputVertices[c].Vector) < 0
+	})
+	return ids
+}
+
+// addEdgeCrossings finds all pairs of input edges that cross at an interior
+// point and adds the intersection points as new input vertices.
+func (b *Builder) addEdgeCrossings(index *ShapeIndex) {
+	// We need to build a list of intersections and add it to inputVertices.
+	// Note that all intersections must be added before we start choosing sites.
+	shape := index.Shape(0)
+	query := NewCrossingEdgeQuery(index)
+	var newVertices []Point
+	for i, e := range b.inputEdges {
+		a0, a1 := b.inputVertices[e.first], b.inputVertices[e.second]
+		for _, j := range query.Crossings(a0, a1, shape, CrossingTypeInterior) {
+			// Each crossing is found twice; only keep one of them.
+			if j <= i {
+				continue
+			}
+			f := b.inputEdges[j]
+			newVertices = append(newVertices, Intersection(a0, a1,
+				b.inputVertices[f.first], b.inputVertices[f.second]))
+		}
+	}
+	if len(newVertices) == 0 {
+		return
+	}
+
+	b.snappingNeeded = true
+	for _, v := range newVertices {
+		b.addVertex(v)
+	}
+}
+
+// addForcedSites sorts the forced sites, removes duplicates, and adds them
+// to the given site index.
+func (b *Builder) addForcedSites(siteIndex *builderPointIndex) {
+	sort.Slice(b.sites, func(i, j int) bool { return b.sites[i].Cmp(b.sites[j].Vector) < 0 })
+	out := 0
+	for i, s := range b.sites {
+		if i > 0 && s == b.sites[out-1] {
+			continue
+		}
+		b.sites[out] = s
+		out++
+	}
+	b.sites = b.sites[:out]
+	for id, s := range b.sites {
+		siteIndex.add(s, int32(id))
+	}
+	b.numForcedSites = len(b.sites)
+}
+
+// isForced reports whether the given site was added using ForceVertex.
+func (b *Builder) isForced(v int32) bool {
+	return int(v) < b.numForcedSites
+}
+
+// chooseInitialSites applies the snap function to each input vertex and
+// adds it as a new site unless an existing site is closer than the minimum
+// vertex separation.
+func (b *Builder) chooseInitialSites(siteIndex *builderPointIndex) error {
+	// NOTE(ericv): There are actually two reasonable algorithms, which we call
+	// "snap first" (the one above) and "snap last". The latter checks for each
+	// input vertex whether any existing site is closer than SnapRadius, and
+	// only then applies the SnapFunction and adds a new site. "Snap last"
+	// can yield slightly fewer sites in some cases, but it is also more
+	// expensive and can produce surprising results. For example, if you snap
+	// the polyline "0:0, 0:0.7" using IntLatLngSnapper(0), the result is
+	// "0:0, 0:0" rather than the expected "0:0, 0:1", because the snap radius
+	// is approximately sqrt(2) degrees and therefore it is legal to snap both
+	// input points to "0:0". "Snap first" produces "0:0, 0:1" as expected.
+	for _, id := range b.sortInputVertices() {
+		vertex := b.inputVertices[id]
+		site, err := b.snapSite(vertex)
+		if err != nil {
+			return err
+		}
+		// If any vertex moves when snapped, the output cannot be idempotent.
+		b.snappingNeeded = b.snappingNeeded || site != vertex
+
+		addSite := true
+		if b.siteSnapRadiusCA == 0 {
+			addSite = len(b.sites) == 0 || site != b.sites[len(b.sites)-1]
+		} else {
+			// The index returns candidates conservatively, so we need to
+			// check the distances using exact predicates.
+			for _, c := range siteIndex.candidates(site) {
+				p := b.sites[c]
+				if CompareDistance(site, p, b.minSiteSeparationCA) <= 0 {
+					addSite = false
+					// This pair of sites is too close. If the sites are
+					// distinct, then the output cannot be idempotent.
+					b.snappingNeeded = b.snappingNeeded || site != p
+				}
+			}
+		}
+		if addSite {
+			siteIndex.add(site, int32(len(b.sites)))
+			b.sites = append(b.sites, site)
+		}
+	}
+	return nil
+}
+
+// snapSite returns the snapped location of the given point, or an error if
+// the snap function moved it further than the snap radius.
+func (b *Builder) snapSite(point Point) (Point, error) {
+	if !b.snappingRequested {
+		return point, nil
+	}
+	site := b.opts.SnapFunction.SnapPoint(point)
+	if distMoved := ChordAngleBetweenPoints(site, point); distMoved > b.siteSnapRadiusCA {
+		return site, fmt.Errorf("snap function moved vertex (%v) by %v, which is more than the specified snap radius of %v",
+			point, distMoved.Angle(), b.siteSnapRadiusCA.Angle())
+	}
+	return site, nil
+}
+
+// collectSiteEdges finds all the sites near each input edge, sorted by
+// their distance from the edge's first vertex.
+func (b *Builder) collectSiteEdges() {
+	siteIndex := NewShapeIndex()
+	sites := make(PointVector, len(b.sites))
+	copy(sites, b.sites)
+	siteIndex.Add(&sites)
+
+	opts := NewClosestEdgeQueryOptions().IncludeInteriors(false)
+	opts.common = opts.common.ClosestConservativeDistanceLimit(b.edgeSiteQueryRadiusCA)
+	query := NewClosestEdgeQuery(siteIndex, opts)
+
+	b.edgeSites = make([][]int32, len(b.inputEdges))
+	for e, edge := range b.inputEdges {
+		v0, v1 := b.inputVertices[edge.first], b.inputVertices[edge.second]
+		results := query.FindEdges(NewMinDistanceToEdgeTarget(Edge{v0, v1}))
+		sites := make([]int32, 0, len(results))
+		for _, r := range results {
+			sites = append(sites, r.EdgeID())
+			p := b.sites[r.EdgeID()]
+			if !b.snappingNeeded && r.Distance() < b.minEdgeSiteSeparationCALimit &&
+				p != v0 && p != v1 &&
+				CompareEdgeDistance(p, v0, v1, b.minEdgeSiteSeparationCA) < 0 {
+				b.snappingNeeded = true
+			}
+		}
+		b.sortSitesByDistance(v0, sites)
+		b.edgeSites[e] = sites
+	}
+}
+
+// sortSitesByDistance sorts the given sites in increasing order of distance
+// to X.
+func (b *Builder) sortSitesByDistance(x Point, sites []int32) {
+	sort.SliceStable(sites, func(i, j int) bool {
+		return CompareDistances(x, b.sites[sites[i]], b.sites[sites[j]]) < 0
+	})
+}
+
+// addExtraSites snaps every input edge and adds extra sites wherever a
+// snapped edge deviates too far from its input edge or passes too close to
+// a site that it does not snap to.
+func (b *Builder) addExtraSites(inputEdgeIndex *ShapeIndex) error {
+	// Note that we could save some work when building the layers by saving
+	// the snapped edge chains here, but currently this is not worthwhile
+	// since snapEdge accounts for only a small fraction of the runtime.
+	var snapQueue []int32
+	for maxE := range b.inputEdges {
+		snapQueue = append(snapQueue, int32(maxE))
+		for len(snapQueue) > 0 {
+			e := snapQueue[len(snapQueue)-1]
+			snapQueue = snapQueue[:len(snapQueue)-1]
+			chain := b.snapEdge(e)
+			var err error
+			snapQueue, err = b.maybeAddExtraSites(e, int32(maxE), chain, inputEdgeIndex, snapQueue)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// maybeAddExtraSites checks the snapped chain of the given input edge and
+// adds an extra site if necessary. Any edges that need to be resnapped as
+// a result are appended to snapQueue.
+func (b *Builder) maybeAddExtraSites(edgeID, maxEdgeID int32, chain []int32,
+	inputEdgeIndex *ShapeIndex, snapQueue []int32) ([]int32, error) {
+	// The snapped chain is always a *subsequence* of the nearby sites
+	// (edgeSites), so we walk through the two arrays in parallel looking for
+	// sites that weren't snapped. We also keep track of the current snapped
+	// edge, since it is the only edge that can be too close.
+	i := 0
+	for _, id := range b.edgeSites[edgeID] {
+		if id == chain[i] {
+			i++
+			if i == len(chain) {
+				break
+			}
+			// Check whether this snapped edge deviates too far from its original
+			// position. If so, we split the edge by adding an extra site.
+			v0, v1 := b.sites[chain[i-1]], b.sites[chain[i]]
+			if ChordAngleBetweenPoints(v0, v1) < b.minEdgeLengthToSplitCA {
+				continue
+			}
+
+			edge := b.inputEdges[edgeID]
+			a0, a1 := b.inputVertices[edge.first], b.inputVertices[edge.second]
+			if !IsEdgeBNearEdgeA(a0, a1, v0, v1, b.maxEdgeDeviation) {
+				// Add a new site on the input edge, positioned so that it splits
+				// the snapped edge into two approximately equal pieces. Then we
+				// find all the edges near the new site (including this one) and
+				// add them to the snap queue.
+				//
+				// Note that with large snap radii, it is possible that the
+				// snapped edge wraps around *away* from the input edge (i.e. the
+				// angle between the input edge and the snapped edge is greater
+				// than 90 degrees). For example, suppose the input edge is
+				// defined by two points A and B that are 10 degrees apart, and
+				// the snapped edge is defined by two sites whose projections onto
+				// the input edge are in the opposite order. Using the midpoint of
+				// the projections handles this case correctly.
+				mid := Point{Project(v0, a0, a1).Add(Project(v1, a0, a1).Vector).Normalize()}
+				newSite, err := b.separationSite(mid, v0, v1, edgeID)
+				if err != nil {
+					return snapQueue, err
+				}
+				return b.addExtraSite(newSite, maxEdgeID, inputEdgeIndex, snapQueue), nil
+			}
+		} else if i > 0 && int(id) >= b.numForcedSites {
+			// Check whether this "site to avoid" is closer to the snapped edge
+			// than to the input edge. If so, it is necessary to add a new site
+			// in order to maintain topology.
+			siteToAvoid := b.sites[id]
+			v0, v1 := b.sites[chain[i-1]], b.sites[chain[i]]
+			if CompareEdgeDistance(siteToAvoid, v0, v1, b.minEdgeSiteSeparationCA) < 0 {
+				// A snapped edge can only approach a site too closely when all
+				// of the nearby sites are sufficiently far from the input edge.
+				// We fill the gap by adding a new site close to the site to
+				// avoid.
+				newSite, err := b.separationSite(siteToAvoid, v0, v1, edgeID)
+				if err != nil {
+					return snapQueue, err
+				}
+				return b.addExtraSite(newSite, maxEdgeID, inputEdgeIndex, snapQueue), nil
+			}
+		}
+	}
+	return snapQueue, nil
+}
+
+// addExtraSite adds a new site, then updates edgeSites for every input edge
+// that is close to it and adds those edges to snapQueue if they have
+// already been snapped.
+func (b *Builder) addExtraSite(newSite Point, maxEdgeID int32,
+	inputEdgeIndex *ShapeIndex, snapQueue []int32) []int32 {
+	newSiteID := int32(len(b.sites))
+	b.sites = append(b.sites, newSite)
+
+	// Find all edges whose distance is <= edgeSiteQueryRadiusCA.
+	opts := NewClosestEdgeQueryOptions().IncludeInteriors(false)
+	opts.common = opts.common.ClosestConservativeDistanceLimit(b.edgeSiteQueryRadiusCA)
+	query := NewClosestEdgeQuery(inputEdgeIndex, opts)
+	for _, r := range query.FindEdges(NewMinDistanceToPointTarget(newSite)) {
+		e := r.EdgeID()
+		b.edgeSites[e] = append(b.edgeSites[e], newSiteID)
+		b.sortSitesByDistance(b.inputVertices[b.inputEdges[e].first], b.edgeSites[e])
+		if e <= maxEdgeID {
+			snapQueue = append(snapQueue, e)
+		}
+	}
+	return snapQueue
+}
+
+// separationSite returns a new site on the given input edge that fills the
+// gap in coverage between the snapped sites v0 and v1, located as close
+// as possible to siteToAvoid.
+func (b *Builder) separationSite(siteToAvoid, v0, v1 Point, inputEdgeID int32) (Point, error) {
+	// Define the "coverage disc" of a site S to be the disc centered at S with
+	// radius edgeSnapRadius. Similarly, define the "coverage interval" of S for
+	// an edge XY to be the intersection of XY with the coverage disc of S. The
+	// Snapper implementations guarantee that the only way that a snapped
+	// edge can be closer than MinEdgeVertexSeparation to a non-snapped
+	// site (i.e., siteToAvoid) is if there is a gap in the coverage of XY
+	// near this site. We can fix this problem simply by adding a new site to
+	// fill this gap, located as closely as possible to the site to avoid.
+	//
+	// To calculate the coverage gap, we look at the two snapped sites on
+	// either side of siteToAvoid, and find the endpoints of their coverage
+	// intervals. Then we place a new site in the gap, located as closely as
+	// possible to the site to avoid. Note that the new site may move when it
+	// is snapped by the SnapFunction, but it is guaranteed not to move by
+	// more than the snap radius and therefore its coverage interval will
+	// still intersect the gap.
+	edge := b.inputEdges[inputEdgeID]
+	x, y := b.inputVertices[edge.first], b.inputVertices[edge.second]
+	xyDir := y.Sub(x.Vector)
+	n := x.PointCross(y)
+	newSite := Project(siteToAvoid, x, y)
+	gapMin := b.coverageEndpoint(v0, n)
+	gapMax := b.coverageEndpoint(v1, Point{n.Mul(-1)})
+	if newSite.Sub(gapMin.Vector).Dot(xyDir) < 0 {
+		newSite = gapMin
+	} else if gapMax.Sub(newSite.Vector).Dot(xyDir) < 0 {
+		newSite = gapMax
+	}
+	return b.snapSite(newSite)
+}
+
+// coverageEndpoint intersects the edge with normal N with the disc of radius
+// edgeSnapRadius around the site P, and returns the intersection point that
+// is further along the edge in the direction of its orientation.
+func (b *Builder) coverageEndpoint(p, n Point) Point {
+	// Consider the plane perpendicular to P that cuts off a spherical cap of
+	// radius edgeSnapRadius. This plane intersects the plane through the edge
+	// XY (perpendicular to N) along a line, and that line intersects the unit
+	// sphere at two points Q and R, and we want to return the point R that is
+	// further along the edge XY toward Y.
+	//
+	// Let M be the midpoint of QR. This is the point along QR that is closest
+	// to P. We can now express R as the sum of two perpendicular vectors OM
+	// and MR in the plane XY. Vector MR is in the direction N x P, while
+	// vector OM is in the direction (N x P) x N, where N = X x Y.
+	//
+	// The length of OM can be found using the Pythagorean theorem on triangle
+	// OPM, and the length of MR can be found using the Pythagorean theorem on
+	// triangle OMR.
+	//
+	// In the calculations below, we save some work by scaling all the vectors
+	// by |N x P|^2, and normalizing at the end.
+	n2 := n.Norm2()
+	nDp := n.Dot(p.Vector)
+	nXp := n.Cross(p.Vector)
+	nXpXn := p.Mul(n2).Sub(n.Mul(nDp))
+	om := nXpXn.Mul(math.Sqrt(1 - b.edgeSnapRadiusSin2))
+	mr2 := b.edgeSnapRadiusSin2*n2 - nDp*nDp
+
+	// MR is constructed so that it points toward Y (rather than X).
+	mr := nXp.Mul(math.Sqrt(math.Max(0, mr2)))
+	return Point{om.Add(mr).Normalize()}
+}
+
+// snapEdge returns the sequence of sites that the given input edge snaps to.
+func (b *Builder) snapEdge(e int32) []int32 {
+	edge := b.inputEdges[e]
+	if !b.snappingNeeded {
+		return []int32{edge.first, edge.second}
+	}
+
+	x, y := b.inputVertices[edge.first], b.inputVertices[edge.second]
+
+	// Now iterate through the sites. We keep track of the sequence of sites
+	// that are visited.
+	var chain []int32
+	for _, siteID := range b.edgeSites[e] {
+		c := b.sites[siteID]
+		// Skip any sites that are too far away. (There will be some of these,
+		// because we also keep track of "sites to avoid".)
+		if CompareEdgeDistance(c, x, y, b.edgeSnapRadiusCA) > 0 {
+			continue
+		}
+		// Check whether the new site C excludes the previous site B. If so,
+		// repeat with the previous site, and so on.
+		addSiteC := true
+		for ; len(chain) > 0; chain = chain[:len(chain)-1] {
+			bp := b.sites[chain[len(chain)-1]]
+
+			// First, check whether B and C are so far apart that their clipped
+			// Voronoi regions can't intersect.
+			if ChordAngleBetweenPoints(bp, c) >= b.maxAdjacentSiteSeparationCA {
+				break
+			}
+
+			// Otherwise, we want to check whether site C prevents the Voronoi
+			// region of B from intersecting XY, or vice versa. This can be
+			// determined by computing the "coverage interval" (the segment of XY
+			// intersected by the coverage disc of radius snapRadius) for each
+			// site. If the coverage interval of one site contains the coverage
+			// interval of the other, then the contained site can be excluded.
+			result := VoronoiSiteExclusion(bp, c, x, y, b.edgeSnapRadiusCA)
+			if result == ExcludedFirst {
+				continue // Site B excluded by C.
+			}
+			if result == ExcludedSecond {
+				addSiteC = false // Site C is excluded by B.
+				break
+			}
+
+			// Otherwise check whether the previous site A is close enough to B
+			// and C that it might further clip the Voronoi region of B.
+			if len(chain) < 2 {
+				break
+			}
+			a := b.sites[chain[len(chain)-2]]
+			if ChordAngleBetweenPoints(a, c) >= b.maxAdjacentSiteSeparationCA {
+				break
+			}
+
+			// If triangles ABC and XYB have the same orientation, the
+			// circumcenter Z of ABC is guaranteed to be on the same side of XY
+			// as B.
+			xyb := RobustSign(x, y, bp)
+			if RobustSign(a, bp, c) == xyb {
+				break // The circumcenter is on the same side as B but further away.
+			}
+
+			// Other possible optimizations:
+			//  - if AB > maxAdjacentSiteSeparationCA then keep B.
+			//  - if d(B, XY) < 0.5 * min(AB, BC) then keep B.
+
+			// If the circumcenter of ABC is on the same side of XY as B, then B
+			// is excluded by A and C combined. Otherwise B is needed and we
+			// can exit.
+			if EdgeCircumcenterSign(x, y, a, bp, c) != int(xyb) {
+				break
+			}
+		}
+		if addSiteC {
+			chain = append(chain, siteID)
+		}
+	}
+	return chain
+}
+
+// buildLayers snaps the edges of every layer, processes them according to
+// the layer's GraphOptions, and passes the resulting graphs to the layers.
+func (b *Builder) buildLayers() error {
+	// Each output edge has an "input edge id set id" (an int32) representing
+	// the set of input edge ids that were snapped to this edge. The actual
+	// input edge ids can be retrieved using the lexicon.
+	lexicon := newIDSetLexicon()
+	layerEdges := make([][]GraphEdge, len(b.layers))
+	layerInputEdgeIDs := make([][]int32, len(b.layers))
+	for i := range b.layers {
+		layerEdges[i], layerInputEdgeIDs[i] = b.addSnappedEdges(
+			b.layerBegins[i], b.layerBegins[i+1], b.layerOptions[i], lexicon)
+	}
+
+	// The errors generated by processEdges are really warnings, so we
+	// simply record them and continue.
+	var firstErr error
+	for i := range b.layers {
+		if err := processEdges(&b.layerOptions[i], &layerEdges[i], &layerInputEdgeIDs[i], lexicon); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i, layer := range b.layers {
+		g := newGraph(b.layerOptions[i], b.sites, layerEdges[i], layerInputEdgeIDs[i],
+			lexicon, b.layerIsFullPolygonPredicates[i])
+		if err := layer.Build(g); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// addSnappedEdges snaps the input edges in the range [begin, end) and
+// returns the resulting edges along with their input edge id set ids.
+func (b *Builder) addSnappedEdges(begin, end int, opts GraphOptions, lexicon *idSetLexicon) ([]GraphEdge, []int32) {
+	discardDegenerateEdges := opts.DegenerateEdges == DegenerateEdgesDiscard
+	var edges []GraphEdge
+	var inputEdgeIDs []int32
+	addSnappedEdge := func(src, dst, id int32) {
+		edges = append(edges, GraphEdge{src, dst})
+		inputEdgeIDs = append(inputEdgeIDs, id)
+		if opts.EdgeType == EdgeTypeUndirected {
+			edges = append(edges, GraphEdge{dst, src})
+			// Automatically created edges do not have input edge ids.
+			inputEdgeIDs = append(inputEdgeIDs, emptySetID)
+		}
+	}
+	for e := begin; e < end; e++ {
+		id := lexicon.add(int32(e))
+		chain := b.snapEdge(int32(e))
+		if len(chain) == 0 {
+			continue
+		}
+		if len(chain) == 1 {
+			if discardDegenerateEdges {
+				continue
+			}
+			addSnappedEdge(chain[0], chain[0], id)
+			continue
+		}
+		for i := 1; i < len(chain); i++ {
+			addSnappedEdge(chain[i-1], chain[i], id)
+		}
+	}
+	return edges, inputEdgeIDs
+}
+
+// builderInputEdgeShape is a Shape that exposes the input edges of a
+// Builder so that they can be indexed.
+type builderInputEdgeShape struct {
+	b *Builder
+}
+
+func (s *builderInputEdgeShape) NumEdges() int { return len(s.b.inputEdges) }
+func (s *builderInputEdgeShape) Edge(id int) Edge {
+	e := s.b.inputEdges[id]
+	return Edge{s.b.inputVertices[e.first], s.b.inputVertices[e.second]}
+}
+func (s *builderInputEdgeShape) ReferencePoint() ReferencePoint { return OriginReferencePoint(false) }
+func (s *builderInputEdgeShape) NumChains() int                 { return len(s.b.inputEdges) }
+func (s *builderInputEdgeShape) Chain(chainID int) Chain        { return Chain{chainID, 1} }
+func (s *builderInputEdgeShape) ChainEdge(chainID, offset int) Edge {
+	return s.Edge(chainID)
+}
+func (s *builderInputEdgeShape) ChainPosition(edgeID int) ChainPosition {
+	return ChainPosition{edgeID, 0}
+}
+func (s *builderInputEdgeShape) Dimension() int    { return 1 }
+func (s *builderInputEdgeShape) IsEmpty() bool     { return defaultShapeIsEmpty(s) }
+func (s *builderInputEdgeShape) IsFull() bool      { return defaultShapeIsFull(s) }
+func (s *builderInputEdgeShape) typeTag() typeTag  { return typeTagNone }
+func (s *builderInputEdgeShape) privateInterface() {}
+
+// builderPointIndex is a simple index of sites that can find all the sites
+// within a fixed distance of a given point. Sites are bucketed by the cell
+// that contains them at a level chosen such that any disc of the given
+// radius is covered by the four cells that share the cell vertex closest to
+// its center (see Cap.CellUnionBound).
+type builderPointIndex struct {
+	level int
+	cells map[CellID][]int32
+}
+
+// newBuilderPointIndex returns a point index for queries with the given
+// (inclusive) radius.
+func newBuilderPointIndex(radius s1.ChordAngle) *builderPointIndex {
+	// Expand the radius to account for the errors in converting it to an
+	// angle and in the cell width bounds.
+	r := radius.Expanded(radius.MaxAngleError()).Angle() * (1 + 4*dblEpsilon)
+	return &builderPointIndex{
+		level: MinWidthMetric.MaxLevel(r.Radians()) - 1,
+		cells: make(map[CellID][]int32),
+	}
+}
+
+// add adds the given point with the given id to the index.
+func (p *builderPointIndex) add(point Point, id int32) {
+	key := p.key(cellIDFromPoint(point))
+	p.cells[key] = append(p.cells[key], id)
+}
+
+func (p *builderPointIndex) key(id CellID) CellID {
+	if p.level < 0 {
+		return id.Parent(0)
+	}
+	return id.Parent(p.level)
+}
+
+// candidates returns the ids of all points that might be within the index
+// radius of the target. The caller must check the distances exactly.
+func (p *builderPointIndex) candidates(target Point) []int32 {
+	var out []int32
+	if p.level < 0 {
+		for face := 0; face < 6; face++ {
+			out = append(out, p.cells[CellIDFromFace(face)]...)
+		}
+		return out
+	}
+	for _, id := range cellIDFromPoint(target).VertexNeighbors(p.level) {
+		out = append(out, p.cells[id]...)
+	}
+	return out
+}
+
+// errBuilderEdgesDoNotFormLoops is returned when the edges of a polygon
+// layer cannot be assembled into loops.
+var errBuilderEdgesDoNotFormLoops = errors.New("given edges do not form loops (indegree != outdegree)")
+
+// TODO(rsned): Differences from C++
+// Labels (SetLabel, PushLabel, PopLabel, ClearLabels)
+// SimplifyEdgeChains
+// check_all_site_crossings (needed when MaxEdgeDeviation is more than
+//   EdgeSnapRadius + MinEdgeVertexSeparation)
+// MemoryTracker
+// Vertex filtering for builders with many layers.
@@ -0,0 +1,1144 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// EdgeType indicates whether the input edges of a layer are directed or
+// undirected.
+//
+// Directed edges are used for polygons and polylines where the direction of
+// each edge matters. Undirected edges are represented internally as a pair
+// of directed edges (a "sibling pair") where only the edge in the input
+// direction is labeled with an input edge id.
+type EdgeType int
+
+// These are the EdgeType values.
+const (
+	EdgeTypeDirected EdgeType = iota
+	EdgeTypeUndirected
+)
+
+// DegenerateEdges controls how degenerate edges (i.e., an edge from a vertex
+// to itself) are handled. Such edges may be present in the input, or they
+// may be created when both endpoints of an edge are snapped to the same
+// output vertex.
+type DegenerateEdges int
+
+// These are the DegenerateEdges values.
+const (
+	// DegenerateEdgesDiscard discards all degenerate edges. This is useful
+	// for layers that do not support degeneracies, such as PolygonLayer.
+	DegenerateEdgesDiscard DegenerateEdges = iota
+
+	// DegenerateEdgesDiscardExcess discards all degenerate edges that are
+	// connected to non-degenerate edges and merges any remaining duplicate
+	// degenerate edges. This is useful for simplifying polygons while
+	// ensuring that loops that collapse to a single point are preserved.
+	DegenerateEdgesDiscardExcess
+
+	// DegenerateEdgesKeep keeps all degenerate edges. Be aware that this may
+	// create many redundant edges when simplifying geometry.
+	DegenerateEdgesKeep
+)
+
+// DuplicateEdges controls how duplicate edges (i.e., edges that are present
+// multiple times) are handled. Such edges may be present in the input, or
+// they can be created when vertices are snapped together.
+type DuplicateEdges int
+
+// These are the DuplicateEdges values.
+const (
+	// DuplicateEdgesMerge merges duplicate edges into a single edge. The
+	// input edge ids of the merged edge are the union of those of the
+	// duplicates.
+	DuplicateEdgesMerge DuplicateEdges = iota
+
+	// DuplicateEdgesKeep keeps all duplicate edges.
+	DuplicateEdgesKeep
+)
+
+// SiblingPairs controls how sibling edge pairs (i.e., pairs consisting of
+// an edge and its reverse edge) are handled. Layer types that define an
+// interior (e.g., polygons) normally discard such edge pairs since they do
+// not affect the result (i.e., they define a "loop" with no interior).
+type SiblingPairs int
+
+// These are the SiblingPairs values.
+const (
+	// SiblingPairsDiscard discards all sibling edge pairs.
+	SiblingPairsDiscard SiblingPairs = iota
+
+	// SiblingPairsDiscardExcess is like SiblingPairsDiscard, except that a
+	// single sibling pair is kept if the result would otherwise be empty.
+	// This is useful for polygons with degeneracies.
+	SiblingPairsDiscardExcess
+
+	// SiblingPairsKeep keeps sibling pairs. This can be used to create
+	// polylines that double back on themselves, or degenerate loops (with a
+	// layer type such as LaxPolygon).
+	SiblingPairsKeep
+
+	// SiblingPairsRequire requires that all edges have a sibling (and returns
+	// an error otherwise). This is useful with layer types that create a
+	// collection of adjacent polygons (a polygon mesh).
+	SiblingPairsRequire
+
+	// SiblingPairsCreate ensures that all edges have a sibling edge by
+	// creating them if necessary. This is useful with layer types that
+	// create polygon meshes.
+	SiblingPairsCreate
+)
+
+// GraphOptions are the options that determine how the edges of a layer are
+// processed before being passed to the layer as a Graph.
+//
+// Note that when SiblingPairs is SiblingPairsRequire or SiblingPairsCreate
+// and the EdgeType is undirected, half of the edges are discarded and the
+// EdgeType is changed to directed.
+type GraphOptions struct {
+	EdgeType        EdgeType
+	DegenerateEdges DegenerateEdges
+	DuplicateEdges  DuplicateEdges
+	SiblingPairs    SiblingPairs
+}
+
+// DefaultGraphOptions returns the default GraphOptions, which keep all
+// edges exactly as they were snapped.
+func DefaultGraphOptions() GraphOptions {
+	return GraphOptions{
+		EdgeType:        EdgeTypeDirected,
+		DegenerateEdges: DegenerateEdgesKeep,
+		DuplicateEdges:  DuplicateEdgesKeep,
+		SiblingPairs:    SiblingPairsKeep,
+	}
+}
+
+// GraphEdge is a directed edge of a Graph represented as a pair of vertex
+// ids.
+type GraphEdge struct {
+	First, Second int32
+}
+
+// reverse returns the edge in the opposite direction.
+func (e GraphEdge) reverse() GraphEdge {
+	return GraphEdge{e.Second, e.First}
+}
+
+// less reports whether this edge sorts before the other edge in
+// lexicographic order.
+func (e GraphEdge) less(o GraphEdge) bool {
+	if e.First != o.First {
+		return e.First < o.First
+	}
+	return e.Second < o.Second
+}
+
+// Graph is a read-only view of the edges of a Builder layer after snapping
+// and processing according to the layer's GraphOptions. Each edge is a
+// pair of vertex ids, and the edges are sorted in lexicographic order.
+// Each edge also has a set of input edge ids that were snapped to it.
+type Graph struct {
+	opts                   GraphOptions
+	vertices               []Point
+	edges                  []GraphEdge
+	inputEdgeIDSetIDs      []int32
+	inputEdgeIDSetLexicon  *idSetLexicon
+	isFullPolygonPredicate IsFullPolygonPredicate
+}
+
+// newGraph returns a new graph with the given vertices and (sorted) edges.
+func newGraph(opts GraphOptions, vertices []Point, edges []GraphEdge, inputEdgeIDSetIDs []int32,
+	lexicon *idSetLexicon, isFullPolygonPredicate IsFullPolygonPredicate) *Graph {
+	return &Graph{
+		opts:                   opts,
+		vertices:               vertices,
+		edges:                  edges,
+		inputEdgeIDSetIDs:      inputEdgeIDSetIDs,
+		inputEdgeIDSetLexicon:  lexicon,
+		isFullPolygonPredicate: isFullPolygonPredicate,
+	}
+}
+
+// Options returns the options used to build this graph.
+func (g *Graph) Options() GraphOptions { return g.opts }
+
+// NumVertices returns the number of vertices in the graph. Note that the
+// vertices may include some that are not used by any edge.
+func (g *Graph) NumVertices() int { return len(g.vertices) }
+
+// Vertex returns the vertex with the given id.
+func (g *Graph) Vertex(v int32) Point { return g.vertices[v] }
+
+// Vertices returns all the vertices of the graph.
+func (g *Graph) Vertices() []Point { return g.vertices }
+
+// NumEdges returns the number of edges in the graph.
+func (g *Graph) NumEdges() int { return len(g.edges) }
+
+// Edge returns the edge with the given id.
+func (g *Graph) Edge(e int32) GraphEdge { return g.edges[e] }
+
+// Edges returns all the edges of the graph in sorted order.
+func (g *Graph) Edges() []GraphEdge { return g.edges }
+
+// InputEdgeIDs returns the ids of the input edges that were snapped to the
+// given edge. Edges created automatically (e.g. the reverse edges of
+// undirected edges) have no input edge ids.
+func (g *Graph) InputEdgeIDs(e int32) []int32 {
+	return g.inputEdgeIDSetLexicon.idSet(g.inputEdgeIDSetIDs[e])
+}
+
+// MinInputEdgeID returns the minimum input edge id that was snapped to the
+// given edge, or math.MaxInt32 if there are none.
+func (g *Graph) MinInputEdgeID(e int32) int32 {
+	id := g.inputEdgeIDSetIDs[e]
+	if id >= 0 {
+		return id
+	}
+	minID := int32(math.MaxInt32)
+	for _, i := range g.inputEdgeIDSetLexicon.idSet(id) {
+		if i < minID {
+			minID = i
+		}
+	}
+	return minID
+}
+
+// IsFullPolygon reports whether a polygon layer with no edges should be
+// considered full rather than empty, as determined by the layer's
+// IsFullPolygonPredicate.
+func (g *Graph) IsFullPolygon() (bool, error) {
+	if g.isFullPolygonPredicate == nil {
+		return false, nil
+	}
+	return g.isFullPolygonPredicate(g)
+}
+
+// minInputEdgeIDs returns the minimum input edge id of every edge.
+func (g *Graph) minInputEdgeIDs() []int32 {
+	ids := make([]int32, len(g.edges))
+	for e := range g.edges {
+		ids[e] = g.MinInputEdgeID(int32(e))
+	}
+	return ids
+}
+
+// inputEdgeOrder returns the edge ids sorted by minimum input edge id,
+// breaking ties by edge id. This is the order in which the edges were
+// added to the Builder.
+func (g *Graph) inputEdgeOrder(minInputIDs []int32) []int32 {
+	order := make([]int32, len(minInputIDs))
+	for i := range order {
+		order[i] = int32(i)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return minInputIDs[order[i]] < minInputIDs[order[j]]
+	})
+	return order
+}
+
+// inEdgeIDs returns the edge ids sorted in lexicographic order by
+// (destination, origin). All of the incoming edges to each vertex form a
+// contiguous range.
+func (g *Graph) inEdgeIDs() []int32 {
+	ids := make([]int32, len(g.edges))
+	for i := range ids {
+		ids[i] = int32(i)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return g.edges[ids[i]].reverse().less(g.edges[ids[j]].reverse())
+	})
+	return ids
+}
+
+// siblingMap returns a map from each edge to its sibling (the edge in the
+// opposite direction). This requires that every edge has a sibling, i.e.
+// the edges are undirected or SiblingPairs is Require or Create.
+func (g *Graph) siblingMap() []int32 {
+	inEdges := g.inEdgeIDs()
+	g.makeSiblingMap(inEdges)
+	return inEdges
+}
+
+// makeSiblingMap converts the given result of inEdgeIDs into a sibling map.
+// This works because when every edge has a sibling, the sorted incoming
+// edges correspond one to one with the sorted outgoing edges.
+func (g *Graph) makeSiblingMap(inEdges []int32) {
+	if g.opts.EdgeType == EdgeTypeDirected || g.opts.DegenerateEdges == DegenerateEdgesDiscard {
+		return
+	}
+
+	// Each undirected degenerate edge is represented by two identical
+	// directed edges, which are siblings of each other.
+	for e := 0; e < len(g.edges); e++ {
+		v := g.edges[e].First
+		if g.edges[e].Second == v {
+			inEdges[e] = int32(e + 1)
+			inEdges[e+1] = int32(e)
+			e++
+		}
+	}
+}
+
+// vertexOutMap provides the outgoing edges of each vertex.
+type vertexOutMap struct {
+	edgeBegins []int32
+}
+
+func newVertexOutMap(g *Graph) *vertexOutMap {
+	m := &vertexOutMap{edgeBegins: make([]int32, 0, len(g.vertices)+1)}
+	e := int32(0)
+	for v := int32(0); v <= int32(len(g.vertices)); v++ {
+		for int(e) < len(g.edges) && g.edges[e].First < v {
+			e++
+		}
+		m.edgeBegins = append(m.edgeBegins, e)
+	}
+	return m
+}
+
+// degree returns the number of outgoing edges of v.
+func (m *vertexOutMap) degree(v int32) int {
+	return int(m.edgeBegins[v+1] - m.edgeBegins[v])
+}
+
+// edgeIDs returns the range of edge ids leaving v.
+func (m *vertexOutMap) edgeIDs(v int32) (begin, end int32) {
+	return m.edgeBegins[v], m.edgeBegins[v+1]
+}
+
+// vertexInMap provides the incoming edges of each vertex.
+type vertexInMap struct {
+	inEdgeIDs  []int32
+	edgeBegins []int32
+}
+
+func newVertexInMap(g *Graph) *vertexInMap {
+	m := &vertexInMap{
+		inEdgeIDs:  g.inEdgeIDs(),
+		edgeBegins: make([]int32, 0, len(g.vertices)+1),
+	}
+	e := 0
+	for v := int32(0); v <= int32(len(g.vertices)); v++ {
+		for e < len(m.inEdgeIDs) && g.edges[m.inEdgeIDs[e]].Second < v {
+			e++
+		}
+		m.edgeBegins = append(m.edgeBegins, int32(e))
+	}
+	return m
+}
+
+// degree returns the number of incoming edges of v.
+func (m *vertexInMap) degree(v int32) int {
+	return int(m.edgeBegins[v+1] - m.edgeBegins[v])
+}
+
+// edgeIDs returns the ids of the edges entering v.
+func (m *vertexInMap) edgeIDs(v int32) []int32 {
+	return m.inEdgeIDs[m.edgeBegins[v]:m.edgeBegins[v+1]]
+}
+
+// errBuilderMissingSiblings is returned when SiblingPairsRequire is used and
+// some edges do not have a sibling.
+var errBuilderMissingSiblings = errors.New("expected all input edges to have siblings, but some were missing")
+
+// leftTurnMap returns a map "m" such that for each incoming edge "e" at a
+// vertex, m[e] is the next outgoing edge in clockwise order around that
+// vertex. In other words, following the map turns as far left as possible
+// at each vertex. Degenerate edges are treated as an incoming edge followed
+// immediately by the same outgoing edge, and sibling pairs are treated as
+// degenerate loops (i.e. the map turns back along the sibling edge).
+//
+// Returns an error if the edges do not form loops (i.e. indegree !=
+// outdegree at some vertex). Entries for unmatched edges are -1.
+func (g *Graph) leftTurnMap(inEdgeIDs []int32) ([]int32, error) {
+	m := make([]int32, len(g.edges))
+	for i := range m {
+		m[i] = -1
+	}
+	if len(g.edges) == 0 {
+		return m, nil
+	}
+
+	type vertexEdge struct {
+		incoming bool
+		index    int32
+		endpoint int32
+		rank     int32
+	}
+
+	// Walk through the two sorted arrays of edges (outgoing and incoming) and
+	// gather all the edges incident to each vertex. Then we sort those edges
+	// and add an entry to the left turn map from each incoming edge to the
+	// immediately following outgoing edge in clockwise order.
+	var err error
+	var v0Edges []vertexEdge
+	var inEdges, outEdges []int32
+	numEdges := len(g.edges)
+	sentinel := GraphEdge{int32(len(g.vertices)), int32(len(g.vertices))}
+	out, in := 0, 0
+	outEdge := func() GraphEdge {
+		if out == numEdges {
+			return sentinel
+		}
+		return g.edges[out]
+	}
+	inEdge := func() GraphEdge {
+		if in == numEdges {
+			return sentinel
+		}
+		return g.edges[inEdgeIDs[in]].reverse()
+	}
+	minEdge := func() GraphEdge {
+		if o, i := outEdge(), inEdge(); i.less(o) {
+			return i
+		}
+		return outEdge()
+	}
+	for e := minEdge(); e != sentinel; {
+		// Gather all incoming and outgoing edges around vertex v0.
+		v0 := e.First
+		rank := int32(0)
+		for ; e.First == v0; e = minEdge() {
+			v1 := e.Second
+			outBegin, inBegin := out, in
+			for outEdge() == e {
+				out++
+			}
+			for inEdge() == e {
+				in++
+			}
+			if v1 != v0 {
+				for i := inBegin; i < in; i++ {
+					v0Edges = append(v0Edges, vertexEdge{true, int32(i), v1, rank})
+					rank++
+				}
+				for i := outBegin; i < out; i++ {
+					v0Edges = append(v0Edges, vertexEdge{false, int32(i), v1, rank})
+					rank++
+				}
+			} else {
+				// Each degenerate edge becomes an incoming edge immediately
+				// followed by an outgoing edge.
+				for i, j := inBegin, outBegin; j < out; i, j = i+1, j+1 {
+					v0Edges = append(v0Edges, vertexEdge{true, int32(i), v1, rank})
+					rank++
+					v0Edges = append(v0Edges, vertexEdge{false, int32(j), v1, rank})
+					rank++
+				}
+			}
+		}
+		if len(v0Edges) == 0 {
+			continue
+		}
+
+		// Sort the edges in clockwise order around v0, starting with the
+		// edges to the smallest endpoint.
+		minEndpoint := v0Edges[0].endpoint
+		sort.SliceStable(v0Edges, func(i, j int) bool {
+			a, b := v0Edges[i], v0Edges[j]
+			if a.endpoint == b.endpoint {
+				return a.rank < b.rank
+			}
+			if a.endpoint == minEndpoint {
+				return true
+			}
+			if b.endpoint == minEndpoint {
+				return false
+			}
+			return !OrderedCCW(g.vertices[a.endpoint], g.vertices[b.endpoint],
+				g.vertices[minEndpoint], g.vertices[v0])
+		})
+
+		// Match incoming with outgoing edges. We do this by keeping a stack of
+		// unmatched incoming edges. We also keep a stack of outgoing edges with
+		// no previous incoming edge, and match these at the end by wrapping
+		// around circularly to the start of the edge ordering.
+		for _, ve := range v0Edges {
+			if ve.incoming {
+				inEdges = append(inEdges, inEdgeIDs[ve.index])
+			} else if len(inEdges) > 0 {
+				m[inEdges[len(inEdges)-1]] = ve.index
+				inEdges = inEdges[:len(inEdges)-1]
+			} else {
+				outEdges = append(outEdges, ve.index) // Matched below.
+			}
+		}
+
+		// Pair up additional edges using the fact that the ordering is circular.
+		for i, j := 0, len(outEdges)-1; i < j; i, j = i+1, j-1 {
+			outEdges[i], outEdges[j] = outEdges[j], outEdges[i]
+		}
+		for len(outEdges) > 0 && len(inEdges) > 0 {
+			m[inEdges[len(inEdges)-1]] = outEdges[len(outEdges)-1]
+			outEdges = outEdges[:len(outEdges)-1]
+			inEdges = inEdges[:len(inEdges)-1]
+		}
+
+		// We only need to process unmatched incoming edges, since we are only
+		// responsible for creating left turn map entries for those edges.
+		if len(inEdges) > 0 && err == nil {
+			err = errBuilderEdgesDoNotFormLoops
+		}
+		inEdges = inEdges[:0]
+		outEdges = outEdges[:0]
+		v0Edges = v0Edges[:0]
+	}
+	return m, err
+}
+
+// LoopType indicates whether loops that share vertices are broken apart
+// when assembling the edges of a graph into loops.
+type LoopType int
+
+// These are the LoopType values.
+const (
+	// LoopTypeSimple breaks loops at repeated vertices, so that each loop
+	// is simple (contains no repeated vertices).
+	LoopTypeSimple LoopType = iota
+
+	// LoopTypeCircuit keeps loops intact even if they pass through the same
+	// vertex more than once (i.e. each loop is an edge circuit).
+	LoopTypeCircuit
+)
+
+// DirectedLoops assembles the edges of a directed graph into loops, where
+// each loop is returned as a sequence of edge ids. The interior of each
+// loop is on its left side. Loops are formed by turning as far left as
+// possible at each vertex.
+//
+// The loops are returned in a canonical order such that each loop starts
+// with the edge with the smallest input edge id (preferring the first edge
+// of a chain snapped from the same input edge), and the loops are sorted
+// by that id. This means that the output loops correspond to the input
+// loops as closely as possible.
+//
+// This requires that the edges are directed and degenerate edges are
+// discarded. Returns an error if the edges do not form loops.
+func (g *Graph) DirectedLoops(loopType LoopType) ([][]int32, error) {
+	leftTurnMap, err := g.leftTurnMap(g.inEdgeIDs())
+	if err != nil {
+		return nil, err
+	}
+	minInputIDs := g.minInputEdgeIDs()
+
+	// If we are breaking loops at repeated vertices, we maintain a map from
+	// vertex id to its position in "path".
+	var pathIndex []int
+	if loopType == LoopTypeSimple {
+		pathIndex = make([]int, len(g.vertices))
+		for i := range pathIndex {
+			pathIndex[i] = -1
+		}
+	}
+
+	// Visit edges in arbitrary order, and try to build a loop from each edge.
+	var loops [][]int32
+	var path []int32
+	for start := int32(0); int(start) < len(g.edges); start++ {
+		if leftTurnMap[start] < 0 {
+			continue
+		}
+
+		// Build a loop by making left turns at each vertex until we return to
+		// "start". We use leftTurnMap to keep track of which edges have
+		// already been visited by setting its entries to -1 as we go along. If
+		// we are building vertex cycles, then whenever we encounter a vertex
+		// that is already part of the path, we "peel off" a loop by removing
+		// those edges from the path so far.
+		for e := start; leftTurnMap[e] >= 0; {
+			path = append(path, e)
+			next := leftTurnMap[e]
+			leftTurnMap[e] = -1
+			if loopType == LoopTypeSimple {
+				pathIndex[g.edges[e].First] = len(path) - 1
+				if loopStart := pathIndex[g.edges[e].Second]; loopStart >= 0 {
+					// Peel off a loop from the path.
+					loop := append([]int32(nil), path[loopStart:]...)
+					path = path[:loopStart]
+					for _, e2 := range loop {
+						pathIndex[g.edges[e2].First] = -1
+					}
+					canonicalizeLoopOrder(minInputIDs, loop)
+					loops = append(loops, loop)
+				}
+			}
+			e = next
+		}
+		if loopType != LoopTypeSimple {
+			canonicalizeLoopOrder(minInputIDs, path)
+			loops = append(loops, path)
+			path = nil
+		}
+	}
+	canonicalizeVectorOrder(minInputIDs, loops)
+	return loops, nil
+}
+
+// canonicalizeLoopOrder rotates the given loop so that it starts with the
+// edge with the smallest input edge id. If several consecutive edges have
+// this id (e.g. because an input edge was split into a chain of edges),
+// the loop starts with the first edge of that chain.
+func canonicalizeLoopOrder(minInputIDs []int32, loop []int32) {
+	if len(loop) == 0 {
+		return
+	}
+	minID := minInputIDs[loop[0]]
+	for _, e := range loop {
+		if minInputIDs[e] < minID {
+			minID = minInputIDs[e]
+		}
+	}
+	pos := 0
+	n := len(loop)
+	for i := range loop {
+		if minInputIDs[loop[i]] == minID && minInputIDs[loop[(i+n-1)%n]] != minID {
+			pos = i
+			break
+		}
+	}
+	rotated := append(append([]int32(nil), loop[pos:]...), loop[:pos]...)
+	copy(loop, rotated)
+}
+
+// canonicalizeVectorOrder sorts the given loops or polylines by the
+// minimum input edge id of their first edge.
+func canonicalizeVectorOrder(minInputIDs []int32, chains [][]int32) {
+	sort.SliceStable(chains, func(i, j int) bool {
+		return minInputIDs[chains[i][0]] < minInputIDs[chains[j][0]]
+	})
+}
+
+// PolylineType indicates how the edges of a graph are assembled into
+// polylines.
+type PolylineType int
+
+// These are the PolylineType values.
+const (
+	// PolylineTypePath breaks polylines at every vertex with degree other
+	// than two (i.e. indegree and outdegree of one for directed edges), so
+	// that the polylines do not contain any repeated vertices (except that
+	// a polyline may be a closed loop).
+	PolylineTypePath PolylineType = iota
+
+	// PolylineTypeWalk builds the smallest possible number of polylines,
+	// which may contain repeated vertices. Each polyline is a walk through
+	// the graph that uses each edge exactly once.
+	PolylineTypeWalk
+)
+
+// Polylines assembles the edges of the graph into polylines, where each
+// polyline is returned as a sequence of edge ids. The polylines follow the
+// input edge order as closely as possible.
+//
+// This requires that degenerate edges are discarded. For undirected
+// graphs, only one edge of each sibling pair is used.
+func (g *Graph) Polylines(polylineType PolylineType) [][]int32 {
+	pb := newPolylineBuilder(g)
+	if polylineType == PolylineTypePath {
+		return pb.buildPaths()
+	}
+	return pb.buildWalks()
+}
+
+// polylineBuilder assembles the edges of a graph into polylines.
+type polylineBuilder struct {
+	g           *Graph
+	in          *vertexInMap
+	out         *vertexOutMap
+	siblingMap  []int32
+	minInputIDs []int32
+	directed    bool
+	edgesLeft   int
+	used        []bool
+
+	// excessUsed is a map of (outdegree(v) - indegree(v)) considering used
+	// edges only.
+	excessUsed map[int32]int
+}
+
+func newPolylineBuilder(g *Graph) *polylineBuilder {
+	pb := &polylineBuilder{
+		g:           g,
+		in:          newVertexInMap(g),
+		out:         newVertexOutMap(g),
+		minInputIDs: g.minInputEdgeIDs(),
+		directed:    g.opts.EdgeType == EdgeTypeDirected,
+		used:        make([]bool, len(g.edges)),
+		excessUsed:  make(map[int32]int),
+	}
+	pb.edgesLeft = len(g.edges)
+	if !pb.directed {
+		pb.edgesLeft /= 2
+		pb.siblingMap = append([]int32(nil), pb.in.inEdgeIDs...)
+		g.makeSiblingMap(pb.siblingMap)
+	}
+	return pb
+}
+
+// isInterior reports whether v can be in the interior of a path.
+func (pb *polylineBuilder) isInterior(v int32) bool {
+	if pb.directed {
+		return pb.in.degree(v) == 1 && pb.out.degree(v) == 1
+	}
+	return pb.out.degree(v) == 2
+}
+
+// excessDegree returns the number of edges that must start or end at v in
+// any decomposition of the graph into walks.
+func (pb *polylineBuilder) excessDegree(v int32) int {
+	if pb.directed {
+		return pb.out.degree(v) - pb.in.degree(v)
+	}
+	return pb.out.degree(v) % 2
+}
+
+// markUsed marks the given edge (and its sibling for undirected graphs) as
+// used.
+func (pb *polylineBuilder) markUsed(e int32) {
+	pb.used[e] = true
+	if !pb.directed {
+		pb.used[pb.siblingMap[e]] = true
+	}
+	pb.edgesLeft--
+}
+
+func (pb *polylineBuilder) buildPaths() [][]int32 {
+	// First build polylines starting at all the vertices that cannot be in the
+	// polyline interior (i.e., indegree != 1 or outdegree != 1 for directed
+	// edges, or degree != 2 for undirected edges). We consider the possible
+	// starting edges in input edge id order so that we preserve the input path
+	// direction even when undirected edges are used. (Undirected edges are
+	// represented by sibling pairs where only the edge in the input direction
+	// is labeled with an input edge id.)
+	var polylines [][]int32
+	edges := pb.g.inputEdgeOrder(pb.minInputIDs)
+	for _, e := range edges {
+		if !pb.used[e] && !pb.isInterior(pb.g.edges[e].First) {
+			polylines = append(polylines, pb.buildPath(e))
+		}
+	}
+
+	// If there are any edges left, they form non-intersecting loops. We build
+	// each loop and then canonicalize its edge order. We consider candidate
+	// starting edges in input edge id order in order to preserve the input
+	// direction of undirected loops. Even so, we still need to canonicalize
+	// the edge order to ensure that when an input edge is split into an edge
+	// chain, the loop does not start in the middle of such a chain.
+	for _, e := range edges {
+		if pb.edgesLeft == 0 {
+			break
+		}
+		if pb.used[e] {
+			continue
+		}
+		polyline := pb.buildPath(e)
+		canonicalizeLoopOrder(pb.minInputIDs, polyline)
+		polylines = append(polylines, polyline)
+	}
+
+	// Sort the polylines to correspond to the input order (if possible).
+	canonicalizeVectorOrder(pb.minInputIDs, polylines)
+	return polylines
+}
+
+// buildPath follows edges starting with e until reaching a vertex where
+// there is a choice about which way to go, or returning to the start.
+func (pb *polylineBuilder) buildPath(e int32) []int32 {
+	var polyline []int32
+	start := pb.g.edges[e].First
+	for {
+		polyline = append(polyline, e)
+		pb.markUsed(e)
+		v := pb.g.edges[e].Second
+		if !pb.isInterior(v) || v == start {
+			break
+		}
+		begin, end := pb.out.edgeIDs(v)
+		for e2 := begin; e2 < end; e2++ {
+			if !pb.used[e2] {
+				e = e2
+			}
+		}
+	}
+	return polyline
+}
+
+func (pb *polylineBuilder) buildWalks() [][]int32 {
+	// Note that some of this code is worst-case quadratic in the maximum vertex
+	// degree. This could be fixed with a few extra arrays, but it should not
+	// be a problem in practice.
+
+	// First, build polylines from all vertices where outdegree > indegree (for
+	// directed edges), or vertices with odd degree (for undirected edges).
+	var polylines [][]int32
+	edges := pb.g.inputEdgeOrder(pb.minInputIDs)
+	for _, e := range edges {
+		if pb.used[e] {
+			continue
+		}
+		v := pb.g.edges[e].First
+		excess := pb.excessDegree(v)
+		if excess <= 0 {
+			continue
+		}
+		excess -= pb.excessUsed[v]
+		if (pb.directed && excess <= 0) || (!pb.directed && excess%2 == 0) {
+			continue
+		}
+		pb.excessUsed[v]++
+		polyline := pb.buildWalk(v)
+		pb.excessUsed[pb.g.edges[polyline[len(polyline)-1]].Second]--
+		polylines = append(polylines, polyline)
+	}
+
+	// Now all vertices have outdegree == indegree (taking into account the
+	// polylines that have already been built). Any edges that remain are part
+	// of loops. We first extend the existing polylines as far as possible by
+	// adding loops to them, and then build loops from the remaining edges.
+	for i := range polylines {
+		polylines[i] = pb.maximizeWalk(polylines[i])
+	}
+	for _, e := range edges {
+		if pb.edgesLeft == 0 {
+			break
+		}
+		if pb.used[e] {
+			continue
+		}
+		polyline := pb.maximizeWalk(pb.buildWalk(pb.g.edges[e].First))
+		canonicalizeLoopOrder(pb.minInputIDs, polyline)
+		polylines = append(polylines, polyline)
+	}
+
+	// Sort the polylines to correspond to the input order (if possible).
+	canonicalizeVectorOrder(pb.minInputIDs, polylines)
+	return polylines
+}
+
+// buildWalk builds a walk starting at v by repeatedly following the unused
+// outgoing edge with the smallest input edge id until no unused edges
+// remain.
+func (pb *polylineBuilder) buildWalk(v int32) []int32 {
+	var polyline []int32
+	for {
+		// Follow the edge with the smallest input edge id.
+		bestEdge := int32(-1)
+		bestOutID := int32(math.MaxInt32)
+		begin, end := pb.out.edgeIDs(v)
+		for e := begin; e < end; e++ {
+			if pb.used[e] || pb.minInputIDs[e] >= bestOutID {
+				continue
+			}
+			bestOutID = pb.minInputIDs[e]
+			bestEdge = e
+		}
+		if bestEdge < 0 {
+			// Edges without input edge ids (e.g. automatically created
+			// siblings) are followed if nothing better is available.
+			for e := begin; e < end; e++ {
+				if !pb.used[e] {
+					bestEdge = e
+					break
+				}
+			}
+		}
+		if bestEdge < 0 {
+			return polyline
+		}
+		polyline = append(polyline, bestEdge)
+		pb.markUsed(bestEdge)
+		v = pb.g.edges[bestEdge].Second
+	}
+}
+
+// maximizeWalk extends the given walk by splicing in loops that start at
+// any of its vertices.
+func (pb *polylineBuilder) maximizeWalk(polyline []int32) []int32 {
+	// Examine all vertices of the polyline and check whether there are any
+	// unused outgoing edges. If so, then build a loop starting at that vertex
+	// and insert it into the polyline. (The walk is guaranteed to be a loop
+	// because this method is only called when all vertices have equal numbers
+	// of unused incoming and outgoing edges.)
+	for i := 0; i <= len(polyline); i++ {
+		var v int32
+		if i == 0 {
+			v = pb.g.edges[polyline[0]].First
+		} else {
+			v = pb.g.edges[polyline[i-1]].Second
+		}
+		begin, end := pb.out.edgeIDs(v)
+		for e := begin; e < end; e++ {
+			if !pb.used[e] {
+				loop := pb.buildWalk(v)
+				rest := append(loop, polyline[i:]...)
+				polyline = append(polyline[:i], rest...)
+				break
+			}
+		}
+	}
+	return polyline
+}
+
+// processEdges processes the given edges according to the given options
+// (discarding degenerate edges, merging duplicates, etc), and updates
+// inputIDs to match. The edges are returned in sorted order.
+//
+// Certain values of SiblingPairs discard half of the edges and change the
+// EdgeType to directed, which is reflected in opts.
+func processEdges(opts *GraphOptions, edges *[]GraphEdge, inputIDs *[]int32, lexicon *idSetLexicon) error {
+	p := newEdgeProcessor(*opts, *edges, *inputIDs, lexicon)
+	err := p.run()
+	*edges, *inputIDs = p.newEdges, p.newInputIDs
+
+	// Certain values of SiblingPairs discard half of the edges and change
+	// the EdgeType to directed (see the description of GraphOptions).
+	if opts.SiblingPairs == SiblingPairsRequire || opts.SiblingPairs == SiblingPairsCreate {
+		opts.EdgeType = EdgeTypeDirected
+	}
+	return err
+}
+
+// edgeProcessor implements processEdges.
+type edgeProcessor struct {
+	opts     GraphOptions
+	edges    []GraphEdge
+	inputIDs []int32
+	lexicon  *idSetLexicon
+	outEdges []int32
+	inEdges  []int32
+
+	newEdges    []GraphEdge
+	newInputIDs []int32
+}
+
+func newEdgeProcessor(opts GraphOptions, edges []GraphEdge, inputIDs []int32, lexicon *idSetLexicon) *edgeProcessor {
+	p := &edgeProcessor{
+		opts:     opts,
+		edges:    edges,
+		inputIDs: inputIDs,
+		lexicon:  lexicon,
+		outEdges: make([]int32, len(edges)),
+		inEdges:  make([]int32, len(edges)),
+	}
+
+	// Sort the outgoing and incoming edges in lexicographic order. We use a
+	// stable sort to ensure that each undirected edge becomes a sibling pair,
+	// even if there are multiple identical input edges.
+	for i := range edges {
+		p.outEdges[i] = int32(i)
+		p.inEdges[i] = int32(i)
+	}
+	sort.SliceStable(p.outEdges, func(i, j int) bool {
+		return edges[p.outEdges[i]].less(edges[p.outEdges[j]])
+	})
+	sort.SliceStable(p.inEdges, func(i, j int) bool {
+		return edges[p.inEdges[i]].reverse().less(edges[p.inEdges[j]].reverse())
+	})
+	return p
+}
+
+func (p *edgeProcessor) addEdge(edge GraphEdge, inputEdgeIDSetID int32) {
+	p.newEdges = append(p.newEdges, edge)
+	p.newInputIDs = append(p.newInputIDs, inputEdgeIDSetID)
+}
+
+func (p *edgeProcessor) addEdges(numEdges int, edge GraphEdge, inputEdgeIDSetID int32) {
+	for i := 0; i < numEdges; i++ {
+		p.addEdge(edge, inputEdgeIDSetID)
+	}
+}
+
+func (p *edgeProcessor) copyEdges(outBegin, outEnd int) {
+	for i := outBegin; i < outEnd; i++ {
+		p.addEdge(p.edges[p.outEdges[i]], p.inputIDs[p.outEdges[i]])
+	}
+}
+
+func (p *edgeProcessor) mergeInputIDs(outBegin, outEnd int) int32 {
+	if outEnd-outBegin == 1 {
+		return p.inputIDs[p.outEdges[outBegin]]
+	}
+	if outEnd == outBegin {
+		return emptySetID
+	}
+	var ids []int32
+	for i := outBegin; i < outEnd; i++ {
+		ids = append(ids, p.lexicon.idSet(p.inputIDs[p.outEdges[i]])...)
+	}
+	return p.lexicon.add(ids...)
+}
+
+func (p *edgeProcessor) run() error {
+	numEdges := len(p.edges)
+	if numEdges == 0 {
+		return nil
+	}
+
+	// Walk through the two sorted arrays performing a merge join. For each
+	// edge, gather all the duplicate copies of the edge in both directions
+	// (outgoing and incoming). Then decide what to do based on the options
+	// and how many copies of the edge there are in each direction.
+	var err error
+	opts := p.opts
+	sentinel := GraphEdge{math.MaxInt32, math.MaxInt32}
+	out, in := 0, 0
+	outEdge := func() GraphEdge {
+		if out == numEdges {
+			return sentinel
+		}
+		return p.edges[p.outEdges[out]]
+	}
+	inEdge := func() GraphEdge {
+		if in == numEdges {
+			return sentinel
+		}
+		return p.edges[p.inEdges[in]].reverse()
+	}
+	for {
+		edge := outEdge()
+		if i := inEdge(); i.less(edge) {
+			edge = i
+		}
+		if edge == sentinel {
+			break
+		}
+
+		outBegin, inBegin := out, in
+		for outEdge() == edge {
+			out++
+		}
+		for inEdge() == edge {
+			in++
+		}
+		nOut := out - outBegin
+		nIn := in - inBegin
+		if edge.First == edge.Second {
+			// This is a degenerate edge.
+			if opts.DegenerateEdges == DegenerateEdgesDiscard {
+				continue
+			}
+			if opts.DegenerateEdges == DegenerateEdgesDiscardExcess &&
+				((outBegin > 0 && p.edges[p.outEdges[outBegin-1]].First == edge.First) ||
+					(out < numEdges && p.edges[p.outEdges[out]].First == edge.First) ||
+					(inBegin > 0 && p.edges[p.inEdges[inBegin-1]].Second == edge.First) ||
+					(in < numEdges && p.edges[p.inEdges[in]].Second == edge.First)) {
+				continue // There were non-degenerate incident edges, so discard.
+			}
+
+			// DegenerateEdgesDiscardExcess also merges degenerate edges.
+			merge := opts.DuplicateEdges == DuplicateEdgesMerge ||
+				opts.DegenerateEdges == DegenerateEdgesDiscardExcess
+			switch {
+			case opts.EdgeType == EdgeTypeUndirected &&
+				(opts.SiblingPairs == SiblingPairsRequire || opts.SiblingPairs == SiblingPairsCreate):
+				// When we have undirected edges and are guaranteed to have
+				// siblings, we cut the number of edges in half.
+				n := nOut / 2
+				if merge {
+					n = 1
+				}
+				p.addEdges(n, edge, p.mergeInputIDs(outBegin, out))
+			case merge:
+				n := 1
+				if opts.EdgeType == EdgeTypeUndirected {
+					n = 2
+				}
+				p.addEdges(n, edge, p.mergeInputIDs(outBegin, out))
+			case opts.SiblingPairs == SiblingPairsDiscard || opts.SiblingPairs == SiblingPairsDiscardExcess:
+				// Any SiblingPair option that discards edges causes the input
+				// ids of all duplicate edges to be merged together.
+				p.addEdges(nOut, edge, p.mergeInputIDs(outBegin, out))
+			default:
+				p.copyEdges(outBegin, out)
+			}
+			continue
+		}
+
+		switch opts.SiblingPairs {
+		case SiblingPairsKeep:
+			if nOut > 1 && opts.DuplicateEdges == DuplicateEdgesMerge {
+				p.addEdge(edge, p.mergeInputIDs(outBegin, out))
+			} else {
+				p.copyEdges(outBegin, out)
+			}
+		case SiblingPairsDiscard:
+			if opts.EdgeType == EdgeTypeDirected {
+				// If nOut == nIn: balanced sibling pairs
+				// If nOut < nIn:  unbalanced siblings, in the form AB, BA, BA
+				// If nOut > nIn:  unbalanced siblings, in the form AB, AB, BA
+				if nOut <= nIn {
+					continue
+				}
+				// Any option that discards edges causes the input ids of all
+				// duplicate edges to be merged together.
+				n := nOut - nIn
+				if opts.DuplicateEdges == DuplicateEdgesMerge {
+					n = 1
+				}
+				p.addEdges(n, edge, p.mergeInputIDs(outBegin, out))
+			} else {
+				if nOut&1 == 0 {
+					continue
+				}
+				p.addEdge(edge, p.mergeInputIDs(outBegin, out))
+			}
+		case SiblingPairsDiscardExcess:
+			if opts.EdgeType == EdgeTypeDirected {
+				// See comments above. The only difference is that if there are
+				// balanced sibling pairs, we want to keep one such pair.
+				if nOut < nIn {
+					continue
+				}
+				n := maxInt(1, nOut-nIn)
+				if opts.DuplicateEdges == DuplicateEdgesMerge {
+					n = 1
+				}
+				p.addEdges(n, edge, p.mergeInputIDs(outBegin, out))
+			} else {
+				n := 2
+				if nOut&1 != 0 {
+					n = 1
+				}
+				p.addEdges(n, edge, p.mergeInputIDs(outBegin, out))
+			}
+		default: // SiblingPairsRequire or SiblingPairsCreate
+			if err == nil && opts.SiblingPairs == SiblingPairsRequire &&
+				((opts.EdgeType == EdgeTypeDirected && nOut != nIn) ||
+					(opts.EdgeType == EdgeTypeUndirected && nOut&1 != 0)) {
+				err = errBuilderMissingSiblings
+			}
+			if opts.DuplicateEdges == DuplicateEdgesMerge {
+				nOut, nIn = 1, 1
+			} else if opts.EdgeType == EdgeTypeUndirected {
+				// Convert the graph to use directed edges instead (see the
+				// description of SiblingPairsRequire and SiblingPairsCreate).
+				nOut = (nOut + 1) / 2
+				nIn = nOut
+			}
+			p.addEdges(nOut, edge, p.mergeInputIDs(outBegin, out))
+			if nIn > nOut {
+				// Automatically created edges have no input edge ids.
+				p.addEdges(nIn-nOut, edge, emptySetID)
+			}
+		}
+	}
+	return err
+}
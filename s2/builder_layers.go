@@ -0,0 +1,285 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PolygonLayerOptions are the options for a PolygonLayer.
+type PolygonLayerOptions struct {
+	// EdgeType indicates whether the input edges are directed or
+	// undirected. Directed edges produce a polygon whose interior is on
+	// the left of every edge. Only EdgeTypeDirected is currently
+	// supported.
+	EdgeType EdgeType
+
+	// Validate reports whether the output polygon should be checked with
+	// Polygon.Validate.
+	Validate bool
+}
+
+// DefaultPolygonLayerOptions returns the default PolygonLayerOptions.
+func DefaultPolygonLayerOptions() PolygonLayerOptions {
+	return PolygonLayerOptions{EdgeType: EdgeTypeDirected}
+}
+
+// PolygonLayer is a Builder layer that assembles its edges into a Polygon.
+// Degenerate edges and sibling edge pairs are discarded, so for example a
+// loop that is snapped to a single vertex or edge disappears.
+//
+// If the output has no edges, the layer's IsFullPolygonPredicate decides
+// whether the result is empty or full.
+type PolygonLayer struct {
+	opts    PolygonLayerOptions
+	polygon *Polygon
+}
+
+// NewPolygonLayer returns a new PolygonLayer with the given options.
+func NewPolygonLayer(opts PolygonLayerOptions) *PolygonLayer {
+	return &PolygonLayer{opts: opts}
+}
+
+// Polygon returns the polygon assembled by the most recent Build.
+func (l *PolygonLayer) Polygon() *Polygon { return l.polygon }
+
+// GraphOptions returns the GraphOptions used by this layer.
+func (l *PolygonLayer) GraphOptions() GraphOptions {
+	return GraphOptions{
+		EdgeType:        l.opts.EdgeType,
+		DegenerateEdges: DegenerateEdgesDiscard,
+		DuplicateEdges:  DuplicateEdgesKeep,
+		SiblingPairs:    SiblingPairsDiscard,
+	}
+}
+
+// Build assembles the edges of the given graph into a polygon.
+func (l *PolygonLayer) Build(g *Graph) error {
+	l.polygon = PolygonFromLoops(nil)
+	if g.Options().EdgeType != EdgeTypeDirected {
+		return errors.New("PolygonLayer does not support undirected edges")
+	}
+
+	if g.NumEdges() == 0 {
+		// The polygon is either full or empty.
+		isFull, err := g.IsFullPolygon()
+		if err != nil {
+			return err
+		}
+		if isFull {
+			l.polygon = FullPolygon()
+		}
+		return nil
+	}
+
+	edgeLoops, err := g.DirectedLoops(LoopTypeSimple)
+	if err != nil {
+		return err
+	}
+	loops := make([]*Loop, 0, len(edgeLoops))
+	for _, edgeLoop := range edgeLoops {
+		vertices := make([]Point, 0, len(edgeLoop))
+		for _, e := range edgeLoop {
+			vertices = append(vertices, g.Vertex(g.Edge(e).First))
+		}
+		loops = append(loops, LoopFromPoints(vertices))
+	}
+	l.polygon = PolygonFromOrientedLoops(loops)
+	if l.opts.Validate {
+		return l.polygon.Validate()
+	}
+	return nil
+}
+
+// PolylineLayerOptions are the options for a PolylineLayer.
+type PolylineLayerOptions struct {
+	// EdgeType indicates whether the input edges are directed or
+	// undirected. With undirected edges the output polyline may be
+	// assembled in either direction, although the input direction is
+	// preserved when possible.
+	EdgeType EdgeType
+
+	// Validate reports whether the output polyline should be checked with
+	// Polyline.Validate.
+	Validate bool
+}
+
+// DefaultPolylineLayerOptions returns the default PolylineLayerOptions.
+func DefaultPolylineLayerOptions() PolylineLayerOptions {
+	return PolylineLayerOptions{EdgeType: EdgeTypeDirected}
+}
+
+// errBuilderNotPolyline is returned when the edges of a PolylineLayer do
+// not form a single polyline.
+var errBuilderNotPolyline = errors.New("input edges cannot be assembled into polyline")
+
+// PolylineLayer is a Builder layer that assembles its edges into a single
+// Polyline. It returns an error if the edges cannot be assembled into
+// exactly one polyline. Degenerate edges are discarded.
+type PolylineLayer struct {
+	opts     PolylineLayerOptions
+	polyline *Polyline
+}
+
+// NewPolylineLayer returns a new PolylineLayer with the given options.
+func NewPolylineLayer(opts PolylineLayerOptions) *PolylineLayer {
+	return &PolylineLayer{opts: opts}
+}
+
+// Polyline returns the polyline assembled by the most recent Build.
+func (l *PolylineLayer) Polyline() *Polyline { return l.polyline }
+
+// GraphOptions returns the GraphOptions used by this layer.
+func (l *PolylineLayer) GraphOptions() GraphOptions {
+	return GraphOptions{
+		EdgeType:        l.opts.EdgeType,
+		DegenerateEdges: DegenerateEdgesDiscard,
+		DuplicateEdges:  DuplicateEdgesKeep,
+		SiblingPairs:    SiblingPairsKeep,
+	}
+}
+
+// Build assembles the edges of the given graph into a polyline.
+func (l *PolylineLayer) Build(g *Graph) error {
+	l.polyline = &Polyline{}
+	if g.NumEdges() == 0 {
+		return nil
+	}
+	edgePolylines := g.Polylines(PolylineTypeWalk)
+	if len(edgePolylines) != 1 {
+		return errBuilderNotPolyline
+	}
+	*l.polyline = graphPolylineVertices(g, edgePolylines[0])
+	if l.opts.Validate {
+		return l.polyline.Validate()
+	}
+	return nil
+}
+
+// PolylineVectorLayerOptions are the options for a PolylineVectorLayer.
+type PolylineVectorLayerOptions struct {
+	// EdgeType indicates whether the input edges are directed or
+	// undirected.
+	EdgeType EdgeType
+
+	// PolylineType indicates whether polylines are broken at every vertex
+	// with degree other than two (PolylineTypePath), or whether the
+	// smallest number of polylines is built (PolylineTypeWalk).
+	PolylineType PolylineType
+
+	// DuplicateEdges indicates whether duplicate edges are merged or kept.
+	DuplicateEdges DuplicateEdges
+
+	// SiblingPairs indicates how sibling edge pairs are handled.
+	SiblingPairs SiblingPairs
+
+	// Validate reports whether each output polyline should be checked with
+	// Polyline.Validate.
+	Validate bool
+}
+
+// DefaultPolylineVectorLayerOptions returns the default
+// PolylineVectorLayerOptions.
+func DefaultPolylineVectorLayerOptions() PolylineVectorLayerOptions {
+	return PolylineVectorLayerOptions{
+		EdgeType:       EdgeTypeDirected,
+		PolylineType:   PolylineTypePath,
+		DuplicateEdges: DuplicateEdgesKeep,
+		SiblingPairs:   SiblingPairsKeep,
+	}
+}
+
+// PolylineVectorLayer is a Builder layer that assembles its edges into a
+// collection of polylines. Degenerate edges are discarded.
+type PolylineVectorLayer struct {
+	opts      PolylineVectorLayerOptions
+	polylines []*Polyline
+}
+
+// NewPolylineVectorLayer returns a new PolylineVectorLayer with the given
+// options.
+func NewPolylineVectorLayer(opts PolylineVectorLayerOptions) *PolylineVectorLayer {
+	return &PolylineVectorLayer{opts: opts}
+}
+
+// Polylines returns the polylines assembled by the most recent Build.
+func (l *PolylineVectorLayer) Polylines() []*Polyline { return l.polylines }
+
+// GraphOptions returns the GraphOptions used by this layer.
+func (l *PolylineVectorLayer) GraphOptions() GraphOptions {
+	return GraphOptions{
+		EdgeType:        l.opts.EdgeType,
+		DegenerateEdges: DegenerateEdgesDiscard,
+		DuplicateEdges:  l.opts.DuplicateEdges,
+		SiblingPairs:    l.opts.SiblingPairs,
+	}
+}
+
+// Build assembles the edges of the given graph into polylines.
+func (l *PolylineVectorLayer) Build(g *Graph) error {
+	l.polylines = nil
+	for _, edgePolyline := range g.Polylines(l.opts.PolylineType) {
+		polyline := Polyline(graphPolylineVertices(g, edgePolyline))
+		if l.opts.Validate {
+			if err := polyline.Validate(); err != nil {
+				return fmt.Errorf("polyline %d: %v", len(l.polylines), err)
+			}
+		}
+		l.polylines = append(l.polylines, &polyline)
+	}
+	return nil
+}
+
+// graphPolylineVertices returns the vertices of the given chain of edges.
+func graphPolylineVertices(g *Graph, edges []int32) []Point {
+	vertices := make([]Point, 0, len(edges)+1)
+	vertices = append(vertices, g.Vertex(g.Edge(edges[0]).First))
+	for _, e := range edges {
+		vertices = append(vertices, g.Vertex(g.Edge(e).Second))
+	}
+	return vertices
+}
+
+// GraphLayer is a Builder layer that simply keeps the Graph passed to it so
+// that callers can process the snapped edges directly.
+type GraphLayer struct {
+	opts  GraphOptions
+	graph *Graph
+}
+
+// NewGraphLayer returns a new GraphLayer that processes its edges with the
+// given options.
+func NewGraphLayer(opts GraphOptions) *GraphLayer {
+	return &GraphLayer{opts: opts}
+}
+
+// Graph returns the graph passed to the most recent Build.
+func (l *GraphLayer) Graph() *Graph { return l.graph }
+
+// GraphOptions returns the GraphOptions used by this layer.
+func (l *GraphLayer) GraphOptions() GraphOptions { return l.opts }
+
+// Build records the given graph.
+func (l *GraphLayer) Build(g *Graph) error {
+	l.graph = g
+	return nil
+}
+
+// TODO(rsned): Differences from C++
+// Undirected edges in PolygonLayer.
+// LaxPolygonLayer.
+// Label support in all layers.
+// IndexedPolygonLayer / IndexedPolylineLayer.
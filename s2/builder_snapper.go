@@ -498,8 +498,12 @@ func (sf IntLatLngSnapper) MinEdgeVertexSeparation() s1.Angle {
 func (sf IntLatLngSnapper) SnapPoint(point Point) Point {
 	// TODO(rsned): C++ DCHECK's on exponent being in valid range. What should we
 	// do when it's bad here.
+
+	// The coordinates are rounded in degrees, as s1.Angle.E5 and friends do,
+	// and without converting to an integer, which would overflow for the
+	// larger exponents.
 	input := LatLngFromPoint(point)
-	lat := s1.Angle(roundAngle(input.Lat * sf.from))
-	lng := s1.Angle(roundAngle(input.Lng * sf.from))
-	return PointFromLatLng(LatLng{lat * sf.to, lng * sf.to})
+	lat := s1.Angle(math.Round(input.Lat.Degrees()*float64(sf.from))) * sf.to
+	lng := s1.Angle(math.Round(input.Lng.Degrees()*float64(sf.from))) * sf.to
+	return PointFromLatLng(LatLng{lat * s1.Degree, lng * s1.Degree})
 }
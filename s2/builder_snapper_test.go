@@ -15,6 +15,7 @@
 package s2
 
 import (
+	"math"
 	"testing"
 
 	"github.com/blevesearch/geo/s1"
@@ -97,6 +98,54 @@ func TestIntLatLngSnapperExponentToFromSnapRadius(t *testing.T) {
 	}
 }
 
+// intLatLng returns the coordinates of the LatLng in degrees scaled by
+// 10**exponent and rounded to integers, which a point snapped by the
+// IntLatLngSnapper of that exponent keeps whatever the rounding errors
+// of its conversions.
+func intLatLng(ll LatLng, exponent int) [2]float64 {
+	scale := math.Pow10(exponent)
+	return [2]float64{math.Round(ll.Lat.Degrees() * scale), math.Round(ll.Lng.Degrees() * scale)}
+}
+
+func TestIntLatLngSnapperSnapPointDegrees(t *testing.T) {
+	tests := []struct {
+		exponent int
+		have     LatLng
+		want     [2]float64
+	}{
+		{0, LatLngFromDegrees(10.4, 20.6), [2]float64{10, 21}},
+		{0, LatLngFromDegrees(-10.4, -20.6), [2]float64{-10, -21}},
+		{2, LatLngFromDegrees(45.12345, -120.98765), [2]float64{4512, -12099}},
+		// the coordinates scaled by the exponent don't fit in an int32
+		{10, LatLngFromDegrees(89.123456789012, -179.123456789012),
+			[2]float64{891234567890, -1791234567890}},
+	}
+	for _, test := range tests {
+		sf := NewIntLatLngSnapper(test.exponent)
+		got := LatLngFromPoint(sf.SnapPoint(PointFromLatLng(test.have)))
+		if e := intLatLng(got, test.exponent); e != test.want {
+			t.Errorf("NewIntLatLngSnapper(%d).SnapPoint(%v) = %v, want %v", test.exponent, test.have, e, test.want)
+		}
+	}
+
+	for iter := 0; iter < 1000; iter++ {
+		// Points with E5, E6 and E7 coordinates keep them when snapped by
+		// the snapper of that exponent.
+		ll := LatLngFromPoint(randomPoint())
+		for exp, round := range map[int]func(s1.Angle) int32{
+			5: s1.Angle.E5, 6: s1.Angle.E6, 7: s1.Angle.E7,
+		} {
+			want := [2]float64{float64(round(ll.Lat)), float64(round(ll.Lng))}
+			scale := math.Pow10(exp)
+			p := PointFromLatLng(LatLngFromDegrees(want[0]/scale, want[1]/scale))
+			got := intLatLng(LatLngFromPoint(NewIntLatLngSnapper(exp).SnapPoint(p)), exp)
+			if got != want {
+				t.Errorf("NewIntLatLngSnapper(%d).SnapPoint(%v) = %v, want %v", exp, p, got, want)
+			}
+		}
+	}
+}
+
 /*
 TODO(roberts): Uncomment when LatLng helpers are incorporated.
 func TestIntLatLngSnapperSnapPoint(t *testing.T) {
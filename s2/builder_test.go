@@ -0,0 +1,1075 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/geo/s1"
+)
+
+// polygonBoundariesEqual reports whether the two polygons have the same
+// loops in the same order, allowing each loop to start at any vertex.
+func polygonBoundariesEqual(a, b *Polygon) bool {
+	if a.NumLoops() != b.NumLoops() {
+		return false
+	}
+	for i := 0; i < a.NumLoops(); i++ {
+		if !a.Loop(i).BoundaryEqual(b.Loop(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildPolygon snaps the given polygon with the given options and returns
+// the result.
+func buildPolygon(t *testing.T, opts BuilderOptions, polygon *Polygon) *Polygon {
+	t.Helper()
+	b := NewBuilder(opts)
+	layer := NewPolygonLayer(DefaultPolygonLayerOptions())
+	b.StartLayer(layer)
+	b.AddPolygon(polygon)
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	return layer.Polygon()
+}
+
+func TestBuilderPolygonIdentity(t *testing.T) {
+	tests := []string{
+		"0:0, 0:10, 10:10, 10:0",
+		"0:0, 0:10, 10:10, 10:0; 2:2, 8:2, 8:8, 2:8",
+		"0:0, 0:5, 5:5, 5:0; 10:10, 10:15, 15:15, 15:10",
+		"-10:170, -10:-170, 10:-170, 10:170",
+	}
+	for _, test := range tests {
+		want := makePolygon(test, true)
+		got := buildPolygon(t, DefaultBuilderOptions(), want)
+		if !polygonBoundariesEqual(got, want) {
+			t.Errorf("Build(%q) = %v, want %v", test, got.Loops(), want.Loops())
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("Build(%q).Validate() = %v, want nil", test, err)
+		}
+	}
+}
+
+func TestBuilderPolygonSnapMergesVertices(t *testing.T) {
+	// The two nearby vertices are within the snap radius of each other and
+	// must be merged into a single output vertex.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(s1.Angle(0.1) * s1.Degree)
+	got := buildPolygon(t, opts, makePolygon("0:0, 0:10, 10:10, 10:0.01, 10:0", true))
+	if got.NumLoops() != 1 {
+		t.Fatalf("NumLoops() = %d, want 1", got.NumLoops())
+	}
+	if n := got.Loop(0).NumVertices(); n != 4 {
+		t.Errorf("NumVertices() = %d, want 4", n)
+	}
+}
+
+func TestBuilderPolygonCollapsesToEmptyOrFull(t *testing.T) {
+	// A loop smaller than the snap radius collapses and is discarded.
+	small := makePolygon("0:0, 0:0.001, 0.001:0.001", true)
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(s1.Degree)
+
+	if got := buildPolygon(t, opts, small); !got.IsEmpty() {
+		t.Errorf("Build(small loop) = %v, want empty polygon", got.Loops())
+	}
+
+	b := NewBuilder(opts)
+	layer := NewPolygonLayer(DefaultPolygonLayerOptions())
+	b.StartLayer(layer)
+	b.AddIsFullPolygonPredicate(IsFullPolygon(true))
+	b.AddPolygon(small)
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	if !layer.Polygon().IsFull() {
+		t.Errorf("Build(small loop) with IsFullPolygon(true) = %v, want full polygon", layer.Polygon().Loops())
+	}
+}
+
+func TestBuilderCellIDSnapperIsIdempotent(t *testing.T) {
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = CellIDSnapperForLevel(10)
+	once := buildPolygon(t, opts, makePolygon("0:0, 0:10, 10:10, 10:0; 3:3, 7:3, 7:7, 3:7", true))
+	for i, loop := range once.Loops() {
+		for j, v := range loop.Vertices() {
+			if got := cellIDFromPoint(v).Parent(10).Point(); got != v {
+				t.Errorf("loop %d vertex %d = %v is not a level 10 cell center", i, j, v)
+			}
+		}
+	}
+	twice := buildPolygon(t, opts, once)
+	if !polygonBoundariesEqual(once, twice) {
+		t.Errorf("snapping twice = %v, want %v", twice.Loops(), once.Loops())
+	}
+}
+
+// loopNear reports whether the loops have the same number of vertices and,
+// starting at some vertex of the second one, each vertex of the first loop
+// is within the given distance of the corresponding one.
+func loopNear(a, b *Loop, maxError s1.Angle) bool {
+	n := a.NumVertices()
+	if n != b.NumVertices() {
+		return false
+	}
+	for offset := 0; offset < n; offset++ {
+		near := true
+		for i := 0; i < n && near; i++ {
+			near = a.Vertex(i).Distance(b.Vertex(i+offset)) <= maxError
+		}
+		if near {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBuilderSimpleVertexMerging(t *testing.T) {
+	// When the IdentitySnapper is used (i.e., no special requirements on
+	// vertex locations), check that vertices closer together than the snap
+	// radius are merged together.
+	snapRadius := 0.5 * s1.Degree
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(snapRadius)
+	got := buildPolygon(t, opts, makePolygon("0:0, 0.2:0.2, 0.1:0.2, 0.1:0.9, 0:1, 0.1:1.1, 0.9:1, 1:1, 1:0.9", true))
+	want := makePolygon("0:0, 0:1, 1:0.9", true)
+	if got.NumLoops() != 1 || !loopNear(got.Loop(0), want.Loop(0), snapRadius) {
+		t.Errorf("Build() = %v, want %v", got.Loops(), want.Loops())
+	}
+}
+
+func TestBuilderSimpleCellIDSnapping(t *testing.T) {
+	// Like the test above, with the vertices snapped to cell centers.
+	sf := NewCellIDSnapper()
+	sf = CellIDSnapperForLevel(sf.levelForMaxSnapRadius(s1.Degree))
+	if sf.SnapRadius() > s1.Degree {
+		t.Fatalf("SnapRadius() = %v, want at most 1 degree", sf.SnapRadius())
+	}
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = sf
+	got := buildPolygon(t, opts, makePolygon("0:0, 0.2:0.2, 0.1:0.2, 0.1:0.9, 0:1, 0.1:1.1, 0.9:1, 1:1, 1:0.9", true))
+	want := makePolygon("0:0, 0:1, 1:0.9", true)
+	if got.NumLoops() != 1 || !loopNear(got.Loop(0), want.Loop(0), sf.SnapRadius()) {
+		t.Errorf("Build() = %v, want %v", got.Loops(), want.Loops())
+	}
+}
+
+func TestBuilderVerticesMoveLessThanSnapRadius(t *testing.T) {
+	// Check that chains of closely spaced vertices do not collapse into a
+	// single vertex. The spacing between input vertices is about
+	// 2*pi*20/1000 = 0.125 degrees, and the output vertices are spaced
+	// between 1 and 2 degrees apart, about 1.33 degrees on average.
+	snapRadius := s1.Degree
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(snapRadius)
+	input := RegularLoop(PointFromCoords(1, 0, 0), 20*s1.Degree, 1000)
+	got := buildPolygon(t, opts, PolygonFromLoops([]*Loop{input}))
+	if got.NumLoops() != 1 {
+		t.Fatalf("Build() returned %d loops, want 1", got.NumLoops())
+	}
+	if n := got.Loop(0).NumVertices(); n < 90 || n > 100 {
+		t.Errorf("Build() returned %d vertices, want between 90 and 100", n)
+	}
+	for i, v := range got.Loop(0).Vertices() {
+		if _, ok := input.findVertex(v); !ok {
+			t.Errorf("output vertex %d = %v is not an input vertex", i, v)
+		}
+	}
+	for i, v := range input.Vertices() {
+		if got.Loop(0).Vertex(nearestVertex(got.Loop(0), v)).Distance(v) > snapRadius {
+			t.Errorf("input vertex %d = %v moved by more than %v", i, v, snapRadius)
+		}
+	}
+}
+
+// nearestVertex returns the index of the vertex of the loop closest to p.
+func nearestVertex(l *Loop, p Point) int {
+	best := 0
+	for i, v := range l.Vertices() {
+		if v.Distance(p) < l.Vertex(best).Distance(p) {
+			best = i
+		}
+	}
+	return best
+}
+
+func TestBuilderMinEdgeVertexSeparation(t *testing.T) {
+	// Check that edges are separated from non-incident vertices by at least
+	// MinEdgeVertexSeparation. This requires adding new vertices (not found
+	// in the input) in some cases.
+	//
+	// The input is a skinny right triangle with two legs of length 10 and 1,
+	// and whose diagonal is subdivided into 10 short edges. Using a snap
+	// radius of 0.5, about half of the long leg is snapped onto the diagonal
+	// (which causes vertices to be added to the diagonal).
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(0.5 * s1.Degree)
+	got := buildPolygon(t, opts, makePolygon("0:0, 0:1, 1:.9, 2:.8, 3:.7, 4:.6, 5:.5, 6:.4, 7:.3, 8:.2, 9:.1, 10:0", true))
+	if got.NumLoops() != 1 {
+		t.Fatalf("Build() returned %d loops, want 1", got.NumLoops())
+	}
+	checkEdgeVertexSeparation(t, got.Loop(0).Vertices(), opts.SnapFunction.MinEdgeVertexSeparation())
+}
+
+// checkEdgeVertexSeparation checks that the vertices of the loop are
+// separated from its non-incident edges by at least the given distance.
+func checkEdgeVertexSeparation(t *testing.T, vertices []Point, minSeparation s1.Angle) {
+	t.Helper()
+	n := len(vertices)
+	for i := 0; i < n; i++ {
+		a, b := vertices[i], vertices[(i+1)%n]
+		for _, v := range vertices {
+			if v == a || v == b {
+				continue
+			}
+			if d := DistanceFromSegment(v, a, b); d < minSeparation-s1.Angle(1e-15) {
+				t.Errorf("vertex %v is %v from edge (%v, %v), want at least %v", v, d, a, b, minSeparation)
+			}
+		}
+	}
+}
+
+func TestBuilderSnapRadiusGuarantees(t *testing.T) {
+	// Snap random polylines in a small area with random snap functions and
+	// radii, and check the guarantees of Builder: every vertex is a snapped
+	// point, input vertices move by at most the snap radius, edges stay
+	// within MaxEdgeDeviation of their input edges, and vertices are
+	// separated from each other and from non-incident edges.
+	const maxError = s1.Angle(1e-14)
+	for iter := 0; iter < 100; iter++ {
+		snapRadius := s1.Angle(randomUniformFloat64(1e-3, 1e-1)) * s1.Degree
+		var sf Snapper = NewIdentitySnapper(snapRadius)
+		if oneIn(2) {
+			sf = CellIDSnapperForLevel(NewCellIDSnapper().levelForMaxSnapRadius(snapRadius))
+		}
+		opts := DefaultBuilderOptions()
+		opts.SnapFunction = sf
+		b := NewBuilder(opts)
+		layer := NewGraphLayer(GraphOptions{EdgeTypeDirected, DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsKeep})
+		b.StartLayer(layer)
+
+		area := CapFromCenterAngle(randomPoint(), 20*sf.SnapRadius())
+		var inputEdges []Edge
+		var inputVertices []Point
+		for i := 0; i < 5; i++ {
+			pl := make(Polyline, 2+randomUniformInt(8))
+			for j := range pl {
+				pl[j] = samplePointFromCap(area)
+			}
+			b.AddPolyline(&pl)
+			for j := 1; j < len(pl); j++ {
+				inputEdges = append(inputEdges, Edge{pl[j-1], pl[j]})
+			}
+			inputVertices = append(inputVertices, pl...)
+		}
+		if err := b.Build(); err != nil {
+			t.Fatalf("iter %d: Build() returned error: %v", iter, err)
+		}
+
+		g := layer.Graph()
+		for i, v := range g.Vertices() {
+			if got := sf.SnapPoint(v); got != v {
+				t.Errorf("iter %d: vertex %d = %v, want the snapped point %v", iter, i, v, got)
+			}
+			for _, w := range g.Vertices()[i+1:] {
+				if d := v.Distance(w); d < sf.MinVertexSeparation()-maxError {
+					t.Errorf("iter %d: vertices %v and %v are %v apart, want at least %v",
+						iter, v, w, d, sf.MinVertexSeparation())
+				}
+			}
+		}
+		for i, v := range inputVertices {
+			moved := true
+			for _, w := range g.Vertices() {
+				if v.Distance(w) <= sf.SnapRadius()+maxError {
+					moved = false
+					break
+				}
+			}
+			if moved {
+				t.Errorf("iter %d: input vertex %d = %v moved by more than %v", iter, i, v, sf.SnapRadius())
+			}
+		}
+		for e := int32(0); int(e) < g.NumEdges(); e++ {
+			edge := g.Edge(e)
+			v0, v1 := g.Vertex(edge.First), g.Vertex(edge.Second)
+			for _, id := range g.InputEdgeIDs(e) {
+				in := inputEdges[id]
+				if !IsEdgeBNearEdgeA(in.V0, in.V1, v0, v1, opts.MaxEdgeDeviation()) {
+					t.Errorf("iter %d: edge (%v, %v) deviates from input edge %d by more than %v",
+						iter, v0, v1, id, opts.MaxEdgeDeviation())
+				}
+			}
+			for v := int32(0); int(v) < g.NumVertices(); v++ {
+				if v == edge.First || v == edge.Second {
+					continue
+				}
+				if d := DistanceFromSegment(g.Vertex(v), v0, v1); d < sf.MinEdgeVertexSeparation()-maxError {
+					t.Errorf("iter %d: vertex %v is %v from edge (%v, %v), want at least %v",
+						iter, g.Vertex(v), d, v0, v1, sf.MinEdgeVertexSeparation())
+				}
+			}
+		}
+	}
+}
+
+// buildPolylines snaps the given polylines with the given options into a
+// PolylineVectorLayer with the given options and returns the result.
+func buildPolylines(t *testing.T, opts BuilderOptions, layerOpts PolylineVectorLayerOptions, polylines ...string) []*Polyline {
+	t.Helper()
+	b := NewBuilder(opts)
+	layer := NewPolylineVectorLayer(layerOpts)
+	b.StartLayer(layer)
+	for _, s := range polylines {
+		b.AddPolyline(makePolyline(s))
+	}
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build(%q) returned error: %v", polylines, err)
+	}
+	return layer.Polylines()
+}
+
+func TestBuilderIdempotencySnapsInadequatelySeparatedVertices(t *testing.T) {
+	// Vertices closer together than MinVertexSeparation are snapped
+	// together even when Idempotent is set.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(s1.Degree)
+	got := buildPolylines(t, opts, DefaultPolylineVectorLayerOptions(), "0:0, 0:0.9, 0:2")
+	if want := makePolyline("0:0, 0:2"); len(got) != 1 || !got[0].Equal(want) {
+		t.Errorf("Build() = %v, want %v", got, *want)
+	}
+}
+
+func TestBuilderIdempotencySnapsEdgesWithTinySnapRadius(t *testing.T) {
+	// An edge separated from a non-incident vertex by a distance of zero
+	// cannot be the output of a previous snapping, so it is snapped even
+	// though the snap radius is as small as intersectionError.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(intersectionError)
+	layerOpts := DefaultPolylineVectorLayerOptions()
+	layerOpts.DuplicateEdges = DuplicateEdgesMerge
+	got := buildPolylines(t, opts, layerOpts, "0:0, 0:10", "0:5, 0:7")
+	if want := makePolyline("0:0, 0:5, 0:7, 0:10"); len(got) != 1 || !got[0].Equal(want) {
+		t.Errorf("Build() = %v, want %v", got, *want)
+	}
+}
+
+func TestBuilderIdempotencySnapsUnsnappedVertices(t *testing.T) {
+	// When Idempotent is set, no snapping is done unless Builder finds at
+	// least one vertex or edge that could not be the output of a previous
+	// snapping. The two vertices are far enough apart to be the result of a
+	// previous snapping to integer degrees, but the second one is not at
+	// integer coordinates, so both are snapped.
+	sf := NewIntLatLngSnapper(0)
+	if sf.MinVertexSeparation() >= 0.6*s1.Degree {
+		t.Fatalf("MinVertexSeparation() = %v, want less than 0.6 degrees", sf.MinVertexSeparation())
+	}
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = sf
+	got := buildPolylines(t, opts, DefaultPolylineVectorLayerOptions(), "0:0, 0:0.6")
+	if want := makePolyline("0:0, 0:1"); len(got) != 1 || !got[0].ApproxEqual(want) {
+		t.Errorf("Build() = %v, want %v", got, *want)
+	}
+}
+
+func TestBuilderIdempotencyDoesNotSnapAdequatelySeparatedEdges(t *testing.T) {
+	// An edge farther than MinEdgeVertexSeparation from the vertices is not
+	// snapped again, so snapping the output of a snapping leaves it as is.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIntLatLngSnapper(0)
+	once := buildPolygon(t, opts, makePolygon("1.49:0, 0:2, 0.49:3", true))
+	want := makePolygon("1:0, 0:2, 0:3", true)
+	if once.NumLoops() != 1 || !loopNear(once.Loop(0), want.Loop(0), s1.Angle(1e-15)) {
+		t.Errorf("Build() = %v, want %v", once.Loops(), want.Loops())
+	}
+	if twice := buildPolygon(t, opts, once); !polygonBoundariesEqual(once, twice) {
+		t.Errorf("snapping twice = %v, want %v", twice.Loops(), once.Loops())
+	}
+}
+
+func TestBuilderIntLatLngSnapperMaxEdgeDeviation(t *testing.T) {
+	// A long edge snapped to integer degrees is split so that it stays
+	// within MaxEdgeDeviation of the input edge.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIntLatLngSnapper(0)
+	const input = "0.4:0.4, 0.6:60.6"
+	got := buildPolylines(t, opts, DefaultPolylineVectorLayerOptions(), input)
+	if len(got) != 1 {
+		t.Fatalf("Build() returned %d polylines, want 1", len(got))
+	}
+	a0, a1 := (*makePolyline(input))[0], (*makePolyline(input))[1]
+	for i, pl := 1, *got[0]; i < len(pl); i++ {
+		if !IsEdgeBNearEdgeA(a0, a1, pl[i-1], pl[i], opts.MaxEdgeDeviation()) {
+			t.Errorf("output edge %d (%v, %v) deviates from the input edge by more than %v",
+				i-1, pl[i-1], pl[i], opts.MaxEdgeDeviation())
+		}
+		if ll := LatLngFromPoint(pl[i]); !ll.ApproxEqual(LatLngFromDegrees(math.Round(ll.Lat.Degrees()), math.Round(ll.Lng.Degrees()))) {
+			t.Errorf("output vertex %d = %v is not at integer degrees", i, ll)
+		}
+	}
+}
+
+func TestBuilderIdempotentSnapping(t *testing.T) {
+	// Snapping the output of a snapping again with the same options leaves
+	// it unchanged.
+	for iter := 0; iter < 50; iter++ {
+		snapRadius := s1.Angle(randomUniformFloat64(1e-3, 1e-1)) * s1.Degree
+		var sf Snapper = NewIdentitySnapper(snapRadius)
+		if oneIn(2) {
+			sf = CellIDSnapperForLevel(NewCellIDSnapper().levelForMaxSnapRadius(snapRadius))
+		}
+		opts := DefaultBuilderOptions()
+		opts.SnapFunction = sf
+		layerOpts := DefaultPolylineVectorLayerOptions()
+
+		area := CapFromCenterAngle(randomPoint(), 20*sf.SnapRadius())
+		b := NewBuilder(opts)
+		layer := NewPolylineVectorLayer(layerOpts)
+		b.StartLayer(layer)
+		for i := 0; i < 5; i++ {
+			pl := make(Polyline, 2+randomUniformInt(8))
+			for j := range pl {
+				pl[j] = samplePointFromCap(area)
+			}
+			b.AddPolyline(&pl)
+		}
+		if err := b.Build(); err != nil {
+			t.Fatalf("iter %d: Build() returned error: %v", iter, err)
+		}
+		once := layer.Polylines()
+
+		b = NewBuilder(opts)
+		layer = NewPolylineVectorLayer(layerOpts)
+		b.StartLayer(layer)
+		for _, pl := range once {
+			b.AddPolyline(pl)
+		}
+		if err := b.Build(); err != nil {
+			t.Fatalf("iter %d: Build() returned error: %v", iter, err)
+		}
+		twice := layer.Polylines()
+		if len(twice) != len(once) {
+			t.Errorf("iter %d: snapping twice returned %d polylines, want %d", iter, len(twice), len(once))
+			continue
+		}
+		for i := range once {
+			if !twice[i].Equal(once[i]) {
+				t.Errorf("iter %d: snapping twice polyline %d = %v, want %v", iter, i, *twice[i], *once[i])
+			}
+		}
+	}
+}
+
+func TestBuilderMaxEdgeDeviation(t *testing.T) {
+	// Test that MaxEdgeDeviation is large enough for the worst case: long
+	// edges snapped to the sites near them are split as needed so that
+	// every output edge stays within it of the input edge.
+	opts := DefaultBuilderOptions()
+	opts.SnapFunction = NewIdentitySnapper(s1.Degree)
+	maxDev := opts.MaxEdgeDeviation()
+	for iter := 0; iter < 50; iter++ {
+		b := NewBuilder(opts)
+		layer := NewGraphLayer(DefaultGraphOptions())
+		b.StartLayer(layer)
+		a0 := randomPoint()
+		a1 := InterpolateAtDistance(s1.Angle(randomUniformFloat64(2, 60))*s1.Degree, a0, randomPoint())
+		b.AddEdge(a0, a1)
+
+		// The input points on either side of the edge, within the edge snap
+		// radius, become sites that the edge snaps to.
+		b.StartLayer(NewGraphLayer(DefaultGraphOptions()))
+		normal := Point{a0.PointCross(a1).Normalize()}
+		for i := 0; i < 20; i++ {
+			p := InterpolateAtDistance(s1.Angle(randomFloat64())*a0.Distance(a1), a0, a1)
+			side := normal
+			if oneIn(2) {
+				side = Point{normal.Mul(-1)}
+			}
+			b.AddPoint(InterpolateAtDistance(s1.Angle(randomFloat64())*opts.EdgeSnapRadius(), p, side))
+		}
+		if err := b.Build(); err != nil {
+			t.Fatalf("iter %d: Build() returned error: %v", iter, err)
+		}
+		g := layer.Graph()
+		for e := int32(0); int(e) < g.NumEdges(); e++ {
+			b0, b1 := g.Vertex(g.Edge(e).First), g.Vertex(g.Edge(e).Second)
+			if !IsEdgeBNearEdgeA(a0, a1, b0, b1, maxDev) {
+				t.Errorf("iter %d: output edge (%v, %v) deviates from the input edge (%v, %v) by more than %v",
+					iter, b0, b1, a0, a1, maxDev)
+			}
+		}
+	}
+}
+
+func TestBuilderSplitCrossingEdges(t *testing.T) {
+	opts := DefaultBuilderOptions()
+	opts.SplitCrossingEdges = true
+	b := NewBuilder(opts)
+	layer := NewGraphLayer(DefaultGraphOptions())
+	b.StartLayer(layer)
+	b.AddPolyline(makePolyline("0:-1, 0:1"))
+	b.AddPolyline(makePolyline("-1:0, 1:0"))
+	if err := b.Build(); err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	g := layer.Graph()
+	if g.NumVertices() != 5 {
+		t.Errorf("NumVertices() = %d, want 5", g.NumVertices())
+	}
+	if g.NumEdges() != 4 {
+		t.Errorf("NumEdges() = %d, want 4", g.NumEdges())
+	}
+	center := parsePoint("0:0")
+	for e := int32(0); int(e) < g.NumEdges(); e++ {
+		edge := g.Edge(e)
+		if g.Vertex(edge.First).Distance(center) > intersectionError &&
+			g.Vertex(edge.Second).Distance(center) > intersectionError {
+			t.Errorf("edge %d = %v does not end at the crossing point", e, edge)
+		}
+		if ids := g.InputEdgeIDs(e); len(ids) != 1 {
+			t.Errorf("InputEdgeIDs(%d) = %v, want exactly one id", e, ids)
+		}
+	}
+}
+
+func TestBuilderPolylineLayer(t *testing.T) {
+	tests := []struct {
+		have     []string
+		edgeType EdgeType
+		want     string
+		wantErr  bool
+	}{
+		{
+			have:     []string{"0:0, 0:10, 10:20, 20:30"},
+			edgeType: EdgeTypeDirected,
+			want:     "0:0, 0:10, 10:20, 20:30",
+		},
+		{
+			// Consecutive polylines are joined into a single polyline.
+			have:     []string{"0:0, 0:10", "0:10, 10:20"},
+			edgeType: EdgeTypeDirected,
+			want:     "0:0, 0:10, 10:20",
+		},
+		{
+			// Undirected edges are assembled in the input direction.
+			have:     []string{"0:0, 0:10, 10:20"},
+			edgeType: EdgeTypeUndirected,
+			want:     "0:0, 0:10, 10:20",
+		},
+		{
+			have:     []string{"0:0, 0:10", "10:10, 10:20"},
+			edgeType: EdgeTypeDirected,
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		b := NewBuilder(DefaultBuilderOptions())
+		layer := NewPolylineLayer(PolylineLayerOptions{EdgeType: test.edgeType})
+		b.StartLayer(layer)
+		for _, s := range test.have {
+			b.AddPolyline(makePolyline(s))
+		}
+		err := b.Build()
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Build(%q) = nil, want error", test.have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Build(%q) returned error: %v", test.have, err)
+			continue
+		}
+		if want := makePolyline(test.want); !layer.Polyline().Equal(want) {
+			t.Errorf("Build(%q) = %v, want %v", test.have, *layer.Polyline(), *want)
+		}
+	}
+}
+
+func TestBuilderPolylineVectorLayer(t *testing.T) {
+	tests := []struct {
+		polylineType PolylineType
+		want         []string
+	}{
+		{PolylineTypePath, []string{"0:0, 0:10", "0:10, 10:10", "0:10, 0:20"}},
+		{PolylineTypeWalk, []string{"0:0, 0:10, 10:10", "0:10, 0:20"}},
+	}
+	for _, test := range tests {
+		opts := DefaultPolylineVectorLayerOptions()
+		opts.PolylineType = test.polylineType
+		b := NewBuilder(DefaultBuilderOptions())
+		layer := NewPolylineVectorLayer(opts)
+		b.StartLayer(layer)
+		b.AddPolyline(makePolyline("0:0, 0:10, 10:10"))
+		b.AddPolyline(makePolyline("0:10, 0:20"))
+		if err := b.Build(); err != nil {
+			t.Fatalf("Build() returned error: %v", err)
+		}
+		got := layer.Polylines()
+		if len(got) != len(test.want) {
+			t.Errorf("Build() with type %v returned %d polylines, want %d", test.polylineType, len(got), len(test.want))
+			continue
+		}
+		for i, s := range test.want {
+			if want := makePolyline(s); !got[i].Equal(want) {
+				t.Errorf("Build() with type %v polyline %d = %v, want %v", test.polylineType, i, *got[i], *want)
+			}
+		}
+	}
+}
+
+func TestBuilderProcessEdges(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    GraphOptions
+		have    []GraphEdge
+		want    []GraphEdge
+		wantErr bool
+	}{
+		{
+			name: "discard degenerate",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsKeep},
+			have: []GraphEdge{{0, 0}, {0, 1}},
+			want: []GraphEdge{{0, 1}},
+		},
+		{
+			name: "discard excess degenerate",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep},
+			have: []GraphEdge{{0, 0}, {0, 1}, {2, 2}, {2, 2}},
+			want: []GraphEdge{{0, 1}, {2, 2}},
+		},
+		{
+			name: "merge duplicates",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesKeep, DuplicateEdgesMerge, SiblingPairsKeep},
+			have: []GraphEdge{{0, 1}, {0, 1}, {1, 0}},
+			want: []GraphEdge{{0, 1}, {1, 0}},
+		},
+		{
+			name: "discard sibling pairs",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsDiscard},
+			have: []GraphEdge{{0, 1}, {0, 1}, {1, 0}, {1, 2}},
+			want: []GraphEdge{{0, 1}, {1, 2}},
+		},
+		{
+			name: "discard excess sibling pairs",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsDiscardExcess},
+			have: []GraphEdge{{0, 1}, {1, 0}},
+			want: []GraphEdge{{0, 1}, {1, 0}},
+		},
+		{
+			name: "create siblings",
+			opts: GraphOptions{EdgeTypeDirected, DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsCreate},
+			have: []GraphEdge{{0, 1}},
+			want: []GraphEdge{{0, 1}, {1, 0}},
+		},
+		{
+			name:    "require siblings",
+			opts:    GraphOptions{EdgeTypeDirected, DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsRequire},
+			have:    []GraphEdge{{0, 1}, {1, 2}, {2, 1}},
+			want:    []GraphEdge{{0, 1}, {1, 0}, {1, 2}, {2, 1}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		lexicon := newIDSetLexicon()
+		edges := append([]GraphEdge(nil), test.have...)
+		ids := make([]int32, len(edges))
+		for i := range ids {
+			ids[i] = lexicon.add(int32(i))
+		}
+		opts := test.opts
+		err := processEdges(&opts, &edges, &ids, lexicon)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: processEdges(%v) error = %v, want error: %t", test.name, test.have, err, test.wantErr)
+		}
+		if len(edges) != len(test.want) {
+			t.Errorf("%s: processEdges(%v) = %v, want %v", test.name, test.have, edges, test.want)
+			continue
+		}
+		for i := range edges {
+			if edges[i] != test.want[i] {
+				t.Errorf("%s: processEdges(%v) = %v, want %v", test.name, test.have, edges, test.want)
+				break
+			}
+		}
+		if len(ids) != len(edges) {
+			t.Errorf("%s: processEdges(%v) returned %d input id sets for %d edges", test.name, test.have, len(ids), len(edges))
+		}
+	}
+}
+
+// labeledEdge is an edge with the set of input edge ids it comes from.
+type labeledEdge struct {
+	edge GraphEdge
+	ids  []int32
+}
+
+func TestBuilderProcessEdgesGraphOptions(t *testing.T) {
+	directed := func(degenerate DegenerateEdges, duplicate DuplicateEdges, siblings SiblingPairs) GraphOptions {
+		return GraphOptions{EdgeTypeDirected, degenerate, duplicate, siblings}
+	}
+	undirected := func(degenerate DegenerateEdges, duplicate DuplicateEdges, siblings SiblingPairs) GraphOptions {
+		return GraphOptions{EdgeTypeUndirected, degenerate, duplicate, siblings}
+	}
+	edges := func(pairs ...int32) []labeledEdge {
+		var rv []labeledEdge
+		for i := 0; i < len(pairs); i += 2 {
+			rv = append(rv, labeledEdge{edge: GraphEdge{pairs[i], pairs[i+1]}})
+		}
+		return rv
+	}
+	tests := []struct {
+		name         string
+		opts         GraphOptions
+		have         []labeledEdge
+		want         []labeledEdge
+		wantEdgeType EdgeType
+		wantErr      bool
+	}{
+		{
+			name: "discard degenerate edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 0, 0, 0),
+		},
+		{
+			name: "keep duplicate degenerate edges",
+			opts: directed(DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 0, 0, 0),
+			want: edges(0, 0, 0, 0),
+		},
+		{
+			name: "merge duplicate degenerate edges",
+			opts: directed(DegenerateEdgesKeep, DuplicateEdgesMerge, SiblingPairsKeep),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, []int32{2}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}},
+		},
+		{
+			// The edge count is reduced to 2 (i.e., one undirected edge), and
+			// all labels are merged.
+			name: "merge undirected duplicate degenerate edges",
+			opts: undirected(DegenerateEdgesKeep, DuplicateEdgesMerge, SiblingPairsKeep),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, nil},
+				{GraphEdge{0, 0}, nil}, {GraphEdge{0, 0}, []int32{2}}},
+			want:         []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}, {GraphEdge{0, 0}, []int32{1, 2}}},
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			// Converting from undirected to directed cuts the edge count in
+			// half and merges any edge labels.
+			name: "converted undirected degenerate edges",
+			opts: undirected(DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsRequire),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, nil},
+				{GraphEdge{0, 0}, nil}, {GraphEdge{0, 0}, []int32{2}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}, {GraphEdge{0, 0}, []int32{1, 2}}},
+		},
+		{
+			name: "merge converted undirected duplicate degenerate edges",
+			opts: undirected(DegenerateEdgesKeep, DuplicateEdgesMerge, SiblingPairsRequire),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, nil},
+				{GraphEdge{0, 0}, nil}, {GraphEdge{0, 0}, []int32{2}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}},
+		},
+		{
+			// Degenerate edges are discarded if they are connected to any
+			// non-degenerate edges, incoming or outgoing.
+			name: "discard excess connected degenerate edges outgoing",
+			opts: directed(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 0, 0, 1),
+			want: edges(0, 1),
+		},
+		{
+			name: "discard excess connected degenerate edges incoming",
+			opts: directed(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 0, 1, 0),
+			want: edges(1, 0),
+		},
+		{
+			name: "discard excess connected degenerate edges at the end",
+			opts: directed(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 1, 1, 1),
+			want: edges(0, 1),
+		},
+		{
+			name: "discard excess connected degenerate edges at the start",
+			opts: directed(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(1, 0, 1, 1),
+			want: edges(1, 0),
+		},
+		{
+			// DiscardExcess merges any duplicate degenerate edges together.
+			name: "discard excess isolated degenerate edges",
+			opts: directed(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, []int32{2}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}},
+		},
+		{
+			name: "discard excess undirected isolated degenerate edges",
+			opts: undirected(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, nil},
+				{GraphEdge{0, 0}, []int32{2}}, {GraphEdge{0, 0}, nil}},
+			want:         []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2}}, {GraphEdge{0, 0}, []int32{1, 2}}},
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			name: "discard excess converted undirected isolated degenerate edges",
+			opts: undirected(DegenerateEdgesDiscardExcess, DuplicateEdgesKeep, SiblingPairsRequire),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, []int32{2}},
+				{GraphEdge{0, 0}, []int32{3}}, {GraphEdge{0, 0}, nil}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2, 3}}},
+		},
+		{
+			// Discarding sibling pairs merges the labels of the degenerate
+			// edges too, for consistency with the non-degenerate edges.
+			name: "sibling pairs discard merges degenerate edge labels",
+			opts: directed(DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, []int32{2}},
+				{GraphEdge{0, 0}, []int32{3}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2, 3}}, {GraphEdge{0, 0}, []int32{1, 2, 3}},
+				{GraphEdge{0, 0}, []int32{1, 2, 3}}},
+		},
+		{
+			name: "sibling pairs discard excess merges degenerate edge labels",
+			opts: directed(DegenerateEdgesKeep, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have: []labeledEdge{{GraphEdge{0, 0}, []int32{1}}, {GraphEdge{0, 0}, []int32{2}},
+				{GraphEdge{0, 0}, []int32{3}}},
+			want: []labeledEdge{{GraphEdge{0, 0}, []int32{1, 2, 3}}, {GraphEdge{0, 0}, []int32{1, 2, 3}},
+				{GraphEdge{0, 0}, []int32{1, 2, 3}}},
+		},
+		{
+			name: "keep sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsKeep),
+			have: edges(0, 1, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "merge duplicate sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsKeep),
+			have: edges(0, 1, 0, 1, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		// Matched pairs are discarded, leaving behind any excess edges.
+		{
+			name: "discard sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have: edges(0, 1, 1, 0),
+		},
+		{
+			name: "discard duplicate sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have: edges(0, 1, 0, 1, 1, 0, 1, 0),
+		},
+		{
+			name: "discard sibling pairs with excess outgoing edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have: edges(0, 1, 0, 1, 0, 1, 1, 0),
+			want: edges(0, 1, 0, 1),
+		},
+		{
+			name: "discard sibling pairs with excess incoming edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have: edges(0, 1, 1, 0, 1, 0, 1, 0),
+			want: edges(1, 0, 1, 0),
+		},
+		// Matched pairs are discarded, and then any remaining edges merged.
+		{
+			name: "discard sibling pairs merge duplicates",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscard),
+			have: edges(0, 1, 0, 1, 1, 0, 1, 0),
+		},
+		{
+			name: "discard sibling pairs merge excess outgoing edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscard),
+			have: edges(0, 1, 0, 1, 0, 1, 1, 0),
+			want: edges(0, 1),
+		},
+		{
+			name: "discard sibling pairs merge excess incoming edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscard),
+			have: edges(0, 1, 1, 0, 1, 0, 1, 0),
+			want: edges(1, 0),
+		},
+		// An undirected sibling pair consists of four edges, two in each
+		// direction, so the result always has either 0 or 2 edges.
+		{
+			name:         "discard undirected sibling pairs single edge",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have:         edges(0, 1, 1, 0),
+			want:         edges(0, 1, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			name:         "discard undirected sibling pairs",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have:         edges(0, 1, 0, 1, 1, 0, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			name:         "discard undirected sibling pairs with excess edges",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscard),
+			have:         edges(0, 1, 0, 1, 0, 1, 1, 0, 1, 0, 1, 0),
+			want:         edges(0, 1, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		// Like discard, except that one sibling pair is kept if the result
+		// would otherwise be empty.
+		{
+			name: "discard excess sibling pairs single pair",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "discard excess sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 0, 1, 1, 0, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "discard excess sibling pairs with excess outgoing edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 0, 1, 0, 1, 1, 0),
+			want: edges(0, 1, 0, 1),
+		},
+		{
+			name: "discard excess sibling pairs with excess incoming edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 1, 0, 1, 0, 1, 0),
+			want: edges(1, 0, 1, 0),
+		},
+		{
+			name: "discard excess sibling pairs merge duplicates",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 0, 1, 1, 0, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "discard excess sibling pairs merge excess outgoing edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 0, 1, 0, 1, 1, 0),
+			want: edges(0, 1),
+		},
+		{
+			name: "discard excess sibling pairs merge excess incoming edges",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesMerge, SiblingPairsDiscardExcess),
+			have: edges(0, 1, 1, 0, 1, 0, 1, 0),
+			want: edges(1, 0),
+		},
+		{
+			name:         "discard excess undirected sibling pairs single edge",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have:         edges(0, 1, 1, 0),
+			want:         edges(0, 1, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			// the undirected sibling pair kept is made of four edges
+			name:         "discard excess undirected sibling pairs",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have:         edges(0, 1, 0, 1, 1, 0, 1, 0),
+			want:         edges(0, 1, 0, 1, 1, 0, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			name:         "discard excess undirected sibling pairs with excess edges",
+			opts:         undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsDiscardExcess),
+			have:         edges(0, 1, 0, 1, 0, 1, 1, 0, 1, 0, 1, 0),
+			want:         edges(0, 1, 1, 0),
+			wantEdgeType: EdgeTypeUndirected,
+		},
+		{
+			name: "create sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsCreate),
+			have: edges(0, 1),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "create duplicate sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsCreate),
+			have: edges(0, 1, 0, 1),
+			want: edges(0, 1, 0, 1, 1, 0, 1, 0),
+		},
+		{
+			name: "require sibling pairs",
+			opts: directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsRequire),
+			have: edges(0, 1, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name:    "require missing sibling pairs",
+			opts:    directed(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsRequire),
+			have:    edges(0, 1),
+			want:    edges(0, 1, 1, 0),
+			wantErr: true,
+		},
+		// An undirected sibling pair consists of 4 edges, but creating
+		// sibling pairs also converts the graph to directed edges and cuts
+		// the number of edges in half.
+		{
+			name: "create undirected sibling pairs single edge",
+			opts: undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsCreate),
+			have: edges(0, 1, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "create undirected sibling pairs",
+			opts: undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsCreate),
+			have: edges(0, 1, 0, 1, 1, 0, 1, 0),
+			want: edges(0, 1, 1, 0),
+		},
+		{
+			name: "create undirected sibling pairs with excess edges",
+			opts: undirected(DegenerateEdgesDiscard, DuplicateEdgesKeep, SiblingPairsCreate),
+			have: edges(0, 1, 0, 1, 0, 1, 1, 0, 1, 0, 1, 0),
+			want: edges(0, 1, 0, 1, 1, 0, 1, 0),
+		},
+	}
+	for _, test := range tests {
+		lexicon := newIDSetLexicon()
+		var edges []GraphEdge
+		var ids []int32
+		for _, e := range test.have {
+			edges = append(edges, e.edge)
+			ids = append(ids, lexicon.add(e.ids...))
+		}
+		opts := test.opts
+		err := processEdges(&opts, &edges, &ids, lexicon)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%s: processEdges() error = %v, want error: %t", test.name, err, test.wantErr)
+		}
+		if opts.EdgeType != test.wantEdgeType {
+			t.Errorf("%s: processEdges() edge type = %v, want %v", test.name, opts.EdgeType, test.wantEdgeType)
+		}
+		if len(edges) != len(test.want) {
+			t.Errorf("%s: processEdges() = %v, want %v", test.name, edges, test.want)
+			continue
+		}
+		for i, want := range test.want {
+			if edges[i] != want.edge {
+				t.Errorf("%s: processEdges() = %v, want %v", test.name, edges, test.want)
+				break
+			}
+			if got := lexicon.idSet(ids[i]); len(want.ids) > 0 && !reflect.DeepEqual(got, want.ids) {
+				t.Errorf("%s: processEdges() edge %d input ids = %v, want %v", test.name, i, got, want.ids)
+			}
+		}
+	}
+}
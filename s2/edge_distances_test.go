@@ -894,7 +894,40 @@ func TestEdgeDistancesPointToRight(t *testing.T) {
 	}
 }
 
+func TestEdgeDistancesIsEdgeBNearEdgeA(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		tolerance float64 // degrees
+		want      bool
+	}{
+		// Edge is near itself.
+		{"5:5, 10:-5", "5:5, 10:-5", 1e-6, true},
+		// Edge is near its reverse.
+		{"5:5, 10:-5", "10:-5, 5:5", 1e-6, true},
+		// Short edge is near long edge.
+		{"10:0, -10:0", "2:1, -2:1", 1.0, true},
+		// Long edges cannot be near shorter edges.
+		{"2:1, -2:1", "10:0, -10:0", 1.0, false},
+		// Orthogonal crossing edges are not near each other...
+		{"10:0, -10:0", "0:1.5, 0:-1.5", 1.0, false},
+		// ... unless all points on B are within tolerance of A.
+		{"10:0, -10:0", "0:1.5, 0:-1.5", 2.0, true},
+		// Very long edges whose endpoints are close may have interior points
+		// that are far apart. Consecutive lines of longitude approach each
+		// other near the poles, but the maximum distance between their
+		// interior points is always exactly 1 degree.
+		{"0:0, 90:0", "0:1, 90:1", 0.5, false},
+		{"0:0, 90:0", "0:1, 90:1", 1.0, true},
+	}
+	for _, test := range tests {
+		a, b := parsePoints(test.a), parsePoints(test.b)
+		tolerance := s1.Angle(test.tolerance) * s1.Degree
+		if got := IsEdgeBNearEdgeA(a[0], a[1], b[0], b[1], tolerance); got != test.want {
+			t.Errorf("IsEdgeBNearEdgeA(%s, %s, %v) = %t, want %t", test.a, test.b, test.tolerance, got, test.want)
+		}
+	}
+}
+
 // TODO(rsned): Differences from C++
 //
-// TestEdgeDistancesEdgeBNearEdgeA
 // TestProjectError
@@ -221,6 +221,9 @@ func TestLaxPolygonShapeMultiLoopPolygon(t *testing.T) {
 			if got, want := loop[(j+1)%len(loop)], edge.V1; got != want {
 				t.Errorf("shape.Edge(%d).V1 = %v, want %v", numVertices+j, got, want)
 			}
+			if got, want := shape.ChainPosition(numVertices+j), (ChainPosition{i, j}); got != want {
+				t.Errorf("shape.ChainPosition(%d) = %v, want %v", numVertices+j, got, want)
+			}
 		}
 		numVertices += len(loop)
 	}
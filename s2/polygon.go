@@ -57,6 +57,10 @@ type Polygon struct {
 	// hasHoles tracks if this polygon has at least one hole.
 	hasHoles bool
 
+	// hasInconsistentLoopOrientations is set by PolygonFromOrientedLoops
+	// if the given loops did not have consistent shell/hole orientations.
+	hasInconsistentLoopOrientations bool
+
 	// numVertices keeps the running total of all of the vertices of the contained loops.
 	numVertices int
 
@@ -183,6 +187,18 @@ func PolygonFromOrientedLoops(loops []*Loop) *Polygon {
 		}
 	}
 
+	// Verify that the original loops had consistent shell/hole orientations.
+	// Each original loop L should have been inverted if and only if it now
+	// represents a hole.
+	for _, l := range p.loops {
+		if (containedOrigin[l] != l.ContainsOrigin()) != l.IsHole() {
+			// There is no point in saving the loop index, because the error is
+			// a property of the entire set of loops. In general there is no way
+			// to determine which ones are incorrect.
+			p.hasInconsistentLoopOrientations = true
+		}
+	}
+
 	return p
 }
 
@@ -462,18 +478,17 @@ func (p *Polygon) Validate() error {
 		}
 	}
 
-	// TODO(roberts): Uncomment the remaining checks when they are completed.
-
 	// Check for loop self-intersections and loop pairs that cross
 	// (including duplicate edges and vertices).
-	// if findSelfIntersection(p.index) {
-	//	return fmt.Errorf("polygon has loop pairs that cross")
-	// }
+	if err := findSelfIntersection(p.index); err != nil {
+		return err
+	}
 
-	// Check whether initOriented detected inconsistent loop orientations.
-	// if p.hasInconsistentLoopOrientations {
-	// 	return fmt.Errorf("inconsistent loop orientations detected")
-	// }
+	// Check whether PolygonFromOrientedLoops detected inconsistent loop
+	// orientations.
+	if p.hasInconsistentLoopOrientations {
+		return fmt.Errorf("inconsistent loop orientations detected")
+	}
 
 	// Finally, verify the loop nesting hierarchy.
 	return p.findLoopNestingError()
@@ -1267,15 +1282,11 @@ func (p *Polygon) ProjectToBoundary(point *Point) Point {
 // DistanceToPoint
 // DistanceToBoundary
 // ApproxContains/ApproxDisjoint for Polygons
-// InitTo{Intersection/ApproxIntersection/Union/ApproxUnion/Diff/ApproxDiff}
+// InitTo{Intersection/Union/Difference} - implemented in this fork.
+// IntersectWithPolyline/SubtractFromPolyline - implemented in this fork.
+// DestructiveUnion - implemented in this fork as UnionOfPolygons.
 // InitToSimplified
 // InitToSnapped
-// IntersectWithPolyline
-// ApproxIntersectWithPolyline
-// SubtractFromPolyline
-// ApproxSubtractFromPolyline
-// DestructiveUnion
-// DestructiveApproxUnion
 // InitToCellUnionBorder
 // IsNormalized
 // Equal/BoundaryEqual/BoundaryApproxEqual/BoundaryNear Polygons
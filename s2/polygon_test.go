@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"math"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/blevesearch/geo/s1"
@@ -387,19 +388,173 @@ func TestPolygonIsValidLoopNestingInvalid(t *testing.T) {
 	}
 }
 
+// concentricLoopVertices returns copies of the vertices of a set of
+// concentric test loops so that they can be modified before the loops are
+// constructed.
+func concentricLoopVertices(numLoops, minVertices int) [][]Point {
+	var vloops [][]Point
+	for _, l := range generatePolygonConcentricTestLoops(numLoops, minVertices) {
+		vloops = append(vloops, append([]Point(nil), l.Vertices()...))
+	}
+	return vloops
+}
+
+func loopsFromVertices(vloops [][]Point) []*Loop {
+	var loops []*Loop
+	for _, v := range vloops {
+		loops = append(loops, LoopFromPoints(v))
+	}
+	return loops
+}
+
+func TestPolygonIsValidDuplicateVertex(t *testing.T) {
+	const iters = 100
+
+	for iter := 0; iter < iters; iter++ {
+		vloops := concentricLoopVertices(1+randomUniformInt(6), 3)
+		vloop := vloops[randomUniformInt(len(vloops))]
+		n := len(vloop)
+		i := randomUniformInt(n)
+		j := randomUniformInt(n - 1)
+		if j >= i {
+			j++
+		}
+		vloop[i] = vloop[j]
+		checkPolygonInvalid(t, "duplicate vertex", loopsFromVertices(vloops), false, nil)
+	}
+}
+
+func TestPolygonIsValidSelfIntersection(t *testing.T) {
+	const iters = 100
+
+	for iter := 0; iter < iters; iter++ {
+		// Use multiple loops so that we can test both holes and shells. We
+		// need at least 5 vertices so that the modified edges don't intersect
+		// any nested loops.
+		vloops := concentricLoopVertices(1+randomUniformInt(6), 5)
+		vloop := vloops[randomUniformInt(len(vloops))]
+		n := len(vloop)
+		i := randomUniformInt(n)
+		vloop[i], vloop[(i+1)%n] = vloop[(i+1)%n], vloop[i]
+		checkPolygonInvalid(t, "self-intersection", loopsFromVertices(vloops), false, nil)
+	}
+}
+
+func TestPolygonIsValidLoopsCrossing(t *testing.T) {
+	const iters = 100
+
+	for iter := 0; iter < iters; iter++ {
+		vloops := concentricLoopVertices(2, 4)
+		// Both loops have the same number of vertices, and vertices at the
+		// same index position are collinear with the center point, so we can
+		// create a crossing by simply exchanging two vertices at the same
+		// index position.
+		n := len(vloops[0])
+		i := randomUniformInt(n)
+		vloops[0][i], vloops[1][i] = vloops[1][i], vloops[0][i]
+		if oneIn(2) {
+			// By copying the two adjacent vertices from one loop to the other,
+			// we can ensure that the crossings happen at vertices rather than
+			// edges.
+			vloops[0][(i+1)%n] = vloops[1][(i+1)%n]
+			vloops[0][(i+n-1)%n] = vloops[1][(i+n-1)%n]
+		}
+		checkPolygonInvalid(t, "loops crossing", loopsFromVertices(vloops), false, nil)
+	}
+}
+
+func TestPolygonIsValidDuplicateEdge(t *testing.T) {
+	const iters = 100
+
+	for iter := 0; iter < iters; iter++ {
+		vloops := concentricLoopVertices(2, 4)
+		n := len(vloops[0])
+		if oneIn(2) {
+			// Create a shared edge (same direction in both loops).
+			i := randomUniformInt(n)
+			vloops[0][i] = vloops[1][i]
+			vloops[0][(i+1)%n] = vloops[1][(i+1)%n]
+		} else {
+			// Create a reversed edge (opposite direction in each loop) by
+			// cutting loop 0 into two halves along one of its diagonals and
+			// replacing both loops with the result.
+			split := 2 + randomUniformInt(n-3)
+			vloops[1] = append([]Point{vloops[0][0]}, vloops[0][split:]...)
+			vloops[0] = vloops[0][:split+1]
+		}
+		checkPolygonInvalid(t, "duplicate edge", loopsFromVertices(vloops), false, nil)
+	}
+}
+
+func TestPolygonIsValidInconsistentOrientations(t *testing.T) {
+	const iters = 100
+
+	for iter := 0; iter < iters; iter++ {
+		// All of the loops are oriented counter-clockwise, so the holes do
+		// not have the opposite orientation of their shells.
+		loops := generatePolygonConcentricTestLoops(2+randomUniformInt(5), 3)
+		checkPolygonInvalid(t, "inconsistent orientations", loops, true, nil)
+	}
+}
+
+func TestPolygonValidateErrorMessages(t *testing.T) {
+	tests := []struct {
+		polygon string
+		want    string
+	}{
+		{
+			// A bowtie shaped loop.
+			polygon: "0:0, 0:10, 10:0, 10:10",
+			want:    "edge 1 crosses edge 3",
+		},
+		{
+			// Two loops whose edges cross.
+			polygon: "0:0, 0:10, 10:10, 10:0; 5:5, 5:15, 15:15, 15:5",
+			want:    "loop 0 edge 1 crosses loop 1 edge 0",
+		},
+		{
+			// The second loop repeats a vertex.
+			polygon: "0:0, 0:10, 10:10, 10:0; 2:2, 2:4, 4:4, 2:4, 3:3",
+			want:    "loop 1: edge 0 has duplicate vertex with edge 2",
+		},
+		{
+			// Two loops that share an edge.
+			polygon: "0:0, 0:10, 10:10, 10:0; 0:0, 0:10, -10:10, -10:0",
+			want:    "has duplicate near loop",
+		},
+	}
+
+	for _, test := range tests {
+		p := makePolygon(test.polygon, false)
+		err := p.Validate()
+		if err == nil {
+			t.Errorf("makePolygon(%q).Validate() = nil, want error containing %q", test.polygon, test.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("makePolygon(%q).Validate() = %v, want error containing %q", test.polygon, err, test.want)
+		}
+	}
+
+	// Valid polygons, including ones whose loops share a vertex, should
+	// still pass.
+	for _, s := range []string{
+		"0:0, 0:10, 10:10, 10:0; 2:2, 8:2, 8:8, 2:8",
+		"0:0, 0:10, 10:10, 10:0; 10:10, 10:20, 20:20, 20:10",
+	} {
+		if err := makePolygon(s, true).Validate(); err != nil {
+			t.Errorf("makePolygon(%q).Validate() = %v, want nil", s, err)
+		}
+	}
+}
+
 // TODO(roberts): Implement remaining validity tests.
 // IsValidTests
 //   TestUnitLength
 //   TestVertexCount
-//   TestDuplicateVertex
-//   TestSelfIntersection
 //   TestEmptyLoop
 //   TestFullLoop
-//   TestLoopsCrossing
-//   TestDuplicateEdge
-//   TestInconsistentOrientations
 //   TestLoopDepthNegative
-//   TestLoopNestingInvalid
 //   TestFuzzTest
 
 func TestPolygonParent(t *testing.T) {
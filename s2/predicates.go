@@ -921,6 +921,271 @@ func exactIntersectionOrdering(a, b, c, d, m, n r3.PreciseVector) int {
 	return prodab.Cmp(prodcd)
 }
 
+// CompareEdgeDistance returns -1, 0, or +1 according to whether the distance
+// from the point X to the edge A is less than, equal to, or greater than the
+// provided chord angle. Distances are measured with respect to the positions
+// of all points as though they were projected to lie exactly on the surface
+// of the unit sphere.
+//
+// This requires that the edge A is not antipodal (a0 != -a1).
+func CompareEdgeDistance(x, a0, a1 Point, r s1.ChordAngle) int {
+	sign := triageCompareEdgeDistance(x, a0, a1, r)
+	if sign != 0 {
+		return sign
+	}
+
+	// Optimization for the case where the edge is degenerate.
+	if a0 == a1 {
+		return CompareDistance(x, a0, r)
+	}
+	return exactCompareEdgeDistance(r3.PreciseVectorFromVector(x.Vector),
+		r3.PreciseVectorFromVector(a0.Vector), r3.PreciseVectorFromVector(a1.Vector),
+		big.NewFloat(float64(r)).SetPrec(big.MaxPrec))
+}
+
+// triageCompareEdgeDistance returns -1, 0, or +1 according to whether the
+// distance from X to the edge A is less than, equal to, or greater than r, or
+// 0 if the result is uncertain using float64 arithmetic.
+func triageCompareEdgeDistance(x, a0, a1 Point, r s1.ChordAngle) int {
+	dist, _ := updateMinDistance(x, a0, a1, 0, true)
+	err := minUpdateDistanceMaxError(dist) + 2*dblError*float64(dist)
+	if float64(dist)-err > float64(r) {
+		return 1
+	}
+	if float64(dist)+err < float64(r) {
+		return -1
+	}
+	return 0
+}
+
+// exactCompareEdgeDistance returns -1, 0, or +1 after comparing the distance
+// from X to the edge A with the squared chord length r2 using PreciseVectors.
+func exactCompareEdgeDistance(x, a0, a1 r3.PreciseVector, r2 *big.Float) int {
+	// If X is in the lune bounded by the planes through the origin that are
+	// perpendicular to the edge at a0 and a1, then the closest point on the
+	// edge is interior and the distance is measured to the line containing
+	// the edge. Otherwise the closest point is one of the two endpoints.
+	n := a0.Cross(a1)
+	if !n.IsZero() && x.Dot(n.Cross(a0)).Sign() > 0 && x.Dot(a1.Cross(n)).Sign() > 0 {
+		// The distance to the line is at most 90 degrees, so any limit of 90
+		// degrees or more is satisfied.
+		if r2.Cmp(big.NewFloat(2)) > 0 {
+			return -1
+		}
+		// Compare sin^2(dist) = (X.N)^2 / (|X|^2 |N|^2) with
+		// sin^2(r) = r2 * (1 - r2 / 4).
+		xn := x.Dot(n)
+		sin2R := newBigFloat().Mul(r2, newBigFloat().Sub(bigOne, newBigFloat().Mul(r2, big.NewFloat(0.25))))
+		cmp := newBigFloat().Sub(newBigFloat().Mul(xn, xn),
+			newBigFloat().Mul(sin2R, newBigFloat().Mul(x.Norm2(), n.Norm2())))
+		return cmp.Sign()
+	}
+	return minInt(exactCompareDistance(x, a0, r2), exactCompareDistance(x, a1, r2))
+}
+
+// EdgeCircumcenterSign returns the sign of the circumcenter of the triangle
+// ABC with respect to the edge X = (x0, x1). In other words, it returns +1
+// if the circumcenter of ABC is to the left of X, -1 if it is to the right,
+// and 0 if it lies exactly on the great circle through X (or if any of the
+// inputs are degenerate).
+//
+// The circumcenter of ABC is the point equidistant from A, B and C. When ABC
+// is oriented clockwise the circumcenter computed below is its antipode, so
+// the result is adjusted by the orientation of ABC.
+//
+// This requires that A, B and C are distinct.
+func EdgeCircumcenterSign(x0, x1, a, b, c Point) int {
+	// Return zero if the edge X is degenerate.
+	if x0 == x1 || x0 == (Point{x1.Mul(-1)}) {
+		return 0
+	}
+	abcSign := int(RobustSign(a, b, c))
+	if abcSign == 0 {
+		return 0
+	}
+	if sign := triageEdgeCircumcenterSign(x0, x1, a, b, c); sign != 0 {
+		return abcSign * sign
+	}
+	return abcSign * exactEdgeCircumcenterSign(
+		r3.PreciseVectorFromVector(x0.Vector), r3.PreciseVectorFromVector(x1.Vector),
+		r3.PreciseVectorFromVector(a.Vector), r3.PreciseVectorFromVector(b.Vector),
+		r3.PreciseVectorFromVector(c.Vector))
+}
+
+// triageEdgeCircumcenterSign returns the sign of (X0 x X1).Z where Z is the
+// (unnormalized) circumcenter of ABC, or 0 if the sign is uncertain.
+func triageEdgeCircumcenterSign(x0, x1, a, b, c Point) int {
+	// The circumcenter is in the direction of the normal of the plane
+	// through A, B and C, i.e. (B - A) x (C - A) = AxB + BxC + CxA.
+	z := a.Cross(b.Vector).Add(b.Cross(c.Vector)).Add(c.Cross(a.Vector))
+	nx := x0.Sub(x1.Vector).Cross(x0.Add(x1.Vector))
+	result := nx.Dot(z)
+
+	// The error in each cross product of unit vectors is at most a few
+	// ulps, so the error in Z and NX is bounded by a small multiple of
+	// dblEpsilon times their magnitudes plus a constant term.
+	err := (16*nx.Norm() + 32) * dblEpsilon * (z.Norm() + 3)
+	if result > err {
+		return 1
+	}
+	if result < -err {
+		return -1
+	}
+	return 0
+}
+
+// exactEdgeCircumcenterSign returns the exact sign of (X0 x X1).Z where Z is
+// the (unnormalized) circumcenter of ABC.
+func exactEdgeCircumcenterSign(x0, x1, a, b, c r3.PreciseVector) int {
+	z := a.Cross(b).Add(b.Cross(c)).Add(c.Cross(a))
+	return x0.Cross(x1).Dot(z).Sign()
+}
+
+// Excluded reports which of two sites (if any) is excluded by the other one
+// along a given edge. See VoronoiSiteExclusion for details.
+type Excluded int
+
+// These are the possible results of VoronoiSiteExclusion.
+const (
+	ExcludedFirst Excluded = iota
+	ExcludedSecond
+	ExcludedNeither
+	excludedUncertain
+)
+
+// voronoiSiteExclusionPrec is the precision used for the high precision
+// fallback in VoronoiSiteExclusion. The computation requires square roots so
+// it cannot be done exactly.
+const voronoiSiteExclusionPrec = 512
+
+// VoronoiSiteExclusion is used by Builder to determine which snapped sites
+// an edge passes through. Given two sites A and B that are both within the
+// distance r of the edge X = (x0, x1), where A is closer to x0 than B is, it
+// returns ExcludedFirst if the Voronoi region of A does not intersect X within
+// the distance r (i.e. B excludes A), ExcludedSecond if the same is true for
+// B, and ExcludedNeither otherwise.
+//
+// Define the "coverage disc" of a site S to be the disc centered at S with
+// radius r, and the "coverage interval" of S along the great circle through X
+// to be its intersection with the coverage disc. One site excludes the other
+// if its coverage interval contains the coverage interval of the other site.
+//
+// This requires that r < 90 degrees, CompareDistances(x0, a, b) < 0, and that
+// the edge X is not degenerate or antipodal.
+func VoronoiSiteExclusion(a, b, x0, x1 Point, r s1.ChordAngle) Excluded {
+	// If one site is closer than the other to both endpoints of X, then it is
+	// closer to every point on X. Note that this also handles the case where A
+	// and B are equidistant from every point on X (i.e., X is the perpendicular
+	// bisector of AB), because CompareDistances uses symbolic tie-breaking.
+	if CompareDistances(x1, a, b) < 0 {
+		return ExcludedSecond
+	}
+	if result := triageVoronoiSiteExclusion(a, b, x0, x1, float64(r)); result != excludedUncertain {
+		return result
+	}
+	return preciseVoronoiSiteExclusion(a, b, x0, x1, float64(r))
+}
+
+// triageVoronoiSiteExclusion computes the result of VoronoiSiteExclusion
+// using float64 arithmetic, or returns excludedUncertain.
+func triageVoronoiSiteExclusion(a, b, x0, x1 Point, r2 float64) Excluded {
+	// Let "ra" and "rb" be the radii (semi-widths) of the two coverage
+	// intervals, and let "d" be the angle between their center points (i.e.
+	// the projections of A and B onto the great circle through X). Then one
+	// interval contains the other if d < |ra - rb|, or equivalently if
+	// cos(d) > cos(ra - rb) = cos(ra)cos(rb) + sin(ra)sin(rb).
+	n := x0.Sub(x1.Vector).Cross(x0.Add(x1.Vector))
+	n2 := n.Norm2()
+	sin2R := r2 * (1 - 0.25*r2)
+
+	// For a site S at distance ds from the great circle through X, the
+	// coverage semi-width rs satisfies cos(r) = cos(ds) cos(rs), so that
+	// sin^2(rs) = (sin^2(r) - sin^2(ds)) / cos^2(ds).
+	coverage := func(s Point) (sn, sin2Rs float64) {
+		sn = s.Dot(n)
+		sin2Ds := sn * sn / (s.Norm2() * n2)
+		return sn, math.Max(0, (sin2R-sin2Ds)/(1-sin2Ds))
+	}
+	an, sin2Ra := coverage(a)
+	bn, sin2Rb := coverage(b)
+
+	// Both sin(ra) and sin(rb) are ill-conditioned when the corresponding
+	// site is almost exactly at distance r from X, so the error bound grows
+	// as they approach zero.
+	sinRa, sinRb := math.Sqrt(sin2Ra), math.Sqrt(sin2Rb)
+	if sinRa < 1e-6 || sinRb < 1e-6 {
+		return excludedUncertain
+	}
+	cosRa, cosRb := math.Sqrt(1-sin2Ra), math.Sqrt(1-sin2Rb)
+
+	pa2 := a.Norm2() - an*an/n2
+	pb2 := b.Norm2() - bn*bn/n2
+	cosD := (a.Dot(b.Vector) - an*bn/n2) / math.Sqrt(pa2*pb2)
+	g := cosD - (cosRa*cosRb + sinRa*sinRb)
+	err := (64 + 16/sinRa + 16/sinRb) * dblEpsilon
+	if g < -err {
+		return ExcludedNeither
+	}
+	if g <= err {
+		return excludedUncertain
+	}
+	if sin2Ra > sin2Rb {
+		return ExcludedSecond
+	}
+	return ExcludedFirst
+}
+
+// preciseVoronoiSiteExclusion computes the result of VoronoiSiteExclusion
+// using high precision floating point arithmetic. If the two coverage
+// intervals share an endpoint to within the available precision then
+// neither site is excluded.
+func preciseVoronoiSiteExclusion(a, b, x0, x1 Point, r2f float64) Excluded {
+	newFloat := func() *big.Float { return new(big.Float).SetPrec(voronoiSiteExclusionPrec) }
+	fromFloat := func(f float64) *big.Float { return newFloat().SetFloat64(f) }
+	mul := func(x, y *big.Float) *big.Float { return newFloat().Mul(x, y) }
+	sub := func(x, y *big.Float) *big.Float { return newFloat().Sub(x, y) }
+	quo := func(x, y *big.Float) *big.Float { return newFloat().Quo(x, y) }
+	sqrt := func(x *big.Float) *big.Float {
+		if x.Sign() <= 0 {
+			return newFloat()
+		}
+		return newFloat().Sqrt(x)
+	}
+	one := fromFloat(1)
+
+	pa := r3.PreciseVectorFromVector(a.Vector)
+	pb := r3.PreciseVectorFromVector(b.Vector)
+	n := r3.PreciseVectorFromVector(x0.Vector).Cross(r3.PreciseVectorFromVector(x1.Vector))
+	n2 := n.Norm2()
+	r2 := fromFloat(r2f)
+	sin2R := mul(r2, sub(one, mul(r2, fromFloat(0.25))))
+
+	coverage := func(s r3.PreciseVector) (sn, sin2Rs *big.Float) {
+		sn = s.Dot(n)
+		sin2Ds := quo(mul(sn, sn), mul(s.Norm2(), n2))
+		return sn, quo(sub(sin2R, sin2Ds), sub(one, sin2Ds))
+	}
+	an, sin2Ra := coverage(pa)
+	bn, sin2Rb := coverage(pb)
+	sinRa, sinRb := sqrt(sin2Ra), sqrt(sin2Rb)
+	cosRa, cosRb := sqrt(sub(one, sin2Ra)), sqrt(sub(one, sin2Rb))
+
+	pa2 := sub(pa.Norm2(), quo(mul(an, an), n2))
+	pb2 := sub(pb.Norm2(), quo(mul(bn, bn), n2))
+	cosD := quo(sub(pa.Dot(pb), quo(mul(an, bn), n2)), sqrt(mul(pa2, pb2)))
+	g := sub(cosD, newFloat().Add(mul(cosRa, cosRb), mul(sinRa, sinRb)))
+
+	// Treat values that are within the accumulated rounding error of zero as
+	// ties, in which case neither site excludes the other.
+	if newFloat().Abs(g).Cmp(big.NewFloat(math.Ldexp(1, -400))) <= 0 || g.Sign() < 0 {
+		return ExcludedNeither
+	}
+	if sin2Ra.Cmp(sin2Rb) > 0 {
+		return ExcludedSecond
+	}
+	return ExcludedFirst
+}
+
 // Gappa proof for TriageIntersectionOrdering
 //
 // # Use IEEE754 double precision, round-to-nearest by default.
@@ -1094,25 +1359,18 @@ func exactIntersectionOrdering(a, b, c, d, m, n r3.PreciseVector) int {
 // 13.89949493661167
 
 // TODO(roberts): Differences from C++
-// CompareEdgeDistance
 // CompareEdgeDirections
-// EdgeCircumcenterSign
-// GetVoronoiSiteExclusion
 //
 // getClosestVertex
 // triageCompareLineSin2Distance
 // triageCompareLineCos2Distance
 // triageCompareLineDistance
-// triageCompareEdgeDistance
 // exactCompareLineDistance
-// exactCompareEdgeDistance
 // triageCompareEdgeDirections
 // exactCompareEdgeDirections
 // arePointsAntipodal
 // arePointsLinearlyDependent
 // getCircumcenter
-// triageEdgeCircumcenterSign
-// exactEdgeCircumcenterSign
 // unperturbedSign
 // symbolicEdgeCircumcenterSign
-// exactVoronoiSiteExclusion
+// exactVoronoiSiteExclusion (approximated with high precision arithmetic)
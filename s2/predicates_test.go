@@ -1258,3 +1258,86 @@ func BenchmarkRobustSignNearCollinear(b *testing.B) {
 // TEST(EdgeCircumcenterSign, Consistency) {
 // TEST(VoronoiSiteExclusion, Coverage) {
 // TEST(VoronoiSiteExclusion, Consistency) {
+
+func TestPredicatesCompareEdgeDistance(t *testing.T) {
+	tests := []struct {
+		x, a0, a1 string
+		r         float64 // degrees
+		want      int
+	}{
+		// X is closest to the edge interior.
+		{"1:5", "0:0", "0:10", 0.5, 1},
+		{"1:5", "0:0", "0:10", 2, -1},
+		{"-1:5", "0:10", "0:0", 0.5, 1},
+		{"-1:5", "0:10", "0:0", 2, -1},
+		// X is closest to an edge endpoint.
+		{"0:-2", "0:0", "0:10", 1.5, 1},
+		{"0:-2", "0:0", "0:10", 3, -1},
+		{"0:13", "0:0", "0:10", 2, 1},
+		{"0:13", "0:0", "0:10", 4, -1},
+		// X is on the edge.
+		{"0:5", "0:0", "0:10", 0, 0},
+		{"0:5", "0:0", "0:10", 1, -1},
+		// Degenerate edge.
+		{"1:0", "0:0", "0:0", 0.5, 1},
+		{"1:0", "0:0", "0:0", 2, -1},
+	}
+	for _, test := range tests {
+		x, a0, a1 := parsePoint(test.x), parsePoint(test.a0), parsePoint(test.a1)
+		r := s1.ChordAngleFromAngle(s1.Angle(test.r) * s1.Degree)
+		if got := CompareEdgeDistance(x, a0, a1, r); got != test.want {
+			t.Errorf("CompareEdgeDistance(%s, %s, %s, %v) = %d, want %d", test.x, test.a0, test.a1, test.r, got, test.want)
+		}
+	}
+}
+
+func TestPredicatesEdgeCircumcenterSign(t *testing.T) {
+	tests := []struct {
+		x0, x1, a, b, c string
+		want            int
+	}{
+		// The circumcenter of ABC is north of the equator.
+		{"0:0", "0:10", "10:0", "10:10", "20:5", 1},
+		{"0:10", "0:0", "10:0", "10:10", "20:5", -1},
+		// Reversing the orientation of ABC does not change the result.
+		{"0:0", "0:10", "10:10", "10:0", "20:5", 1},
+		// The circumcenter of ABC is south of the equator.
+		{"0:0", "0:10", "-10:0", "-20:5", "-10:10", -1},
+		// The circumcenter of ABC is on the equator.
+		{"0:0", "0:10", "10:0", "0:10", "-10:0", 0},
+	}
+	for _, test := range tests {
+		got := EdgeCircumcenterSign(parsePoint(test.x0), parsePoint(test.x1),
+			parsePoint(test.a), parsePoint(test.b), parsePoint(test.c))
+		if got != test.want {
+			t.Errorf("EdgeCircumcenterSign(%s, %s, %s, %s, %s) = %d, want %d",
+				test.x0, test.x1, test.a, test.b, test.c, got, test.want)
+		}
+	}
+}
+
+func TestPredicatesVoronoiSiteExclusion(t *testing.T) {
+	tests := []struct {
+		a, b, x0, x1 string
+		r            float64 // degrees
+		want         Excluded
+	}{
+		// The coverage intervals of A and B along X do not overlap.
+		{"0:1", "0:9", "0:0", "0:10", 1, ExcludedNeither},
+		// The coverage interval of B is contained by that of A.
+		{"0:1", "0.9:1", "0:0", "0:10", 1, ExcludedSecond},
+		// The coverage interval of A is contained by that of B.
+		{"0.9:1.2", "0:1.7", "0:0", "0:10", 1, ExcludedFirst},
+		// The coverage intervals overlap but neither contains the other.
+		{"0:1", "0:2", "0:0", "0:10", 1, ExcludedNeither},
+	}
+	for _, test := range tests {
+		r := s1.ChordAngleFromAngle(s1.Angle(test.r) * s1.Degree)
+		got := VoronoiSiteExclusion(parsePoint(test.a), parsePoint(test.b),
+			parsePoint(test.x0), parsePoint(test.x1), r)
+		if got != test.want {
+			t.Errorf("VoronoiSiteExclusion(%s, %s, %s, %s, %v) = %v, want %v",
+				test.a, test.b, test.x0, test.x1, test.r, got, test.want)
+		}
+	}
+}
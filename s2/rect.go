@@ -167,8 +167,7 @@ func (r Rect) AddPoint(ll LatLng) Rect {
 // same Rect.
 //
 // If you are trying to grow a rectangle by a certain distance on the
-// sphere (e.g. 5km), refer to the ExpandedByDistance() C++ method implementation
-// instead.
+// sphere (e.g. 5km), use ExpandedByDistance instead.
 func (r Rect) expanded(margin LatLng) Rect {
 	lat := r.Lat.Expanded(margin.Lat.Radians())
 	lng := r.Lng.Expanded(margin.Lng.Radians())
@@ -183,6 +182,64 @@ func (r Rect) expanded(margin LatLng) Rect {
 	}
 }
 
+// ExpandedByDistance returns a rectangle that contains all points whose
+// minimum distance to the current rectangle is at most the given distance.
+// If the distance is negative, it instead returns a rectangle containing only
+// the points whose distance to the complement of the current rectangle is
+// more than -distance. Either way the result is conservative, i.e. it may
+// contain a few points that do not satisfy these conditions.
+func (r Rect) ExpandedByDistance(distance s1.Angle) Rect {
+	if r.IsEmpty() {
+		return r
+	}
+	if distance >= 0 {
+		// The most straightforward approach is to build a cap centered on each
+		// vertex and take the union of all the bounding rectangles (including
+		// the original rectangle; this is necessary for very large rectangles).
+		radius := s1.ChordAngleFromAngle(distance)
+		result := r
+		for k := 0; k < 4; k++ {
+			result = result.Union(CapFromCenterChordAngle(PointFromLatLng(r.Vertex(k)), radius).RectBound())
+		}
+		return result
+	}
+
+	// Shrink the latitude interval unless the latitude interval contains a
+	// pole and the longitude interval is full, in which case the rectangle
+	// has no boundary at that pole.
+	lat := r1.Interval{Lo: r.Lat.Lo - distance.Radians(), Hi: r.Lat.Hi + distance.Radians()}
+	if r.Lat.Lo <= validRectLatRange.Lo && r.Lng.IsFull() {
+		lat.Lo = validRectLatRange.Lo
+	}
+	if r.Lat.Hi >= validRectLatRange.Hi && r.Lng.IsFull() {
+		lat.Hi = validRectLatRange.Hi
+	}
+	if lat.IsEmpty() {
+		return EmptyRect()
+	}
+
+	// Maximum absolute value of a latitude in lat. At this latitude, the cap
+	// occupies the largest longitude interval.
+	maxAbsLat := math.Max(-lat.Lo, lat.Hi)
+
+	// Compute the largest longitude interval that the cap occupies. We use
+	// the law of sines for spherical triangles, as in Cap.RectBound.
+	//
+	// When sinA >= sinC, the cap covers all the latitude.
+	sinA := math.Sin(-distance.Radians())
+	sinC := math.Cos(maxAbsLat)
+	maxLngMargin := math.Pi / 2
+	if sinA < sinC {
+		maxLngMargin = math.Asin(sinA / sinC)
+	}
+
+	lng := r.Lng.Expanded(-maxLngMargin)
+	if lng.IsEmpty() {
+		return EmptyRect()
+	}
+	return Rect{Lat: lat, Lng: lng}
+}
+
 func (r Rect) String() string { return fmt.Sprintf("[Lo%v, Hi%v]", r.Lo(), r.Hi()) }
 
 // PolarClosure returns the rectangle unmodified if it does not include either pole.
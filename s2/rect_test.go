@@ -349,6 +349,90 @@ func TestRectExpanded(t *testing.T) {
 	}
 }
 
+func TestRectExpandedByDistance(t *testing.T) {
+	// Expanding and then shrinking by the same distance should give back
+	// the original rectangle.
+	for _, r := range []Rect{
+		rectFromDegrees(0, 0, 30, 90),
+		rectFromDegrees(-30, -90, 0, 0),
+	} {
+		d := s1.Angle(5) * s1.Degree
+		if got := r.ExpandedByDistance(d).ExpandedByDistance(-d); !got.ApproxEqual(r) {
+			t.Errorf("%v.ExpandedByDistance(%v).ExpandedByDistance(%v) = %v, want %v", r, d, -d, got, r)
+		}
+	}
+
+	tests := []struct {
+		input    Rect
+		distance s1.Angle
+		want     Rect
+	}{
+		{
+			rectFromDegrees(0, -90, 90, 180),
+			-5 * s1.Degree,
+			rectFromDegrees(5, 0, 85, 90),
+		},
+		{
+			// The rectangle has no boundary at the north pole.
+			rectFromDegrees(0, -180, 90, 180),
+			-5 * s1.Degree,
+			rectFromDegrees(5, -180, 90, 180),
+		},
+		{
+			rectFromDegrees(-90, -90, 0, 180),
+			-5 * s1.Degree,
+			rectFromDegrees(-85, 0, -5, 90),
+		},
+		{
+			// The latitude interval becomes empty.
+			rectFromDegrees(0, 0, 9.9, 90),
+			-5 * s1.Degree,
+			EmptyRect(),
+		},
+		{
+			// The longitude interval becomes empty.
+			rectFromDegrees(0, 0, 89.9, 90),
+			-5 * s1.Degree,
+			EmptyRect(),
+		},
+		{
+			EmptyRect(),
+			5 * s1.Degree,
+			EmptyRect(),
+		},
+	}
+	for _, test := range tests {
+		got := test.input.ExpandedByDistance(test.distance)
+		if test.want.IsEmpty() {
+			if !got.IsEmpty() {
+				t.Errorf("%v.ExpandedByDistance(%v) = %v, want empty", test.input, test.distance, got)
+			}
+			continue
+		}
+		if !got.ApproxEqual(test.want) {
+			t.Errorf("%v.ExpandedByDistance(%v) = %v, want %v", test.input, test.distance, got, test.want)
+		}
+	}
+
+	// Every point within the distance of the rectangle should be contained
+	// in the expanded rectangle.
+	r := rectFromDegrees(10, 20, 30, 40)
+	d := s1.Angle(3) * s1.Degree
+	expanded := r.ExpandedByDistance(d)
+	for i := 0; i < 4; i++ {
+		v := PointFromLatLng(r.Vertex(i))
+		for _, axis := range []Point{PointFromCoords(1, 0, 0), PointFromCoords(0, 1, 0), PointFromCoords(0, 0, 1)} {
+			dir := Point{v.Cross(axis.Vector).Normalize()}
+			for _, b := range []Point{dir, Point{dir.Mul(-1)}} {
+				p := InterpolateAtDistance(0.999*d, v, b)
+				if !expanded.ContainsPoint(p) {
+					t.Errorf("%v.ContainsPoint(%v) = false, want true", expanded, p)
+				}
+			}
+		}
+	}
+}
+
 func TestRectPolarClosure(t *testing.T) {
 	tests := []struct {
 		r    Rect
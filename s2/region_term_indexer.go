@@ -256,7 +256,7 @@ func (o *Options) trueMaxLevel() int {
 //    - the union or intersection of arbitrary other regions
 //
 // So for example, if you want to query documents that are within 500 meters
-// of a polyline, you could use an S2ShapeIndexBufferedRegion containing the
+// of a polyline, you could use a ShapeIndexBufferedRegion containing the
 // polyline with a radius of 500 meters.
 //
 // For example usage refer:
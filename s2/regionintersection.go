@@ -0,0 +1,89 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+// A RegionIntersection represents the intersection of a set of regions. It
+// is convenient for computing a covering of the intersection of a set of
+// regions, e.g. "inside this polygon and within this cap".
+//
+// An empty RegionIntersection contains the entire sphere, since it imposes
+// no constraints on the points it contains.
+//
+// Note that IntersectsCell is conservative: it returns true if every region
+// intersects the cell, even though the regions may not have any point in
+// common within the cell.
+type RegionIntersection []Region
+
+// CapBound returns a bounding cap for this RegionIntersection.
+func (ri RegionIntersection) CapBound() Cap { return ri.RectBound().CapBound() }
+
+// RectBound returns a bounding latitude-longitude rectangle for this
+// RegionIntersection.
+func (ri RegionIntersection) RectBound() Rect {
+	ret := FullRect()
+	for _, reg := range ri {
+		ret = ret.Intersection(reg.RectBound())
+	}
+	return ret
+}
+
+// ContainsCell reports whether the given Cell is contained by this
+// RegionIntersection, i.e. whether every region contains the cell.
+func (ri RegionIntersection) ContainsCell(c Cell) bool {
+	for _, reg := range ri {
+		if !reg.ContainsCell(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsCell reports whether this RegionIntersection may intersect the
+// given cell, i.e. whether every region intersects the cell.
+func (ri RegionIntersection) IntersectsCell(c Cell) bool {
+	for _, reg := range ri {
+		if !reg.IntersectsCell(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsPoint reports whether this RegionIntersection contains the Point.
+func (ri RegionIntersection) ContainsPoint(p Point) bool {
+	for _, reg := range ri {
+		if !reg.ContainsPoint(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// CellUnionBound computes a covering of the RegionIntersection by
+// intersecting the cell union bounds of its regions.
+func (ri RegionIntersection) CellUnionBound() []CellID {
+	if len(ri) == 0 {
+		return FullCap().CellUnionBound()
+	}
+
+	ret := CellUnion(ri[0].CellUnionBound())
+	ret.Normalize()
+	for _, reg := range ri[1:] {
+		cu := CellUnion(reg.CellUnionBound())
+		cu.Normalize()
+		ret = CellUnionFromIntersection(ret, cu)
+	}
+	return ret
+}
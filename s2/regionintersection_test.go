@@ -0,0 +1,157 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"testing"
+
+	"github.com/blevesearch/geo/s1"
+)
+
+// Make sure RegionIntersection implements Region.
+var _ Region = RegionIntersection{}
+
+func TestEmptyRegionIntersectionIsFull(t *testing.T) {
+	var empty RegionIntersection
+
+	if got := empty.CapBound(); !got.IsFull() {
+		t.Errorf("empty region intersection cap = %v, want full", got)
+	}
+	if got := empty.RectBound(); !got.IsFull() {
+		t.Errorf("empty region intersection rect = %v, want full", got)
+	}
+	if got := len(empty.CellUnionBound()); got != 6 {
+		t.Errorf("len(empty region intersection cell union bound) = %d, want 6", got)
+	}
+	if !empty.ContainsCell(face0Cell) {
+		t.Errorf("%v.ContainsCell(%v) = false, want true", empty, face0Cell)
+	}
+	if p := randomPoint(); !empty.ContainsPoint(p) {
+		t.Errorf("%v.ContainsPoint(%v) = false, want true", empty, p)
+	}
+}
+
+var twoCapsRegionIntersection = RegionIntersection{
+	CapFromCenterAngle(PointFromLatLng(LatLngFromDegrees(0, 0)), 10*s1.Degree),
+	CapFromCenterAngle(PointFromLatLng(LatLngFromDegrees(0, 10)), 10*s1.Degree),
+}
+
+func TestRegionIntersectionOfTwoCapsHasCorrectBound(t *testing.T) {
+	got := twoCapsRegionIntersection.RectBound()
+
+	want := rectFromDegrees(-10, 0, 10, 10)
+	if !got.ApproxEqual(want) {
+		t.Errorf("%v.RectBound() = %v, want %v", twoCapsRegionIntersection, got, want)
+	}
+}
+
+func TestRegionIntersectionOfTwoCapsContainsPoint(t *testing.T) {
+	testCases := []struct {
+		ll   LatLng
+		want bool
+	}{
+		{LatLngFromDegrees(0, 5), true},
+		{LatLngFromDegrees(5, 5), true},
+		{LatLngFromDegrees(0, -5), false},
+		{LatLngFromDegrees(0, 15), false},
+		{LatLngFromDegrees(9, 5), false},
+	}
+
+	for _, tc := range testCases {
+		got := twoCapsRegionIntersection.ContainsPoint(PointFromLatLng(tc.ll))
+
+		if got != tc.want {
+			t.Errorf("%v.ContainsPoint(%v) = %t, want %t", twoCapsRegionIntersection, tc.ll, got, tc.want)
+		}
+	}
+}
+
+func TestRegionIntersectionOfTwoCapsCells(t *testing.T) {
+	testCases := []struct {
+		ll             LatLng
+		level          int
+		wantContains   bool
+		wantIntersects bool
+	}{
+		// A small cell in the overlap of the two caps.
+		{LatLngFromDegrees(0, 5), 10, true, true},
+		// A small cell in only one cap.
+		{LatLngFromDegrees(0, -5), 10, false, false},
+		// A large cell spanning both caps.
+		{LatLngFromDegrees(0, 5), 2, false, true},
+	}
+
+	for _, tc := range testCases {
+		cell := CellFromCellID(CellIDFromLatLng(tc.ll).Parent(tc.level))
+		if got := twoCapsRegionIntersection.ContainsCell(cell); got != tc.wantContains {
+			t.Errorf("%v.ContainsCell(%v) = %t, want %t", twoCapsRegionIntersection, cell.ID(), got, tc.wantContains)
+		}
+		if got := twoCapsRegionIntersection.IntersectsCell(cell); got != tc.wantIntersects {
+			t.Errorf("%v.IntersectsCell(%v) = %t, want %t", twoCapsRegionIntersection, cell.ID(), got, tc.wantIntersects)
+		}
+	}
+}
+
+func TestRegionIntersectionDisjointRegionsCovering(t *testing.T) {
+	disjoint := RegionIntersection{
+		CapFromCenterAngle(PointFromLatLng(LatLngFromDegrees(0, 0)), 1*s1.Degree),
+		CapFromCenterAngle(PointFromLatLng(LatLngFromDegrees(0, 90)), 1*s1.Degree),
+	}
+
+	if got := disjoint.RectBound(); !got.IsEmpty() {
+		t.Errorf("%v.RectBound() = %v, want empty", disjoint, got)
+	}
+
+	cov := NewRegionCoverer()
+	if got := cov.Covering(disjoint); len(got) != 0 {
+		t.Errorf("covering of disjoint regions = %v, want empty", got)
+	}
+}
+
+func TestRegionIntersectionPolygonAndCapCovering(t *testing.T) {
+	polygon := makePolygon("0:0, 0:20, 20:20, 20:0", true)
+	c := CapFromCenterAngle(PointFromLatLng(LatLngFromDegrees(20, 20)), 5*s1.Degree)
+	region := RegionIntersection{polygon, c}
+
+	cov := NewRegionCoverer()
+	cov.MaxCells = 20
+	covering := cov.Covering(region)
+	if len(covering) == 0 {
+		t.Fatalf("covering of %v is empty", region)
+	}
+
+	// Every cell of the covering must intersect both regions.
+	for _, id := range covering {
+		cell := CellFromCellID(id)
+		if !polygon.IntersectsCell(cell) || !c.IntersectsCell(cell) {
+			t.Errorf("covering cell %v does not intersect both regions", id)
+		}
+	}
+
+	// The covering should be smaller than the covering of the cap alone,
+	// since it only covers one quadrant of the cap.
+	capCovering := cov.Covering(c)
+	if got, limit := covering.ExactArea(), capCovering.ExactArea(); got >= limit {
+		t.Errorf("covering area = %v, want less than cap covering area %v", got, limit)
+	}
+
+	// Every point in the intersection must be in the covering.
+	for i := 0; i < 100; i++ {
+		p := samplePointFromCap(c)
+		if region.ContainsPoint(p) && !covering.ContainsPoint(p) {
+			t.Errorf("covering does not contain %v", p)
+		}
+	}
+}
@@ -541,9 +541,11 @@ func (t *tracker) restoreStateBefore(limitShapeID int32) {
 	t.savedIDs = nil
 }
 
-// lowerBound returns the shapeID of the first entry x where x >= shapeID.
+// lowerBound returns the position of the first entry x where x >= shapeID.
 func (t *tracker) lowerBound(shapeID int32) int32 {
-	panic("not implemented")
+	return int32(sort.Search(len(t.shapeIDs), func(i int) bool {
+		return t.shapeIDs[i] >= shapeID
+	}))
 }
 
 // removedShape represents a set of edges from the given shape that is queued for removal.
@@ -601,6 +603,11 @@ type ShapeIndex struct {
 	cellMap map[CellID]*ShapeIndexCell
 	// Track the ordered list of cell IDs.
 	cells []CellID
+	// newCells collects the IDs of the cells made while the index is being
+	// updated, which are merged into cells once it is done. cells keeps
+	// listing the existing cells until then, so that the iterators used by
+	// the update can locate them.
+	newCells []CellID
 
 	// The current status of the index; accessed atomically.
 	status int32
@@ -687,6 +694,8 @@ func (s *ShapeIndex) Reset() {
 	s.nextID = 0
 	s.cellMap = make(map[CellID]*ShapeIndexCell)
 	s.cells = nil
+	s.pendingAdditionsPos = 0
+	s.pendingRemovals = nil
 	atomic.StoreInt32(&s.status, fresh)
 }
 
@@ -772,7 +781,7 @@ func (s *ShapeIndex) Remove(shape Shape) {
 	removed := &removedShape{
 		shapeID:               id,
 		hasInterior:           shape.Dimension() == 2,
-		containsTrackerOrigin: shape.ReferencePoint().Contained,
+		containsTrackerOrigin: containsBruteForce(shape, trackerOrigin()),
 		edges:                 make([]Edge, numEdges),
 	}
 
@@ -850,19 +859,53 @@ func (s *ShapeIndex) applyUpdatesInternal() {
 		s.removeShapeInternal(p, allEdges, t)
 	}
 
-	for id := s.pendingAdditionsPos; id < int32(len(s.shapes)); id++ {
+	// The IDs of removed shapes are not reused, so the shapes being added
+	// are those from pendingAdditionsPos up to nextID, rather than up to
+	// the number of shapes in the index.
+	for id := s.pendingAdditionsPos; id < s.nextID; id++ {
 		s.addShapeInternal(id, allEdges, t)
 	}
 
 	for face := 0; face < 6; face++ {
 		s.updateFaceEdges(face, allEdges[face], t)
 	}
+	s.mergeNewCells()
 
 	s.pendingRemovals = s.pendingRemovals[:0]
-	s.pendingAdditionsPos = int32(len(s.shapes))
+	s.pendingAdditionsPos = s.nextID
 	// It is the caller's responsibility to update the index status.
 }
 
+// mergeNewCells merges the cells made by an update into the ordered list
+// of cells, along with the existing cells that the update kept.
+func (s *ShapeIndex) mergeNewCells() {
+	if len(s.cells) == 0 {
+		s.cells, s.newCells = s.newCells, nil
+		return
+	}
+	cells := make([]CellID, 0, len(s.cells)+len(s.newCells))
+	i, j := 0, 0
+	for i < len(s.cells) || j < len(s.newCells) {
+		switch {
+		case j == len(s.newCells) || (i < len(s.cells) && s.cells[i] < s.newCells[j]):
+			// An existing cell absorbed by the update is no longer in
+			// the map, unless it was made again.
+			if _, ok := s.cellMap[s.cells[i]]; ok {
+				cells = append(cells, s.cells[i])
+			}
+			i++
+		case i == len(s.cells) || s.newCells[j] < s.cells[i]:
+			cells = append(cells, s.newCells[j])
+			j++
+		default:
+			cells = append(cells, s.newCells[j])
+			i++
+			j++
+		}
+	}
+	s.cells, s.newCells = cells, nil
+}
+
 // addShapeInternal clips all edges of the given shape to the six cube faces,
 // adds the clipped edges to the set of allEdges, and starts tracking its
 // interior if necessary.
@@ -977,8 +1020,9 @@ func (s *ShapeIndex) shrinkToFit(pcell *PaddedCell, bound r2.Rect) CellID {
 
 	if !s.isFirstUpdate() && shrunkID != pcell.CellID() {
 		// Don't shrink any smaller than the existing index cells, since we need
-		// to combine the new edges with those cells.
-		iter := s.Iterator()
+		// to combine the new edges with those cells. The iterator must not
+		// apply the pending updates, which are being applied.
+		iter := NewShapeIndexIterator(s)
 		if iter.LocateCellID(shrunkID) == Indexed {
 			shrunkID = iter.CellID()
 		}
@@ -1032,7 +1076,7 @@ func (s *ShapeIndex) updateEdges(pcell *PaddedCell, edges []*clippedEdge, t *tra
 		// There may be existing index cells contained inside pcell. If we
 		// encounter such a cell, we need to combine the edges being updated with
 		// the existing cell contents by absorbing the cell.
-		iter := s.Iterator()
+		iter := NewShapeIndexIterator(s)
 		r := iter.LocateCellID(pcell.id)
 		switch r {
 		case Disjoint:
@@ -1040,7 +1084,7 @@ func (s *ShapeIndex) updateEdges(pcell *PaddedCell, edges []*clippedEdge, t *tra
 		case Indexed:
 			// Absorb the index cell by transferring its contents to edges and
 			// deleting it. We also start tracking the interior of any new shapes.
-			s.absorbIndexCell(pcell, iter, edges, t)
+			edges = s.absorbIndexCell(pcell, iter, edges, t)
 			indexCellAbsorbed = true
 			disjointFromIndex = true
 		case Subdivided:
@@ -1215,7 +1259,7 @@ func (s *ShapeIndex) makeIndexCell(p *PaddedCell, edges []*clippedEdge, t *track
 	for i := 0; i < numShapes; i++ {
 		var clipped *clippedShape
 		// advance to next value base + i
-		eshapeID := int32(s.Len())
+		eshapeID := s.nextID
 		cshapeID := eshapeID // Sentinels
 
 		if eNext != len(edges) {
@@ -1249,7 +1293,7 @@ func (s *ShapeIndex) makeIndexCell(p *PaddedCell, edges []*clippedEdge, t *track
 
 	// Add this cell to the map.
 	s.cellMap[p.id] = cell
-	s.cells = append(s.cells, p.id)
+	s.newCells = append(s.newCells, p.id)
 
 	// Shift the tracker focus point to the exit vertex of this cell.
 	if t.isActive && len(edges) != 0 {
@@ -1367,7 +1411,8 @@ func (s *ShapeIndex) clipVAxis(edge *clippedEdge, middle r1.Interval) (a, b *cli
 // and/or "tracker", and then delete this cell from the index. If edges includes
 // any edges that are being removed, this method also updates their
 // InteriorTracker state to correspond to the exit vertex of this cell.
-func (s *ShapeIndex) absorbIndexCell(p *PaddedCell, iter *ShapeIndexIterator, edges []*clippedEdge, t *tracker) {
+// It returns the edges to update the cell with.
+func (s *ShapeIndex) absorbIndexCell(p *PaddedCell, iter *ShapeIndexIterator, edges []*clippedEdge, t *tracker) []*clippedEdge {
 	// When we absorb a cell, we erase all the edges that are being removed.
 	// However when we are finished with this cell, we want to restore the state
 	// of those edges (since that is how we find all the index cells that need
@@ -1424,7 +1469,7 @@ func (s *ShapeIndex) absorbIndexCell(p *PaddedCell, iter *ShapeIndexIterator, ed
 		// cell is inside the shape, but we only know whether the center of the
 		// cell is inside the shape, so we need to test all the edges against the
 		// line segment from the cell center to the entry vertex.
-		edge := &faceEdge{
+		edge := faceEdge{
 			shapeID:     shapeID,
 			hasInterior: shape.Dimension() == 2,
 		}
@@ -1454,7 +1499,9 @@ func (s *ShapeIndex) absorbIndexCell(p *PaddedCell, iter *ShapeIndexIterator, ed
 			if !ok {
 				panic("invariant failure in ShapeIndex")
 			}
-			faceEdges = append(faceEdges, edge)
+			// Append a copy, since the clipped edges point to each of them.
+			fe := edge
+			faceEdges = append(faceEdges, &fe)
 		}
 	}
 	// Now create a clippedEdge for each faceEdge, and put them in "new_edges".
@@ -1476,12 +1523,9 @@ func (s *ShapeIndex) absorbIndexCell(p *PaddedCell, iter *ShapeIndexIterator, ed
 		}
 	}
 
-	// Update the edge list and delete this cell from the index.
-	// TODO(rsned): Figure out best fix for this. Linters are
-	// flagging the swap because newEdges is no longer used after
-	// this.
-	edges, newEdges = newEdges, edges // nolint
+	// Delete this cell from the index, and return the new edge list.
 	delete(s.cellMap, p.id)
+	return newEdges
 }
 
 // testAllEdges calls the trackers testEdge on all edges from shapes that have interiors.
@@ -1542,5 +1586,17 @@ func maxLevelForEdge(edge Edge) int {
 
 // removeShapeInternal does the actual work for removing a given shape from the index.
 func (s *ShapeIndex) removeShapeInternal(removed *removedShape, allEdges [][]faceEdge, t *tracker) {
-	// TODO(roberts): finish the implementation of this.
+	faceEdge := faceEdge{
+		edgeID:      -1, // Not used or needed for removed edges.
+		shapeID:     removed.shapeID,
+		hasInterior: removed.hasInterior,
+	}
+	if faceEdge.hasInterior {
+		t.addShape(faceEdge.shapeID, removed.containsTrackerOrigin)
+	}
+	for _, edge := range removed.edges {
+		faceEdge.edge = edge
+		faceEdge.MaxLevel = maxLevelForEdge(edge)
+		s.addFaceEdge(faceEdge, allEdges)
+	}
 }
@@ -0,0 +1,167 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"github.com/blevesearch/geo/s1"
+)
+
+// ShapeIndexBufferedRegion wraps a ShapeIndex and implements the Region
+// interface for the set of points within a given radius of the indexed
+// geometry. This can be used, for example, to find all documents within
+// 500 meters of a polyline by using a RegionCoverer to compute a covering
+// of this region.
+//
+// Note that the covering computed for a buffered region follows the shape
+// of the buffered geometry, so it is usually much smaller than the covering
+// of a single Cap that contains it.
+//
+// This type is not safe for concurrent use, since the underlying queries
+// keep some internal state.
+type ShapeIndexBufferedRegion struct {
+	index  *ShapeIndex
+	radius s1.ChordAngle
+
+	// radiusSuccessor is the smallest ChordAngle larger than radius. The
+	// EdgeQuery methods only support strict comparisons, so this is used
+	// to implement the "less than or equal to" tests.
+	radiusSuccessor s1.ChordAngle
+
+	// region is the unbuffered region of the index, used for bounds and for
+	// quick containment tests.
+	region *ShapeIndexRegion
+	query  *EdgeQuery
+}
+
+// Enforce Region interface satisfaction similar to other types that implement Region.
+var _ Region = (*ShapeIndexBufferedRegion)(nil)
+
+// NewShapeIndexBufferedRegion returns a region representing all points within
+// the given radius of any geometry in the index. A radius of zero gives the
+// same region as the index itself, except that the boundary of each shape is
+// always included.
+//
+// The index must not be modified while the region is being used.
+func NewShapeIndexBufferedRegion(index *ShapeIndex, radius s1.ChordAngle) *ShapeIndexBufferedRegion {
+	return &ShapeIndexBufferedRegion{
+		index:           index,
+		radius:          radius,
+		radiusSuccessor: radius.Successor(),
+		region:          index.Region(),
+		query:           NewClosestEdgeQuery(index, NewClosestEdgeQueryOptions().IncludeInteriors(true)),
+	}
+}
+
+// Index returns the index this region was constructed from.
+func (b *ShapeIndexBufferedRegion) Index() *ShapeIndex {
+	return b.index
+}
+
+// Radius returns the buffer radius of this region.
+func (b *ShapeIndexBufferedRegion) Radius() s1.ChordAngle {
+	return b.radius
+}
+
+// CapBound returns a bounding spherical cap for this region. This is not
+// guaranteed to be exact.
+func (b *ShapeIndexBufferedRegion) CapBound() Cap {
+	c := b.region.CapBound()
+	return CapFromCenterChordAngle(c.Center(), c.radius.Add(b.radius))
+}
+
+// RectBound returns a bounding rectangle for this region. The bounds are not
+// guaranteed to be tight.
+func (b *ShapeIndexBufferedRegion) RectBound() Rect {
+	return b.region.RectBound().ExpandedByDistance(b.radius.Angle())
+}
+
+// CellUnionBound returns a small collection of CellIDs whose union covers
+// the region.
+func (b *ShapeIndexBufferedRegion) CellUnionBound() []CellID {
+	// We start with a covering of the original ShapeIndex, and then expand
+	// it by replacing each cell with a block of 4 cells whose union contains
+	// the original cell buffered by the given radius.
+	//
+	// This method could be made more accurate by covering the buffered region
+	// with cells smaller than the original cells, but this is not efficient.
+	origCellIDs := b.region.CellUnionBound()
+
+	maxLevel := MinWidthMetric.MaxLevel(b.radius.Angle().Radians()) - 1
+	if maxLevel < 0 {
+		return FullCap().CellUnionBound()
+	}
+
+	var cellIDs []CellID
+	for _, id := range origCellIDs {
+		if id.isFace() {
+			return FullCap().CellUnionBound()
+		}
+		cellIDs = append(cellIDs, id.VertexNeighbors(minInt(maxLevel, id.Level()-1))...)
+	}
+	return cellIDs
+}
+
+// ContainsCell reports whether this region completely contains the given
+// cell. This method may return false negatives, i.e. it is not guaranteed
+// to return true for every cell that is contained by the region.
+func (b *ShapeIndexBufferedRegion) ContainsCell(cell Cell) bool {
+	// Return true if the buffered region is guaranteed to cover the whole
+	// globe.
+	if b.radiusSuccessor > s1.StraightChordAngle {
+		return true
+	}
+
+	// To implement this method perfectly would require computing the
+	// directed Hausdorff distance, which is expensive (and not currently
+	// implemented). However the following heuristic is almost as good in
+	// practice and much cheaper to compute.
+
+	// Return true if the unbuffered region contains this cell.
+	if b.region.ContainsCell(cell) {
+		return true
+	}
+
+	// Otherwise approximate the cell by its bounding cap.
+	//
+	// It would be slightly more accurate to first find the closest point in
+	// the indexed geometry to the cell, and then measure the actual maximum
+	// distance from that point to the cell (a poor man's Hausdorff distance).
+	// But based on actual tests this is not worthwhile.
+	c := cell.CapBound()
+	if b.radius < c.radius {
+		return false
+	}
+
+	// Return true if the distance to the cell center plus the radius of the
+	// cell's bounding cap is less than or equal to the buffer radius.
+	target := NewMinDistanceToPointTarget(cell.Center())
+	return b.query.IsDistanceLess(target, b.radiusSuccessor.Sub(c.radius))
+}
+
+// IntersectsCell reports whether this region intersects the given cell.
+func (b *ShapeIndexBufferedRegion) IntersectsCell(cell Cell) bool {
+	// Return true if the distance is less than or equal to the radius.
+	target := NewMinDistanceToCellTarget(cell)
+	return b.query.IsDistanceLess(target, b.radiusSuccessor)
+}
+
+// ContainsPoint reports whether the given point is within the buffer radius
+// of any geometry in the index.
+func (b *ShapeIndexBufferedRegion) ContainsPoint(p Point) bool {
+	// IsDistanceLess is faster than testing for "less than or equal", so we
+	// compare against the successor of the radius.
+	target := NewMinDistanceToPointTarget(p)
+	return b.query.IsDistanceLess(target, b.radiusSuccessor)
+}
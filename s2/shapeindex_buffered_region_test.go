@@ -0,0 +1,206 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"testing"
+
+	"github.com/blevesearch/geo/s1"
+)
+
+func TestShapeIndexBufferedRegionEmptyIndex(t *testing.T) {
+	// Test buffering an empty ShapeIndex.
+	index := NewShapeIndex()
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(2*s1.Degree))
+	coverer := NewRegionCoverer()
+	if got := coverer.Covering(region); len(got) != 0 {
+		t.Errorf("Covering(empty buffered region) = %v, want empty", got)
+	}
+}
+
+func TestShapeIndexBufferedRegionFullPolygon(t *testing.T) {
+	// Test buffering a full polygon.
+	index := makeShapeIndex("# # full")
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(2*s1.Degree))
+	coverer := NewRegionCoverer()
+	covering := coverer.Covering(region)
+	if len(covering) != 6 {
+		t.Fatalf("len(Covering(full buffered region)) = %d, want 6", len(covering))
+	}
+	for _, id := range covering {
+		if !id.isFace() {
+			t.Errorf("covering cell %v is not a face cell", id)
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionFullAfterBuffering(t *testing.T) {
+	// Test a region that becomes full after buffering.
+	index := makeShapeIndex("0:0 | 0:90 | 0:180 | 0:-90 | 90:0 | -90:0 # #")
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(60*s1.Degree))
+	coverer := NewRegionCoverer()
+	coverer.MaxCells = 1000
+	covering := coverer.Covering(region)
+	if len(covering) != 6 {
+		t.Fatalf("len(Covering(buffered region)) = %d, want 6", len(covering))
+	}
+	for _, id := range covering {
+		if !id.isFace() {
+			t.Errorf("covering cell %v is not a face cell", id)
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionPointZeroRadius(t *testing.T) {
+	// Test that buffering a point by zero still contains the point.
+	for i := 0; i < 100; i++ {
+		p := randomPoint()
+		index := NewShapeIndex()
+		index.Add(&PointVector{p})
+		region := NewShapeIndexBufferedRegion(index, s1.ChordAngle(0))
+		if !region.ContainsPoint(p) {
+			t.Errorf("region.ContainsPoint(%v) = false, want true", p)
+		}
+		coverer := NewRegionCoverer()
+		coverer.MaxCells = 1
+		covering := coverer.Covering(region)
+		if !covering.ContainsPoint(p) {
+			t.Errorf("Covering(%v) = %v, does not contain the point", p, covering)
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionBounds(t *testing.T) {
+	index := makeShapeIndex("# 10:10, 10:20, 20:20 #")
+	radius := s1.ChordAngleFromAngle(kmToAngle(100))
+	region := NewShapeIndexBufferedRegion(index, radius)
+
+	// The polyline vertices buffered by the radius should be within all of
+	// the bounds.
+	capBound := region.CapBound()
+	rectBound := region.RectBound()
+	cellUnion := CellUnion(region.CellUnionBound())
+	for _, ll := range []LatLng{
+		LatLngFromDegrees(10, 10),
+		LatLngFromDegrees(20, 20),
+	} {
+		center := PointFromLatLng(ll)
+		for _, v := range CapFromCenterChordAngle(center, radius.Predecessor()).CellUnionBound() {
+			p := CellFromCellID(v).Center()
+			if center.Distance(p) >= radius.Angle() {
+				continue
+			}
+			if !capBound.ContainsPoint(p) {
+				t.Errorf("%v.ContainsPoint(%v) = false, want true", capBound, p)
+			}
+			if !rectBound.ContainsPoint(p) {
+				t.Errorf("%v.ContainsPoint(%v) = false, want true", rectBound, p)
+			}
+			if !cellUnion.ContainsPoint(p) {
+				t.Errorf("CellUnionBound().ContainsPoint(%v) = false, want true", p)
+			}
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionContainsPoint(t *testing.T) {
+	index := makeShapeIndex("# 0:0, 0:10 # 20:20, 20:30, 30:30")
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(kmToAngle(100)))
+
+	tests := []struct {
+		point string
+		want  bool
+	}{
+		// On the polyline.
+		{"0:5", true},
+		// About 56km from the polyline.
+		{"0.5:5", true},
+		// About 111km from the polyline.
+		{"1:5", false},
+		// About 111km past the end of the polyline.
+		{"0:11", false},
+		// Inside the polygon.
+		{"22:28", true},
+		// About 90km outside the polygon.
+		{"19.2:25", true},
+		// Far from everything.
+		{"-40:100", false},
+	}
+	for _, test := range tests {
+		if got := region.ContainsPoint(parsePoint(test.point)); got != test.want {
+			t.Errorf("region.ContainsPoint(%v) = %v, want %v", test.point, got, test.want)
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionCells(t *testing.T) {
+	index := makeShapeIndex("# 0:0, 0:10 #")
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(kmToAngle(100)))
+
+	tests := []struct {
+		id             CellID
+		wantContains   bool
+		wantIntersects bool
+	}{
+		// A small cell on the polyline.
+		{cellIDFromPoint(parsePoint("0:5")).Parent(15), true, true},
+		// A small cell about 56km from the polyline.
+		{cellIDFromPoint(parsePoint("0.5:5")).Parent(15), true, true},
+		// A small cell far from the polyline.
+		{cellIDFromPoint(parsePoint("5:5")).Parent(15), false, false},
+		// A large cell containing part of the polyline.
+		{cellIDFromPoint(parsePoint("0:5")).Parent(3), false, true},
+	}
+	for _, test := range tests {
+		cell := CellFromCellID(test.id)
+		if got := region.ContainsCell(cell); got != test.wantContains {
+			t.Errorf("region.ContainsCell(%v) = %v, want %v", test.id, got, test.wantContains)
+		}
+		if got := region.IntersectsCell(cell); got != test.wantIntersects {
+			t.Errorf("region.IntersectsCell(%v) = %v, want %v", test.id, got, test.wantIntersects)
+		}
+	}
+}
+
+func TestShapeIndexBufferedRegionCoveringFollowsShape(t *testing.T) {
+	// The covering of a buffered polyline should contain every point within
+	// the radius of the polyline, while being much smaller than the
+	// covering of a cap that contains the whole buffered region.
+	index := makeShapeIndex("# 0:0, 1:1, 2:0, 3:1, 4:0, 5:1, 6:0 #")
+	radius := kmToAngle(5)
+	region := NewShapeIndexBufferedRegion(index, s1.ChordAngleFromAngle(radius))
+
+	coverer := NewRegionCoverer()
+	coverer.MaxCells = 50
+	covering := coverer.Covering(region)
+	capCovering := coverer.Covering(region.CapBound())
+
+	if got, limit := covering.ExactArea(), capCovering.ExactArea()/4; got > limit {
+		t.Errorf("buffered covering area = %v, want less than %v", got, limit)
+	}
+
+	polyline := makePolyline("0:0, 1:1, 2:0, 3:1, 4:0, 5:1, 6:0")
+	for i := 0; i < 200; i++ {
+		// Pick a point along the polyline and move it some distance away.
+		along, _ := polyline.Interpolate(randomFloat64())
+		p := samplePointFromCap(CapFromCenterAngle(along, 0.99*radius))
+		if !region.ContainsPoint(p) {
+			t.Errorf("region.ContainsPoint(%v) = false, want true", p)
+		}
+		if !covering.ContainsPoint(p) {
+			t.Errorf("covering.ContainsPoint(%v) = false, want true", p)
+		}
+	}
+}
@@ -23,9 +23,8 @@ type ShapeIndexRegion struct {
 	iter          *ShapeIndexIterator
 }
 
-// TODO(roberts): Uncomment once implementation is complete.
 // Enforce Region interface satisfaction similar to other types that implement Region.
-// var _ Region = (*ShapeIndexRegion)(nil)
+var _ Region = (*ShapeIndexRegion)(nil)
 
 // CapBound returns a bounding spherical cap for this collection of geometry.
 // This is not guaranteed to be exact.
@@ -123,11 +122,123 @@ func (s *ShapeIndexRegion) coverRange(first, last CellID, cellIDs []CellID) []Ce
 	return append(cellIDs, first.Parent(level))
 }
 
-// TODO(roberts): remaining methods
-/*
-// ContainsCell(target Cell) bool {
-// IntersectsCell(target Cell) bool {
-// ContainsPoint(p Point) bool {
-// contains(id CellID, clipped clippedShape, p Point) bool {
-// anyEdgeIntersects(clipped clippedShape, target Cell) bool {
-*/
+// ContainsCell reports whether the given Cell is contained by the region.
+// It returns true if and only if some shape in the index contains the
+// cell, which means that the shape has dimension 2 (i.e. it is a polygon).
+// Points and polylines never contain a cell.
+//
+// The result is conservative: it may return false for cells that are
+// contained but very close to the boundary of a polygon.
+func (s *ShapeIndexRegion) ContainsCell(target Cell) bool {
+	relation := s.iter.LocateCellID(target.ID())
+
+	// If the relation is Disjoint, then "target" is not contained. Similarly
+	// if the relation is Subdivided then "target" is not contained, since
+	// index cells are subdivided only if they (nearly) intersect too many
+	// edges.
+	if relation != Indexed {
+		return false
+	}
+
+	// Otherwise, the iterator points to an index cell containing "target".
+	// If any shape contains the target cell, we return true.
+	cell := s.iter.IndexCell()
+	for _, clipped := range cell.shapes {
+		// The shape contains the target cell iff the shape contains the cell
+		// center and none of its edges intersects the (padded) cell interior.
+		if s.iter.CellID() == target.ID() {
+			if clipped.numEdges() == 0 && clipped.containsCenter {
+				return true
+			}
+		} else {
+			// It is faster to call anyEdgeIntersects before contains.
+			if s.index.Shape(clipped.shapeID).Dimension() == 2 &&
+				!s.anyEdgeIntersects(clipped, target) &&
+				s.contains(clipped, target.Center()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IntersectsCell reports whether the region intersects the given cell. It
+// returns true if any shape in the index intersects the cell, including
+// points and polylines whose edges pass through it.
+//
+// The result is conservative: it may return true for cells that are very
+// close to but not actually intersecting the geometry.
+func (s *ShapeIndexRegion) IntersectsCell(target Cell) bool {
+	relation := s.iter.LocateCellID(target.ID())
+
+	// If "target" does not overlap any index cell, there is no intersection.
+	if relation == Disjoint {
+		return false
+	}
+
+	// If "target" is subdivided into one or more index cells, then there is
+	// an intersection to within the ShapeIndex error bound.
+	if relation == Subdivided {
+		return true
+	}
+
+	// If "target" is an index cell, there is an intersection because index
+	// cells are created only if they have at least one edge or they are
+	// entirely contained by some shape.
+	if s.iter.CellID() == target.ID() {
+		return true
+	}
+
+	// Test whether any shape intersects the target cell or contains its center.
+	cell := s.iter.IndexCell()
+	for _, clipped := range cell.shapes {
+		if s.anyEdgeIntersects(clipped, target) {
+			return true
+		}
+		if s.contains(clipped, target.Center()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsPoint reports whether the given point is contained by any
+// polygon in the index. Points and polylines are ignored, since the
+// containment test uses the semi-open vertex model.
+func (s *ShapeIndexRegion) ContainsPoint(p Point) bool {
+	if !s.iter.LocatePoint(p) {
+		return false
+	}
+
+	cell := s.iter.IndexCell()
+	for _, clipped := range cell.shapes {
+		if s.contains(clipped, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether the given clipped shape of the index cell that
+// the iterator is positioned at contains the point p.
+func (s *ShapeIndexRegion) contains(clipped *clippedShape, p Point) bool {
+	return s.containsQuery.shapeContains(clipped, s.iter.Center(), p)
+}
+
+// anyEdgeIntersects reports whether any edge of the given clipped shape
+// intersects the interior of the given cell, or comes within the
+// worst-case error tolerance of doing so.
+func (s *ShapeIndexRegion) anyEdgeIntersects(clipped *clippedShape, target Cell) bool {
+	maxError := (faceClipErrorUVCoord + intersectsRectErrorUVDist)
+	bound := target.BoundUV().ExpandedByMargin(maxError)
+	face := target.Face()
+	shape := s.index.Shape(clipped.shapeID)
+	for _, e := range clipped.edges {
+		edge := shape.Edge(e)
+		p0, p1, ok := ClipToPaddedFace(edge.V0, edge.V1, face, maxError)
+		if ok && edgeIntersectsRect(p0, p1, bound) {
+			return true
+		}
+	}
+	return false
+}
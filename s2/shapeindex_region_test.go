@@ -124,9 +124,120 @@ func TestShapeIndexRegionCellUnionBoundOneFace(t *testing.T) {
 	}
 }
 
+func TestShapeIndexRegionContainsCellMultipleShapes(t *testing.T) {
+	id := CellIDFromString("3/0123012301230123012301230123")
+
+	// Add a polygon that is slightly smaller than the cell being tested.
+	index := NewShapeIndex()
+	index.Add(padCell(id, -shapeIndexCellPadding))
+	cell := CellFromCellID(id)
+	if index.Region().ContainsCell(cell) {
+		t.Errorf("%v.ContainsCell(%v) = true, want false", index, cell)
+	}
+
+	// Add a second polygon that is slightly larger than the cell being tested.
+	// Note that ContainsCell should return true if *any* shape contains the cell.
+	index.Add(padCell(id, shapeIndexCellPadding))
+	if !index.Region().ContainsCell(cell) {
+		t.Errorf("%v.ContainsCell(%v) = false, want true", index, cell)
+	}
+
+	// Verify that all children of the cell are also contained.
+	for child := id.ChildBegin(); child != id.ChildEnd(); child = child.Next() {
+		if !index.Region().ContainsCell(CellFromCellID(child)) {
+			t.Errorf("%v.ContainsCell(%v) = false, want true", index, child)
+		}
+	}
+}
+
+func TestShapeIndexRegionIntersectsShrunkenCell(t *testing.T) {
+	target := CellIDFromString("3/0123012301230123012301230123")
+
+	// Add a polygon that is slightly smaller than the cell being tested.
+	index := NewShapeIndex()
+	index.Add(padCell(target, -shapeIndexCellPadding))
+	region := index.Region()
+
+	// Check that the index intersects the cell itself, but not any of the
+	// neighboring cells.
+	if !region.IntersectsCell(CellFromCellID(target)) {
+		t.Errorf("region.IntersectsCell(%v) = false, want true", target)
+	}
+	for _, id := range target.AllNeighbors(target.Level()) {
+		if region.IntersectsCell(CellFromCellID(id)) {
+			t.Errorf("region.IntersectsCell(%v) = true, want false", id)
+		}
+	}
+}
+
+func TestShapeIndexRegionIntersectsExactCell(t *testing.T) {
+	target := CellIDFromString("3/0123012301230123012301230123")
+
+	// Adds a polygon that exactly follows a cell boundary.
+	index := NewShapeIndex()
+	index.Add(padCell(target, 0.0))
+	region := index.Region()
+
+	// Check that the index intersects the cell and all of its neighbors.
+	ids := append([]CellID{target}, target.AllNeighbors(target.Level())...)
+	for _, id := range ids {
+		if !region.IntersectsCell(CellFromCellID(id)) {
+			t.Errorf("region.IntersectsCell(%v) = false, want true", id)
+		}
+	}
+}
+
+func TestShapeIndexRegionContainsPoint(t *testing.T) {
+	index := makeShapeIndex("20:20 # 10:0, 10:10 # 0:0, 0:5, 5:5, 5:0")
+	region := index.Region()
+	tests := []struct {
+		point string
+		want  bool
+	}{
+		// Inside the polygon.
+		{"2:2", true},
+		// Outside every shape.
+		{"30:30", false},
+		// Points and polylines never contain anything.
+		{"20:20", false},
+		{"10:5", false},
+	}
+	for _, test := range tests {
+		if got := region.ContainsPoint(parsePoint(test.point)); got != test.want {
+			t.Errorf("region.ContainsPoint(%s) = %t, want %t", test.point, got, test.want)
+		}
+	}
+}
+
+func TestShapeIndexRegionMixedShapesCovering(t *testing.T) {
+	// A point, a polyline and a polygon indexed together must all be covered
+	// by a covering of the index as a single region.
+	index := makeShapeIndex("20:20 # 10:0, 10:10 # 0:0, 0:5, 5:5, 5:0")
+	region := index.Region()
+	coverer := &RegionCoverer{MaxLevel: 20, MaxCells: 50}
+	covering := coverer.Covering(region)
+	for _, s := range []string{"20:20", "10:0", "10:5", "10:10", "2:2", "0:0"} {
+		if p := parsePoint(s); !covering.ContainsPoint(p) {
+			t.Errorf("covering of %v does not contain %s", index, s)
+		}
+	}
+	if p := parsePoint("30:30"); covering.ContainsPoint(p) {
+		t.Errorf("covering of %v contains 30:30", index)
+	}
+
+	// Cells inside the polygon are in the interior covering, while those
+	// covering the point and the polyline are not.
+	interior := coverer.InteriorCovering(region)
+	if len(interior) == 0 {
+		t.Fatalf("InteriorCovering(%v) is empty", index)
+	}
+	for _, id := range interior {
+		if c := id.Point(); !region.ContainsPoint(c) {
+			t.Errorf("interior covering cell %v center is not contained by the region", id)
+		}
+	}
+}
+
 // TODO(roberts): remaining tests
-// func TestShapeIndexRegionContainsCellMultipleShapes(t *testing.T) { }
-// func TestShapeIndexRegionIntersectsShrunkenCell(t *testing.T){ }
-// func TestShapeIndexRegionIntersectsExactCell(t *testing.T){ }
 // Add VisitIntersectingShapes tests
 // Benchmarks
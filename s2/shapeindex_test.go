@@ -488,6 +488,103 @@ func TestShapeIndexMixedGeometry(t *testing.T) {
 	}
 }
 
+func TestShapeIndexAddAfterBuild(t *testing.T) {
+	index := NewShapeIndex()
+	index.Add(makePolyline("0:0, 2:1, 0:2, 2:3, 0:4"))
+	index.Build()
+	testIteratorMethods(t, index)
+
+	// Adding and removing shapes after the index has been built updates the
+	// existing cells to the same state as building it from scratch.
+	loop := makeLoop("10:10, 10:12, 12:12, 12:10")
+	index.Add(loop)
+	index.Add(makePolyline("1:0, 3:1, 1:2, 3:3, 1:4"))
+	quadraticValidate(t, index)
+	testIteratorMethods(t, index)
+
+	index.Remove(loop)
+	quadraticValidate(t, index)
+	testIteratorMethods(t, index)
+
+	for it := index.Iterator(); !it.Done(); it.Next() {
+		if it.IndexCell().findByShapeID(1) != nil {
+			t.Errorf("index cell %v still refers to shape 1 after it was removed", it.CellID())
+		}
+	}
+
+	// Shapes added after a removal get new IDs beyond the number of shapes.
+	index.Add(makePolyline("10:10, 12:12"))
+	quadraticValidate(t, index)
+	testIteratorMethods(t, index)
+}
+
+func TestShapeIndexRemoveSomeLoops(t *testing.T) {
+	// Add the loops of a polygon to the index, remove every other loop, and
+	// add them back, checking the index after each step.
+	polygon := concentricLoopsPolygon(PointFromCoords(1, 0.5, -0.5), 10, 20)
+	index := NewShapeIndex()
+	for _, l := range polygon.loops {
+		index.Add(l)
+	}
+	quadraticValidate(t, index)
+
+	for i := 0; i < len(polygon.loops); i += 2 {
+		index.Remove(polygon.loops[i])
+	}
+	quadraticValidate(t, index)
+	testIteratorMethods(t, index)
+
+	for i := 0; i < len(polygon.loops); i += 2 {
+		index.Add(polygon.loops[i])
+	}
+	quadraticValidate(t, index)
+	testIteratorMethods(t, index)
+}
+
+func TestShapeIndexRandomUpdates(t *testing.T) {
+	// Apply batches of random additions and removals of loops and polylines,
+	// checking the index after each batch.
+	index := NewShapeIndex()
+	var shapes []Shape
+	for batch := 0; batch < 10; batch++ {
+		for i := 0; i < 5; i++ {
+			center := randomPoint()
+			radius := s1.Angle(randomUniformFloat64(0.001, 0.2))
+			var shape Shape = RegularLoop(center, radius, 4+randomUniformInt(20))
+			if oneIn(3) {
+				pl := Polyline(RegularLoop(center, radius, 4+randomUniformInt(20)).Vertices())
+				shape = &pl
+			}
+			index.Add(shape)
+			shapes = append(shapes, shape)
+		}
+		for i := 0; i < 2 && len(shapes) > 0; i++ {
+			j := randomUniformInt(len(shapes))
+			index.Remove(shapes[j])
+			shapes = append(shapes[:j], shapes[j+1:]...)
+		}
+		quadraticValidate(t, index)
+		testIteratorMethods(t, index)
+	}
+}
+
+func BenchmarkShapeIndexIncrementalAdd(b *testing.B) {
+	// Add small loops one at a time to an index of many loops, applying
+	// the update after each addition, which only updates the cells that
+	// the new loop intersects.
+	index := NewShapeIndex()
+	for i := 0; i < 1000; i++ {
+		index.Add(RegularLoop(randomPoint(), kmToAngle(100), 100))
+	}
+	index.Build()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Add(RegularLoop(randomPoint(), kmToAngle(10), 10))
+		index.Build()
+	}
+}
+
 func TestShapeIndexLoopSpanningThreeFaces(t *testing.T) {
 	const numEdges = 100
 	// Construct two loops consisting of numEdges vertices each, centered
@@ -0,0 +1,214 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"fmt"
+)
+
+// EdgePairVisitor is a visitor that can be used to visit pairs of edges. It
+// is passed two edges and a flag indicating whether the edges cross at a
+// point interior to both edges. It returns false if the visiting should
+// stop, and true otherwise.
+type EdgePairVisitor func(a, b ShapeEdge, isInterior bool) bool
+
+// getShapeEdges returns all the edges that intersect a given index cell.
+func getShapeEdges(index *ShapeIndex, cell *ShapeIndexCell) []ShapeEdge {
+	var shapeEdges []ShapeEdge
+	for _, clipped := range cell.shapes {
+		shape := index.Shape(clipped.shapeID)
+		for _, edgeID := range clipped.edges {
+			shapeEdges = append(shapeEdges, ShapeEdge{
+				ID:   ShapeEdgeID{ShapeID: clipped.shapeID, EdgeID: int32(edgeID)},
+				Edge: shape.Edge(edgeID),
+			})
+		}
+	}
+	return shapeEdges
+}
+
+// VisitCrossingEdgePairs finds all crossing edge pairs in an index and calls
+// the visitor function for each of them. It returns false if the visitor
+// stopped the iteration early, and true otherwise.
+//
+// If crossType is CrossingTypeInterior, only the crossings at a point
+// interior to both edges are visited. CrossingTypeAll also visits the pairs
+// of edges that share a vertex, and CrossingTypeNonAdjacent is like
+// CrossingTypeAll except that pairs of the form (AB, BC) may be skipped.
+//
+// Each pair of edges is visited once for every index cell they share, so
+// the same pair may be visited more than once.
+func VisitCrossingEdgePairs(index *ShapeIndex, crossType CrossingType, visitor EdgePairVisitor) bool {
+	needAdjacent := crossType == CrossingTypeAll
+	for iter := index.Iterator(); !iter.Done(); iter.Next() {
+		shapeEdges := getShapeEdges(index, iter.IndexCell())
+		if !visitCrossings(shapeEdges, crossType, needAdjacent, visitor) {
+			return false
+		}
+	}
+	return true
+}
+
+// visitCrossings visits all the crossing pairs of the given edges.
+func visitCrossings(shapeEdges []ShapeEdge, crossType CrossingType, needAdjacent bool, visitor EdgePairVisitor) bool {
+	numEdges := len(shapeEdges)
+	for i := 0; i+1 < numEdges; i++ {
+		a := shapeEdges[i]
+		j := i + 1
+		// A common situation is that an edge AB is followed by an edge BC. We
+		// only need to visit such crossings if needAdjacent is true (even if
+		// AB and BC belong to different edge chains).
+		if !needAdjacent && a.Edge.V1 == shapeEdges[j].Edge.V0 {
+			j++
+			if j >= numEdges {
+				break
+			}
+		}
+		crosser := NewEdgeCrosser(a.Edge.V0, a.Edge.V1)
+		for ; j < numEdges; j++ {
+			b := shapeEdges[j]
+			sign := crosser.CrossingSign(b.Edge.V0, b.Edge.V1)
+			if sign == Cross || (sign == MaybeCross && crossType != CrossingTypeInterior) {
+				if !visitor(a, b, sign == Cross) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// findSelfIntersection checks the shape in the given index for loop
+// self-intersections and loop pairs that cross (including duplicate edges
+// and vertices), and returns an error describing the first problem found.
+// The index must contain at most one shape, whose edges are grouped into
+// closed loops such as a Loop or Polygon.
+func findSelfIntersection(index *ShapeIndex) error {
+	if index == nil || index.Len() == 0 {
+		return nil
+	}
+	shape := index.Shape(0)
+
+	// Visit all crossing pairs except possibly for ones of the form (AB, BC),
+	// since such pairs are very common and findCrossingError only needs pairs
+	// of the form (AB, AC).
+	var err error
+	VisitCrossingEdgePairs(index, CrossingTypeNonAdjacent, func(a, b ShapeEdge, isInterior bool) bool {
+		err = findCrossingError(shape, a, b, isInterior)
+		return err == nil
+	})
+	return err
+}
+
+// findCrossingError returns an error if the given pair of edges from the
+// given shape constitutes a self-intersection or a crossing between two
+// loops, and nil otherwise.
+func findCrossingError(shape Shape, a, b ShapeEdge, isInterior bool) error {
+	isPolygon := shape.NumChains() > 1
+	ap := shape.ChainPosition(int(a.ID.EdgeID))
+	bp := shape.ChainPosition(int(b.ID.EdgeID))
+
+	if isInterior {
+		if ap.ChainID != bp.ChainID {
+			return fmt.Errorf("loop %d edge %d crosses loop %d edge %d", ap.ChainID, ap.Offset, bp.ChainID, bp.Offset)
+		}
+		return loopError(fmt.Errorf("edge %d crosses edge %d", ap.Offset, bp.Offset), ap, isPolygon)
+	}
+
+	// Loops are not allowed to have duplicate vertices, and separate loops
+	// are not allowed to share edges or cross at vertices.
+	switch LoopsAtSharedVertex(shape, a, b) {
+	case SharedVertexDuplicate:
+		return loopError(fmt.Errorf("edge %d has duplicate vertex with edge %d", ap.Offset, bp.Offset), ap, isPolygon)
+	case SharedVertexEdge:
+		// The second edge index is sometimes off by one, hence "near".
+		return fmt.Errorf("loop %d edge %d has duplicate near loop %d edge %d", ap.ChainID, ap.Offset, bp.ChainID, bp.Offset)
+	case SharedVertexCrossing:
+		return fmt.Errorf("loop %d edge %d crosses loop %d edge %d", ap.ChainID, ap.Offset, bp.ChainID, bp.Offset)
+	}
+	return nil
+}
+
+// SharedVertexRelation describes how the loops of two edges of a shape,
+// both ending at the same vertex, meet at that vertex.
+type SharedVertexRelation int
+
+const (
+	// SharedVertexNone is reported for edges that don't end at the same
+	// vertex, and for loops that only touch there.
+	SharedVertexNone SharedVertexRelation = iota
+	// SharedVertexDuplicate is reported for two edges of the same loop,
+	// which then has a duplicate vertex.
+	SharedVertexDuplicate
+	// SharedVertexEdge is reported for loops that share an edge at the
+	// vertex.
+	SharedVertexEdge
+	// SharedVertexCrossing is reported for loops that cross each other at
+	// the vertex.
+	SharedVertexCrossing
+)
+
+// LoopsAtSharedVertex reports how the loops of the given edges of the shape
+// meet at the end vertex the edges share. Only pairs of edges with the same
+// end vertex are considered, so that each vertex is checked once.
+func LoopsAtSharedVertex(shape Shape, a, b ShapeEdge) SharedVertexRelation {
+	if a.Edge.V1 != b.Edge.V1 {
+		return SharedVertexNone
+	}
+	ap := shape.ChainPosition(int(a.ID.EdgeID))
+	bp := shape.ChainPosition(int(b.ID.EdgeID))
+	if ap.ChainID == bp.ChainID {
+		return SharedVertexDuplicate
+	}
+
+	aLen := shape.Chain(ap.ChainID).Length
+	bLen := shape.Chain(bp.ChainID).Length
+	aNext := ap.Offset + 1
+	if aNext == aLen {
+		aNext = 0
+	}
+	bNext := bp.Offset + 1
+	if bNext == bLen {
+		bNext = 0
+	}
+	a2 := shape.ChainEdge(ap.ChainID, aNext).V1
+	b2 := shape.ChainEdge(bp.ChainID, bNext).V1
+
+	if a.Edge.V0 == b.Edge.V0 || a.Edge.V0 == b2 {
+		return SharedVertexEdge
+	}
+
+	// Since ShapeIndex loops are oriented such that the polygon interior is
+	// always on the left, we need to handle the case where one wedge contains
+	// the complement of the other wedge. This is not specifically detected by
+	// WedgeRelation, so there are two cases to check for.
+	//
+	// Note that we don't need to maintain any state regarding loop crossings
+	// because duplicate edges are detected and rejected above.
+	if WedgeRelation(a.Edge.V0, a.Edge.V1, a2, b.Edge.V0, b2) == WedgeProperlyOverlaps &&
+		WedgeRelation(a.Edge.V0, a.Edge.V1, a2, b2, b.Edge.V0) == WedgeProperlyOverlaps {
+		return SharedVertexCrossing
+	}
+	return SharedVertexNone
+}
+
+// loopError prefixes the given error with the loop it occurred in when the
+// shape has more than one loop.
+func loopError(err error, ap ChainPosition, isPolygon bool) error {
+	if isPolygon {
+		return fmt.Errorf("loop %d: %w", ap.ChainID, err)
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type edgePair struct {
+	a, b ShapeEdgeID
+}
+
+// getCrossingEdgePairsBruteForce returns the set of crossing edge pairs in
+// the index by testing every pair of edges.
+func getCrossingEdgePairsBruteForce(index *ShapeIndex, crossType CrossingType) []edgePair {
+	type shapeEdge struct {
+		id   ShapeEdgeID
+		edge Edge
+	}
+	var edges []shapeEdge
+	for i := int32(0); i < int32(index.Len()); i++ {
+		shape := index.Shape(i)
+		for e := 0; e < shape.NumEdges(); e++ {
+			edges = append(edges, shapeEdge{ShapeEdgeID{i, int32(e)}, shape.Edge(e)})
+		}
+	}
+
+	var result []edgePair
+	for i, a := range edges {
+		for _, b := range edges[i+1:] {
+			sign := CrossingSign(a.edge.V0, a.edge.V1, b.edge.V0, b.edge.V1)
+			if sign == Cross || (sign == MaybeCross && crossType == CrossingTypeAll) {
+				result = append(result, edgePair{a.id, b.id})
+			}
+		}
+	}
+	return result
+}
+
+func testGetCrossingEdgePairs(t *testing.T, index *ShapeIndex, crossType CrossingType) {
+	t.Helper()
+	want := getCrossingEdgePairsBruteForce(index, crossType)
+
+	seen := make(map[edgePair]bool)
+	var got []edgePair
+	VisitCrossingEdgePairs(index, crossType, func(a, b ShapeEdge, isInterior bool) bool {
+		pair := edgePair{a.ID, b.ID}
+		if b.ID.Cmp(a.ID) < 0 {
+			pair = edgePair{b.ID, a.ID}
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			got = append(got, pair)
+		}
+		return true
+	})
+
+	sortPairs := func(pairs []edgePair) {
+		sort.Slice(pairs, func(i, j int) bool {
+			if c := pairs[i].a.Cmp(pairs[j].a); c != 0 {
+				return c < 0
+			}
+			return pairs[i].b.Cmp(pairs[j].b) < 0
+		})
+	}
+	sortPairs(got)
+	sortPairs(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisitCrossingEdgePairs(%v) = %v, want %v", crossType, got, want)
+	}
+}
+
+func TestVisitCrossingEdgePairsNoIntersections(t *testing.T) {
+	index := NewShapeIndex()
+	testGetCrossingEdgePairs(t, index, CrossingTypeAll)
+	testGetCrossingEdgePairs(t, index, CrossingTypeInterior)
+}
+
+func TestVisitCrossingEdgePairsEdgeGrid(t *testing.T) {
+	const gridSize = 10 // (gridSize + 1) * (gridSize + 1) crossings
+	index := NewShapeIndex()
+	edges := &edgeVectorShape{}
+	for i := 0; i <= gridSize; i++ {
+		f := float64(i)
+		edges.Add(PointFromLatLng(LatLngFromDegrees(0, f)), PointFromLatLng(LatLngFromDegrees(gridSize, f)))
+		edges.Add(PointFromLatLng(LatLngFromDegrees(f, 0)), PointFromLatLng(LatLngFromDegrees(f, gridSize)))
+	}
+	index.Add(edges)
+	testGetCrossingEdgePairs(t, index, CrossingTypeAll)
+	testGetCrossingEdgePairs(t, index, CrossingTypeInterior)
+}
+
+func TestVisitCrossingEdgePairsStopsEarly(t *testing.T) {
+	index := makeShapeIndex("# 0:0, 10:10 | 0:10, 10:0 | 0:5, 10:5 #")
+	count := 0
+	if VisitCrossingEdgePairs(index, CrossingTypeAll, func(a, b ShapeEdge, isInterior bool) bool {
+		count++
+		return false
+	}) {
+		t.Errorf("VisitCrossingEdgePairs() = true, want false when the visitor stops")
+	}
+	if count != 1 {
+		t.Errorf("visitor was called %d times, want 1", count)
+	}
+}
+
+func TestFindSelfIntersection(t *testing.T) {
+	tests := []struct {
+		loop    string
+		wantErr bool
+	}{
+		{"0:0, 0:10, 10:10, 10:0", false},
+		{"0:0, 0:10, 10:0, 10:10", true},
+		{"0:0, 0:10, 5:5, 10:10, 10:0, 5:5", true},
+	}
+	for _, test := range tests {
+		index := NewShapeIndex()
+		index.Add(makeLoop(test.loop))
+		if err := findSelfIntersection(index); (err != nil) != test.wantErr {
+			t.Errorf("findSelfIntersection(%q) = %v, want error: %v", test.loop, err, test.wantErr)
+		}
+	}
+}
+
+func TestLoopsAtSharedVertex(t *testing.T) {
+	tests := []struct {
+		polygon string
+		want    []SharedVertexRelation
+	}{
+		// loops touching at a vertex
+		{"0:0, 0:1, 1:1, 1:0; 1:1, 1:2, 2:2, 2:1", nil},
+		// a loop with a duplicate vertex
+		{"0:0, 0:2, 1:1, 2:2, 2:0, 1:1", []SharedVertexRelation{SharedVertexDuplicate}},
+		// loops sharing an edge
+		{"0:0, 0:1, 1:1, 1:0; 0:1, 0:2, 1:2, 1:1", []SharedVertexRelation{SharedVertexEdge}},
+		// loops crossing at a vertex
+		{"0:0, 0:2, 2:2, 2:0; 1:1, 2:2, 4:3", []SharedVertexRelation{SharedVertexCrossing}},
+	}
+	for _, test := range tests {
+		shape := makeLaxPolygon(test.polygon)
+		var got []SharedVertexRelation
+		for i := 0; i < shape.NumEdges(); i++ {
+			for j := i + 1; j < shape.NumEdges(); j++ {
+				a := ShapeEdge{ID: ShapeEdgeID{EdgeID: int32(i)}, Edge: shape.Edge(i)}
+				b := ShapeEdge{ID: ShapeEdgeID{EdgeID: int32(j)}, Edge: shape.Edge(j)}
+				if rel := LoopsAtSharedVertex(shape, a, b); rel != SharedVertexNone {
+					got = append(got, rel)
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("LoopsAtSharedVertex(%q) = %v, want %v", test.polygon, got, test.want)
+		}
+	}
+}